@@ -7,12 +7,41 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/tink3rlabs/magic/types"
-	openapigodoc "github.com/tink3rlabs/openapi-godoc"
+	"gopkg.in/yaml.v3"
+
+	"todo-service/pkg/openapi"
 )
 
+// configPath is the configuration the servers of the generated definition are read from, the server
+// replaces them with the service.url of the configuration it runs with
+const configPath = "./config/development.yaml"
+
+func serviceURL() (string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", err
+	}
+	config := struct {
+		Service struct {
+			URL string `yaml:"url"`
+		} `yaml:"service"`
+	}{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("invalid config %s: %v", configPath, err)
+	}
+	return config.Service.URL, nil
+}
+
 func generateOApiSpec() ([]byte, error) {
+	url, err := serviceURL()
+	if err != nil {
+		return nil, err
+	}
+	servers := []any{}
+	if url != "" {
+		servers = append(servers, map[string]any{"url": url})
+	}
+
 	securitySchemasData := []byte(`
 	{
 		"petstore_auth": {
@@ -35,39 +64,41 @@ func generateOApiSpec() ([]byte, error) {
 	}`)
 
 	var securitySchemas map[string]interface{}
-	err := json.Unmarshal(securitySchemasData, &securitySchemas)
+	err = json.Unmarshal(securitySchemasData, &securitySchemas)
 	if err != nil {
 		return nil, err
 	}
 
-	apiDefinition := openapigodoc.OpenAPIDefinition{
-		OpenAPI: "3.0.3",
-		Info: openapigodoc.Info{
-			Title:       "Todo API",
-			Version:     "1.0.0",
-			Description: "Simple example Todo API",
-			Contact:     &openapi3.Contact{Email: "developer@example.com"},
-			License:     &openapi3.License{Name: "Apache 2.0", URL: "http://www.apache.org/licenses/LICENSE-2.0.html"},
+	apiDefinition := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Todo API",
+			"version":     "1.0.0",
+			"description": "Simple example Todo API",
+			"contact":     map[string]any{"email": "developer@example.com"},
+			"license":     map[string]any{"name": "Apache 2.0", "url": "http://www.apache.org/licenses/LICENSE-2.0.html"},
 		},
-		Servers: []openapigodoc.Server{{URL: "http://localhost:8080"}},
-		Tags: []openapigodoc.Tag{
-			{
-				Name:         "todos",
-				Description:  "Manage Todo items",
-				ExternalDocs: &openapi3.ExternalDocs{URL: "http://example.com", Description: "Find out more"},
+		"servers": servers,
+		"tags": []any{
+			map[string]any{
+				"name":         "todos",
+				"description":  "Manage Todo items",
+				"externalDocs": map[string]any{"url": "http://example.com", "description": "Find out more"},
+			},
+			map[string]any{
+				"name":        "graphql",
+				"description": "Query and change Todo items with GraphQL",
 			},
 		},
-		ExternalDocs: openapigodoc.ExternalDocs{Description: "Find out more", URL: "http://example.com"},
-		Components: openapigodoc.Components{
-			SecuritySchemes: securitySchemas,
+		"externalDocs": map[string]any{"description": "Find out more", "url": "http://example.com"},
+		"components": map[string]any{
+			"securitySchemes": securitySchemas,
 		},
 	}
 
-	definition, err := openapigodoc.GenerateOpenApiDoc(apiDefinition, false)
-	if err != nil {
-		return nil, err
-	}
-	return definition, nil
+	// The definition is built the way the tests build it (see pkg/openapi/openapitest), merged
+	// with the tink3rlabs magic definition
+	return openapi.Generate(".", apiDefinition)
 }
 
 func main() {
@@ -80,22 +111,14 @@ func main() {
 		fmt.Printf("error generating OpenAPI definition: %v\n", err)
 	}
 
-	fmt.Println("Merginig local OpenAPI definition with tink3rlabs magic definition")
-	finalDefinition, err := types.MergeOpenAPIDefinitions(openApiSpec)
-	if err != nil {
-		fmt.Printf("error merging OpenAPI definition with common definitions: %v\n", err)
-	}
-
 	fmt.Println("Validating OpenAPI definition")
-	valid, err := openapigodoc.ValidateOpenApiDoc(finalDefinition)
-	if valid {
-		fmt.Println("OpenAPI definition validated successfully")
-	} else {
+	if err := openapi.Validate(openApiSpec); err != nil {
 		fmt.Printf("error validating OpenAPI definition: %v\n", err)
 		return
 	}
+	fmt.Println("OpenAPI definition validated successfully")
 
-	err = os.WriteFile(path, finalDefinition, 0644)
+	err = os.WriteFile(path, openApiSpec, 0644)
 	if err != nil {
 		fmt.Printf("error writing OpenAPI definition to file: %v", err)
 	}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"todo-service/pkg/client"
+	"todo-service/pkg/types"
+)
+
+const defaultServerURL = "http://localhost:8080"
+
+var todoCommand = &cobra.Command{
+	Use:   "todo",
+	Short: "Manage the todos of a remote Todo server",
+	Long: `Manage the todos of a remote Todo server through its REST API.
+
+The server defaults to the service.url of the configuration, use --server to talk to another one.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// The arguments were valid, don't print the usage along with errors returned by the server
+		cmd.SilenceUsage = true
+	},
+}
+
+var todoListCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List todos",
+	Args:  cobra.NoArgs,
+	RunE:  runTodoList,
+}
+
+var todoAddCommand = &cobra.Command{
+	Use:   "add <summary>",
+	Short: "Add a todo",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runTodoAdd,
+}
+
+var todoDoneCommand = &cobra.Command{
+	Use:   "done <id>...",
+	Short: "Mark todos as done",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runTodoDone,
+}
+
+var todoRemoveCommand = &cobra.Command{
+	Use:     "rm <id>...",
+	Aliases: []string{"remove", "delete"},
+	Short:   "Delete todos",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runTodoRemove,
+}
+
+var todoEditCommand = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Change the fields of a todo",
+	Long:  `Change the fields of a todo, only the fields whose flag is set are changed.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTodoEdit,
+}
+
+func init() {
+	todoCommand.PersistentFlags().String("server", "", "The URL of the Todo server (defaults to service.url)")
+	todoCommand.PersistentFlags().Duration("timeout", 30*time.Second, "How long to wait for the server")
+
+	todoListCommand.Flags().Int("limit", 0, "The maximum number of todos to list, 0 lists every todo")
+	todoListCommand.Flags().Bool("pending", false, "Only list the todos that aren't done")
+
+	todoAddCommand.Flags().String("due", "", "The time the todo is due (RFC 3339, e.g. 2024-07-02T17:00:00Z)")
+	todoAddCommand.Flags().Int("priority", 0, "The priority from 1 (highest) to 9 (lowest)")
+
+	todoDoneCommand.Flags().Bool("undo", false, "Mark the todos as not done instead")
+
+	todoEditCommand.Flags().String("summary", "", "The new summary")
+	todoEditCommand.Flags().Bool("done", false, "Whether the todo is done")
+	todoEditCommand.Flags().String("due", "", `The new due time (RFC 3339), "none" clears it`)
+	todoEditCommand.Flags().Int("priority", 0, "The new priority from 1 (highest) to 9 (lowest), 0 clears it")
+
+	todoCommand.AddCommand(todoListCommand, todoAddCommand, todoDoneCommand, todoRemoveCommand, todoEditCommand)
+}
+
+func newClient(cmd *cobra.Command) (*client.Client, context.Context, context.CancelFunc, error) {
+	server, _ := cmd.Flags().GetString("server")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if server == "" {
+		server = viper.GetString("service.url")
+	}
+	if server == "" {
+		server = defaultServerURL
+	}
+
+	c, err := client.New(server)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return c, ctx, cancel, nil
+}
+
+func runTodoList(cmd *cobra.Command, args []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+	pending, _ := cmd.Flags().GetBool("pending")
+
+	c, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDONE\tPRIORITY\tDUE\tSUMMARY")
+	listed := 0
+	it := c.Todos(ctx, 100)
+	for (limit <= 0 || listed < limit) && it.Next() {
+		t := it.Todo()
+		if pending && t.Done {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.Id, formatDone(t.Done), formatPriority(t.Priority), formatDue(t.Due), t.Summary)
+		listed++
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func runTodoAdd(cmd *cobra.Command, args []string) error {
+	dueFlag, _ := cmd.Flags().GetString("due")
+	priority, _ := cmd.Flags().GetInt("priority")
+
+	update := types.TodoUpdate{Summary: strings.Join(args, " "), Priority: priority}
+	if dueFlag != "" {
+		due, err := time.Parse(time.RFC3339, dueFlag)
+		if err != nil {
+			return fmt.Errorf("invalid due time: %v", err)
+		}
+		update.Due = &due
+	}
+
+	c, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	created, err := c.CreateTodo(ctx, update)
+	if err != nil {
+		return err
+	}
+	fmt.Println(created.Id)
+	return nil
+}
+
+func runTodoDone(cmd *cobra.Command, args []string) error {
+	undo, _ := cmd.Flags().GetBool("undo")
+
+	c, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	for _, id := range args {
+		if err := c.UpdateTodo(ctx, id, client.Patch{client.SetDone(!undo)}); err != nil {
+			return fmt.Errorf("failed to update todo %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func runTodoRemove(cmd *cobra.Command, args []string) error {
+	c, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	for _, id := range args {
+		if err := c.DeleteTodo(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete todo %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func runTodoEdit(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	patch := client.Patch{}
+	if flags.Changed("summary") {
+		summary, _ := flags.GetString("summary")
+		patch = append(patch, client.SetSummary(summary))
+	}
+	if flags.Changed("done") {
+		done, _ := flags.GetBool("done")
+		patch = append(patch, client.SetDone(done))
+	}
+	if flags.Changed("due") {
+		dueFlag, _ := flags.GetString("due")
+		if dueFlag == "none" {
+			patch = append(patch, client.SetDue(nil))
+		} else {
+			due, err := time.Parse(time.RFC3339, dueFlag)
+			if err != nil {
+				return fmt.Errorf("invalid due time: %v", err)
+			}
+			patch = append(patch, client.SetDue(&due))
+		}
+	}
+	if flags.Changed("priority") {
+		priority, _ := flags.GetInt("priority")
+		patch = append(patch, client.SetPriority(priority))
+	}
+	if len(patch) == 0 {
+		return fmt.Errorf("nothing to change, set at least one of --summary, --done, --due or --priority")
+	}
+
+	c, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return c.UpdateTodo(ctx, args[0], patch)
+}
+
+func formatDone(done bool) string {
+	if done {
+		return "x"
+	}
+	return ""
+}
+
+func formatPriority(priority int) string {
+	if priority == 0 {
+		return ""
+	}
+	return fmt.Sprint(priority)
+}
+
+func formatDue(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	return due.Local().Format("2006-01-02 15:04")
+}
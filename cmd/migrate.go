@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"todo-service/pkg/migrations"
+)
+
+var migrateCommand = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database migrations",
+	Long: `Manage the database migrations of the configured storage adapter.
+
+Migrations are read from the files under config/migrations/{mysql,postgresql,sqlite} that were
+embedded in the binary at build time.`,
+}
+
+var migrateUpCommand = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrator, err := newMigrator()
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("applied %s\n", m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	},
+}
+
+var migrateDownCommand = &cobra.Command{
+	Use:   "down [N]",
+	Short: "Roll back the last N applied migrations (defaults to 1)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n := 1
+		if len(args) == 1 {
+			var err error
+			n, err = strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("N must be a positive number, got %s", args[0])
+			}
+		}
+
+		migrator, err := newMigrator()
+		if err != nil {
+			return err
+		}
+		rolledBack, err := migrator.Down(n)
+		for _, m := range rolledBack {
+			fmt.Printf("rolled back %s\n", m.Name)
+		}
+		if err == nil && len(rolledBack) == 0 {
+			fmt.Println("no applied migrations")
+		}
+		return err
+	},
+}
+
+var migrateStatusCommand = &cobra.Command{
+	Use:   "status",
+	Short: "Show which migrations were applied",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrator, err := newMigrator()
+		if err != nil {
+			return err
+		}
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tDESCRIPTION\tAPPLIED AT")
+		for _, m := range status {
+			appliedAt := "pending"
+			if m.Applied {
+				appliedAt = m.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", m.Id, m.Name, m.Description, appliedAt)
+		}
+		return w.Flush()
+	},
+}
+
+var migrateCreateCommand = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Scaffold a new migration file for every SQL provider",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cmd.Flags().GetString("dir")
+		if err != nil {
+			return err
+		}
+		created, err := migrations.Create(dir, args[0])
+		for _, p := range created {
+			fmt.Printf("created %s\n", p)
+		}
+		return err
+	},
+}
+
+func init() {
+	migrateCreateCommand.Flags().String("dir", migrations.Dir, "The directory holding the migration files of every provider")
+	migrateCommand.AddCommand(migrateUpCommand, migrateDownCommand, migrateStatusCommand, migrateCreateCommand)
+}
+
+func newMigrator() (*migrations.Migrator, error) {
+	storageAdapter, err := newStorageAdapter()
+	if err != nil {
+		return nil, err
+	}
+	return migrations.NewMigrator(storageAdapter, ConfigFS)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/tink3rlabs/magic/logger"
+	"github.com/tink3rlabs/magic/storage"
 )
 
 var ConfigFS embed.FS
@@ -37,6 +38,10 @@ func init() {
 		os.Exit(1)
 	}
 	rootCmd.AddCommand(serverCommand)
+	rootCmd.AddCommand(migrateCommand)
+	rootCmd.AddCommand(exportCommand)
+	rootCmd.AddCommand(importCommand)
+	rootCmd.AddCommand(todoCommand)
 }
 
 func initConfig() {
@@ -79,3 +84,14 @@ func loggerConfig() *logger.Config {
 		JSON:  json,
 	}
 }
+
+func newStorageAdapter() (storage.StorageAdapter, error) {
+	storageAdapter, err := storage.StorageAdapterFactory{}.GetInstance(
+		storage.StorageAdapterType(viper.GetString("storage.type")),
+		viper.GetStringMapString("storage.config"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage adapter instance: %v", err)
+	}
+	return storageAdapter, nil
+}
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"math/rand/v2"
+	"net"
 	"net/http"
 	"time"
 
@@ -18,10 +20,17 @@ import (
 	"github.com/tink3rlabs/magic/health"
 	"github.com/tink3rlabs/magic/leadership"
 	"github.com/tink3rlabs/magic/logger"
-	"github.com/tink3rlabs/magic/middlewares"
 	"github.com/tink3rlabs/magic/storage"
+	"google.golang.org/grpc"
 
+	"todo-service/pkg/blob"
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/gql"
+	"todo-service/pkg/middlewares"
+	"todo-service/pkg/migrations"
+	"todo-service/pkg/openapi"
 	"todo-service/pkg/routes"
+	"todo-service/pkg/rpc"
 )
 
 var serverCommand = &cobra.Command{
@@ -32,33 +41,72 @@ var serverCommand = &cobra.Command{
 
 func init() {
 	serverCommand.Flags().StringP("port", "p", "8080", "The port on which the Todo server will listen on")
+	serverCommand.Flags().Bool("skip-migrations", false, "Don't apply pending migrations on startup, use when migrations run as a separate deploy step")
 }
 
-func initRoutes() *chi.Mux {
+func initRoutes(todoService todo.TodoService, validator *openapi.Validator) *chi.Mux {
 	router := chi.NewRouter()
 	router.Use(
 		render.SetContentType(render.ContentTypeJSON), // Set content-Type headers as application/json
 		middleware.Logger,          // Log API request calls
 		middleware.RedirectSlashes, // Redirect slashes to no slash URL versions
 		middleware.Recoverer,       // Recover from panics without crashing server
+		middlewares.Timeout(viper.GetDuration("service.timeout"), "/graphql"), // Cancel requests that take too long, GraphQL times out event streams itself
+		middlewares.Actor(actorHeader()),                                      // Record who makes the request
 		cors.Handler(cors.Options{
 			AllowedOrigins:   []string{"https://*", "http://*"},
 			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-			ExposedHeaders:   []string{"Link"},
+			ExposedHeaders:   []string{"Link", "Undo-Token"},
 			AllowCredentials: false,
 			MaxAge:           300, // Maximum value not ignored by any of major browsers
 		}),
+		validator.Middleware, // Reject requests (and in development responses) that don't match the OpenAPI definition
 	)
 
-	t := routes.NewTodoRouter()
+	t := routes.NewTodoRouter(todoService, viper.GetInt("service.maxLimit"), viper.GetInt64("service.maxImportSize"))
+	c := routes.NewCalendarRouter(todoService, viper.GetStringSlice("calendar.tokens"))
+	d := routes.NewCalDAVRouter(todoService, "/caldav")
+	g := routes.NewGraphQLRouter(todoService, graphQLLimits(), viper.GetDuration("service.timeout"))
+	u := routes.NewUndoRouter(todoService)
+	s := routes.NewStatsRouter(todoService)
 	router.Route("/", func(r chi.Router) {
 		r.Mount("/todos", t.Router)
+		r.Mount("/todos.ics", c.Router)
+		r.Mount("/caldav", d.Router)
+		r.Mount("/graphql", g.Router)
+		r.Mount("/undo", u.Router)
+		r.Mount("/stats", s.Router)
+		// Lets CalDAV clients find the server from its host name (RFC 6764)
+		r.Handle("/.well-known/caldav", http.RedirectHandler("/caldav", http.StatusMovedPermanently))
 	})
 
 	return router
 }
 
+// actorHeader returns the header naming the user making a request
+func actorHeader() string {
+	if header := viper.GetString("service.actorHeader"); header != "" {
+		return header
+	}
+	return "X-Forwarded-User"
+}
+
+// graphQLLimits returns the configured GraphQL limits, limits that aren't configured keep their
+// default rather than being disabled
+func graphQLLimits() gql.Limits {
+	limits := gql.DefaultLimits
+	if viper.IsSet("graphql.maxDepth") {
+		limits.MaxDepth = viper.GetInt("graphql.maxDepth")
+	}
+	if viper.IsSet("graphql.maxComplexity") {
+		limits.MaxComplexity = viper.GetInt("graphql.maxComplexity")
+	}
+	// Pages are as large as the pages of the REST API
+	limits.MaxPageSize = viper.GetInt("service.maxLimit")
+	return limits
+}
+
 func createScheduler() {
 	slog.Info("strating scheduler")
 	// create a scheduler
@@ -90,22 +138,52 @@ func runServer(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load OpenAPI definition, did you forget to run go generate?: %v", err)
 	}
+	// The definition lists the URL the service is reachable at in this environment
+	if serviceURL := viper.GetString("service.url"); serviceURL != "" {
+		if openApiSpec, err = openapi.WithServers(openApiSpec, serviceURL); err != nil {
+			return err
+		}
+	}
+	validator, err := openapi.NewValidator(openApiSpec, viper.GetBool("openapi.validateResponses"))
+	if err != nil {
+		return err
+	}
+	openApiYAML, err := openapi.ToYAML(openApiSpec)
+	if err != nil {
+		return err
+	}
+	docsIndex, err := ConfigFS.ReadFile("config/docs/index.html")
+	if err != nil {
+		return fmt.Errorf("failed to load the API docs page: %v", err)
+	}
 
 	// Random sleep between 0 to 30 seconds to handle multiple instances starting at the same time
 	sleep := rand.IntN(30)
 	slog.Info("Sleeping to handle multiple instances starting at the same time", slog.Int("sleep_duration_sec", sleep))
 	time.Sleep(time.Duration(sleep) * time.Second)
 
-	storageAdapter, err := storage.StorageAdapterFactory{}.GetInstance(
-		storage.StorageAdapterType(viper.GetString("storage.type")),
-		viper.GetStringMapString("storage.config"),
-	)
-
+	storageAdapter, err := newStorageAdapter()
 	if err != nil {
-		panic("failed to get storage adapter instance")
+		return err
 	}
 
-	storage.NewDatabaseMigration(storageAdapter).Migrate()
+	skipMigrations, err := cmd.Flags().GetBool("skip-migrations")
+	if err != nil {
+		return err
+	}
+	if skipMigrations {
+		slog.Info("skipping migrations")
+	} else if storageAdapter.GetType() == storage.DYNAMODB {
+		slog.Info(fmt.Sprintf(`using %s storage adapter, migrations are not supported`, storageAdapter.GetType()))
+	} else {
+		migrator, err := migrations.NewMigrator(storageAdapter, ConfigFS)
+		if err != nil {
+			return err
+		}
+		if _, err := migrator.Up(); err != nil {
+			return fmt.Errorf("failed to run migrations: %v", err)
+		}
+	}
 
 	electionProps := leadership.LeaderElectionProps{
 		HeartbeatInterval: viper.GetDuration("leadership.heartbeat"),
@@ -127,13 +205,42 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	router := initRoutes()
+	blobStore, err := blob.New(blob.StoreType(viper.GetString("attachments.store.type")), viper.GetStringMapString("attachments.store.config"))
+	if err != nil {
+		return fmt.Errorf("failed to create the attachments blob store: %v", err)
+	}
+
+	workflow := todo.Workflow{}
+	if err := viper.UnmarshalKey("workflow", &workflow); err != nil {
+		return fmt.Errorf("failed to read the workflow: %v", err)
+	}
 
-	router.Get("/api-docs", func(w http.ResponseWriter, r *http.Request) {
-		if _, responseFailed := w.Write(openApiSpec); responseFailed != nil {
-			slog.Error("failed responding to /api-docs:", slog.Any("error", responseFailed))
-		}
+	todoService, err := todo.NewTodoService(todo.TodoServiceProps{
+		Storage:    storageAdapter,
+		Logger:     slog.Default(),
+		UndoWindow: viper.GetDuration("service.undoWindow"),
+		UndoKey:    []byte(viper.GetString("service.undoKey")),
+		Blobs:      blobStore,
+		AttachmentLimits: todo.AttachmentLimits{
+			MaxSize:      viper.GetInt64("attachments.maxSize"),
+			ContentTypes: viper.GetStringSlice("attachments.contentTypes"),
+		},
+		Users:                 viper.GetStringSlice("service.users"),
+		Workflow:              workflow,
+		AllowDoneWhileBlocked: viper.GetBool("dependencies.allowDoneWhileBlocked"),
 	})
+	if err != nil {
+		return fmt.Errorf("failed to create TodoService instance: %v", err)
+	}
+	if err := todoService.BackfillStatuses(context.Background()); err != nil {
+		return err
+	}
+
+	router := initRoutes(todoService, validator)
+
+	router.Get("/api-docs", routes.SpecHandler(openApiSpec, "application/json"))
+	router.Get("/api-docs.yaml", routes.SpecHandler(openApiYAML, "application/yaml"))
+	router.Mount("/docs", routes.NewDocsRouter(docsIndex).Router)
 
 	//health check - liveness
 	router.Get("/health/liveness", func(w http.ResponseWriter, r *http.Request) {
@@ -156,6 +263,23 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 	}))
 
+	if grpcPort := viper.GetString("grpc.port"); grpcPort != "" {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%s", grpcPort))
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC requests: %v", err)
+		}
+		grpcServer := rpc.NewServer(todoService, viper.GetInt("service.maxLimit"),
+			grpc.ChainUnaryInterceptor(rpc.UnaryActor(actorHeader())),   // Record who makes the call
+			grpc.ChainStreamInterceptor(rpc.StreamActor(actorHeader())), // Record who opens the stream
+		)
+		go func() {
+			slog.Info("serving gRPC", slog.String("address", listener.Addr().String()))
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Fatal("gRPC server failed", slog.Any("error", err))
+			}
+		}()
+	}
+
 	port := viper.GetString("service.port")
 	listenAddress := fmt.Sprintf(":%s", port)
 	return http.ListenAndServe(listenAddress, router)
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/openapi"
+	"todo-service/pkg/openapi/openapitest"
+)
+
+// undocumented are the route prefixes the OpenAPI definition doesn't describe, CalDAV is a WebDAV
+// protocol whose methods and XML bodies OpenAPI can't express
+var undocumented = []string{"/caldav", "/.well-known/caldav"}
+
+func TestRoutesMatchOpenAPIDefinition(t *testing.T) {
+	spec := openapitest.Spec(t)
+	validator, err := openapi.NewValidator(spec, false)
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+	service, err := todo.NewTodoService(todo.TodoServiceProps{Storage: fakes.NewStorage()})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+
+	routes := map[string]bool{}
+	walk := func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		for _, prefix := range undocumented {
+			if strings.HasPrefix(route, prefix) {
+				return nil
+			}
+		}
+		// Mounted routers serve their root with a trailing slash, which the server redirects away
+		routes[method+" "+strings.TrimSuffix(route, "/")] = true
+		return nil
+	}
+	if err := chi.Walk(initRoutes(service, validator), walk); err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+
+	definition := struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}{}
+	if err := json.Unmarshal(spec, &definition); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	documented := map[string]bool{}
+	for path, item := range definition.Paths {
+		for method := range item {
+			// Path items also hold shared parameters, summaries and descriptions
+			if method := strings.ToUpper(method); isMethod(method) {
+				documented[method+" "+path] = true
+			}
+		}
+	}
+
+	for _, route := range sorted(routes) {
+		if !documented[route] {
+			t.Errorf("route %s has no matching operation in the OpenAPI definition", route)
+		}
+	}
+	for _, operation := range sorted(documented) {
+		if !routes[operation] {
+			t.Errorf("operation %s of the OpenAPI definition has no matching route", operation)
+		}
+	}
+}
+
+func isMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func sorted(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
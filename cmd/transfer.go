@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/features/transfer"
+)
+
+var exportCommand = &cobra.Command{
+	Use:   "export",
+	Short: "Export all todos from the configured storage",
+	Args:  cobra.NoArgs,
+	RunE:  runExport,
+}
+
+var importCommand = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import todos into the configured storage",
+	Long: `Import todos, preserving their ids and timestamps, into the configured storage.
+
+Todos are read from file, or from stdin when file is omitted or is "-". The format defaults to the
+one matching the file extension.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	exportCommand.Flags().StringP("format", "f", string(transfer.NDJSON), "The export format, one of ndjson, json, csv or ics")
+	exportCommand.Flags().StringP("output", "o", "-", `The file to write todos to, "-" writes to stdout`)
+
+	importCommand.Flags().StringP("format", "f", "", "The format of the input, one of ndjson, json, csv or ics")
+	importCommand.Flags().String("conflict", string(todo.ConflictSkip), "What to do with todos whose id already exists, one of skip, overwrite or new-id")
+	importCommand.Flags().Bool("dry-run", false, "Report what would be imported without changing anything")
+}
+
+func newTodoService() (todo.TodoService, error) {
+	storageAdapter, err := newStorageAdapter()
+	if err != nil {
+		return nil, err
+	}
+	return todo.NewTodoService(todo.TodoServiceProps{Storage: storageAdapter, Logger: slog.Default()})
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	formatFlag, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+
+	format, err := transfer.ParseFormat(formatFlag)
+	if err != nil {
+		return err
+	}
+
+	service, err := newTodoService()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return transfer.Export(context.Background(), service, format, w)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	formatFlag, _ := cmd.Flags().GetString("format")
+	conflict, _ := cmd.Flags().GetString("conflict")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	input := "-"
+	if len(args) == 1 {
+		input = args[0]
+	}
+	if formatFlag == "" {
+		formatFlag = string(transfer.NDJSON)
+		if ext := strings.TrimPrefix(filepath.Ext(input), "."); ext != "" {
+			formatFlag = ext
+		}
+	}
+
+	format, err := transfer.ParseFormat(formatFlag)
+	if err != nil {
+		return err
+	}
+	options := todo.ImportOptions{DryRun: dryRun}
+	if options.Conflict, err = todo.ParseConflictMode(conflict); err != nil {
+		return err
+	}
+
+	service, err := newTodoService()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	summary, err := transfer.Import(context.Background(), service, format, r, options)
+	prefix := ""
+	if summary.DryRun {
+		prefix = "dry run: "
+	}
+	fmt.Printf("%screated %d, overwritten %d, skipped %d, failed %d\n", prefix, summary.Created, summary.Overwritten, summary.Skipped, summary.Failed)
+	for _, e := range summary.Errors {
+		fmt.Println(e)
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+// Package actor carries the name of whoever makes a request, the actor, through its context so the
+// features can record who changed what.
+//
+// The service doesn't authenticate requests itself, the actor is the user name an authenticating
+// proxy in front of it sets on requests (see middlewares.Actor and the rpc interceptors).
+package actor
+
+import "context"
+
+// Anonymous is the actor of requests that don't name one
+const Anonymous = "anonymous"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the actor name
+func NewContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// FromContext returns the actor carried by ctx, Anonymous when there is none
+func FromContext(ctx context.Context) string {
+	if name, ok := ctx.Value(contextKey{}).(string); ok && name != "" {
+		return name
+	}
+	return Anonymous
+}
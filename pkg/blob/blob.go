@@ -0,0 +1,51 @@
+// Package blob stores the content of attachments outside the database, on the local file system
+// or in an S3-compatible object store.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound is returned when getting a blob that doesn't exist
+var ErrNotFound = errors.New("blob not found")
+
+// Store holds blobs by key. Keys are slash separated paths such as "<todo>/<attachment>".
+type Store interface {
+	// Put stores the size bytes of content under key, replacing the blob stored there
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	// Get returns the content stored under key, which the caller must close, and ErrNotFound
+	// when there is none
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key, deleting a missing blob succeeds
+	Delete(ctx context.Context, key string) error
+}
+
+// StoreType names a Store implementation
+type StoreType string
+
+const (
+	LOCAL StoreType = "local"
+	S3    StoreType = "s3"
+)
+
+// New returns the Store of storeType configured by config, see NewLocal and NewS3 for the settings
+// each of them reads
+func New(storeType StoreType, config map[string]string) (Store, error) {
+	switch storeType {
+	case LOCAL:
+		return NewLocal(config["dir"])
+	case S3:
+		return NewS3(context.Background(), S3Props{
+			Endpoint:  config["endpoint"],
+			Bucket:    config["bucket"],
+			Region:    config["region"],
+			AccessKey: config["access_key"],
+			SecretKey: config["secret_key"],
+		})
+	default:
+		return nil, fmt.Errorf("unsupported blob store type %q, supported types are %s and %s", storeType, LOCAL, S3)
+	}
+}
@@ -0,0 +1,127 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"local": func(t *testing.T) Store {
+			store, err := NewLocal(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewLocal() error = %v", err)
+			}
+			return store
+		},
+		"s3": func(t *testing.T) Store {
+			server := httptest.NewServer(newFakeS3(t))
+			t.Cleanup(server.Close)
+			store, err := NewS3(context.Background(), S3Props{Endpoint: server.URL, Bucket: "attachments", AccessKey: "minio", SecretKey: "minio123"})
+			if err != nil {
+				t.Fatalf("NewS3() error = %v", err)
+			}
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+			key := "todo/attachment"
+
+			if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a missing blob error = %v, want ErrNotFound", err)
+			}
+			for _, content := range []string{"first version", "second version"} {
+				if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+					t.Fatalf("Put() error = %v", err)
+				}
+			}
+			if got := read(t, store, key); got != "second version" {
+				t.Errorf("Get() = %q, want the last version", got)
+			}
+
+			if err := store.Delete(ctx, key); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a deleted blob error = %v, want ErrNotFound", err)
+			}
+			if err := store.Delete(ctx, key); err != nil {
+				t.Errorf("Delete() of a missing blob error = %v, want nil", err)
+			}
+			if err := store.Put(ctx, "/absolute", strings.NewReader(""), 0, "text/plain"); err == nil {
+				t.Errorf("Put() with an invalid key error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(LOCAL, map[string]string{"dir": t.TempDir()}); err != nil {
+		t.Errorf("New(local) error = %v", err)
+	}
+	if _, err := New(S3, map[string]string{"endpoint": "http://localhost:9000"}); err == nil {
+		t.Errorf("New(s3) without a bucket error = nil, want an error")
+	}
+	if _, err := New("ftp", nil); err == nil {
+		t.Errorf("New(ftp) error = nil, want an error")
+	}
+}
+
+func read(t *testing.T, store Store, key string) string {
+	t.Helper()
+	content, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		t.Fatalf("failed to read blob: %v", err)
+	}
+	return string(data)
+}
+
+// newFakeS3 returns a handler standing in for an S3-compatible server (such as MinIO), it keeps the
+// objects in memory and checks requests are signed
+func newFakeS3(t *testing.T) http.Handler {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minio/") || r.Header.Get("X-Amz-Date") == "" {
+			t.Errorf("%s %s isn't signed: %v", r.Method, r.URL.Path, r.Header)
+			http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			if int64(len(data)) != r.ContentLength {
+				http.Error(w, "<Error><Code>IncompleteBody</Code></Error>", http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = data
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// unsignedPayload lets requests be signed without hashing their bodies first, which would mean
+// reading uploads twice
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Props configures an S3 store. Endpoint and Bucket are required. Region defaults to us-east-1,
+// and the credentials to the default AWS credential chain when AccessKey isn't set.
+type S3Props struct {
+	// Endpoint is the URL of the S3 API, such as https://s3.us-west-2.amazonaws.com or the URL of
+	// a MinIO server
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// S3Store stores blobs as the objects of a bucket of an S3-compatible object store, addressed with
+// path-style URLs (<endpoint>/<bucket>/<key>) which every implementation supports
+type S3Store struct {
+	endpoint    *url.URL
+	bucket      string
+	region      string
+	credentials aws.CredentialsProvider
+	client      *http.Client
+	signer      *v4.Signer
+}
+
+func NewS3(ctx context.Context, props S3Props) (*S3Store, error) {
+	if props.Endpoint == "" || props.Bucket == "" {
+		return nil, errors.New("the endpoint and bucket of the S3 blob store are required")
+	}
+	endpoint, err := url.Parse(props.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", props.Endpoint)
+	}
+
+	s := S3Store{
+		endpoint: endpoint,
+		bucket:   props.Bucket,
+		region:   props.Region,
+		client:   props.HTTPClient,
+		signer:   v4.NewSigner(),
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	if props.AccessKey != "" {
+		s.credentials = credentials.NewStaticCredentialsProvider(props.AccessKey, props.SecretKey, "")
+	} else {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the AWS credentials: %v", err)
+		}
+		s.credentials = cfg.Credentials
+	}
+	return &s, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, content)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return s.error(req, res)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch res.StatusCode {
+	case http.StatusOK:
+		return res.Body, nil
+	case http.StatusNotFound:
+		res.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer res.Body.Close()
+		return nil, s.error(req, res)
+	}
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s.error(req, res)
+	}
+}
+
+func (s *S3Store) request(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return nil, fmt.Errorf("invalid blob key %q", key)
+	}
+	target := s.endpoint.JoinPath(s.bucket, key)
+	return http.NewRequestWithContext(ctx, method, target.String(), body)
+}
+
+// do signs req with AWS Signature Version 4 and sends it
+func (s *S3Store) do(req *http.Request) (*http.Response, error) {
+	credentials, err := s.credentials.Retrieve(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the AWS credentials: %v", err)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	if err := s.signer.SignHTTP(req.Context(), credentials, req, unsignedPayload, "s3", s.region, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.client.Do(req)
+}
+
+// error returns the error of an S3 response, S3 describes errors in an XML document
+func (s *S3Store) error(req *http.Request, res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("S3 %s %s failed with %s: %s", req.Method, req.URL.Path, res.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,188 @@
+// Package caldav implements the parts of WebDAV (RFC 4918) and CalDAV (RFC 4791) needed to serve
+// todos as a calendar collection: the XML request and response bodies, calendar-query filters and
+// entity tags.
+package caldav
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"todo-service/pkg/types"
+)
+
+const (
+	NamespaceDAV            = "DAV:"
+	NamespaceCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	NamespaceCalendarServer = "http://calendarserver.org/ns/"
+
+	// maxRequestSize is the maximum size of a PROPFIND or REPORT request body
+	maxRequestSize = 1024 * 1024
+)
+
+// Properties served by the CalDAV router
+var (
+	ResourceType                  = xml.Name{Space: NamespaceDAV, Local: "resourcetype"}
+	DisplayName                   = xml.Name{Space: NamespaceDAV, Local: "displayname"}
+	GetETag                       = xml.Name{Space: NamespaceDAV, Local: "getetag"}
+	GetContentType                = xml.Name{Space: NamespaceDAV, Local: "getcontenttype"}
+	GetLastModified               = xml.Name{Space: NamespaceDAV, Local: "getlastmodified"}
+	CurrentUserPrincipal          = xml.Name{Space: NamespaceDAV, Local: "current-user-principal"}
+	PrincipalURL                  = xml.Name{Space: NamespaceDAV, Local: "principal-URL"}
+	CurrentUserPrivilegeSet       = xml.Name{Space: NamespaceDAV, Local: "current-user-privilege-set"}
+	SupportedReportSet            = xml.Name{Space: NamespaceDAV, Local: "supported-report-set"}
+	CalendarHomeSet               = xml.Name{Space: NamespaceCalDAV, Local: "calendar-home-set"}
+	CalendarData                  = xml.Name{Space: NamespaceCalDAV, Local: "calendar-data"}
+	SupportedCalendarComponentSet = xml.Name{Space: NamespaceCalDAV, Local: "supported-calendar-component-set"}
+	GetCTag                       = xml.Name{Space: NamespaceCalendarServer, Local: "getctag"}
+)
+
+// Reports supported by calendar collections
+var (
+	CalendarQuery    = xml.Name{Space: NamespaceCalDAV, Local: "calendar-query"}
+	CalendarMultiget = xml.Name{Space: NamespaceCalDAV, Local: "calendar-multiget"}
+)
+
+// PropFind is the body of a PROPFIND request, an empty body is the same as allprop
+type PropFind struct {
+	XMLName  xml.Name   `xml:"DAV: propfind"`
+	AllProp  *struct{}  `xml:"DAV: allprop"`
+	PropName *struct{}  `xml:"DAV: propname"`
+	Prop     *PropNames `xml:"DAV: prop"`
+}
+
+// PropNames lists the properties requested by a PROPFIND or REPORT request
+type PropNames struct {
+	Names []Name `xml:",any"`
+}
+
+type Name struct {
+	XMLName xml.Name
+}
+
+// Report is the body of a calendar-query or calendar-multiget REPORT request
+type Report struct {
+	XMLName xml.Name
+	AllProp *struct{}  `xml:"DAV: allprop"`
+	Prop    *PropNames `xml:"DAV: prop"`
+	Filter  *Filter    `xml:"urn:ietf:params:xml:ns:caldav filter"`
+	Hrefs   []string   `xml:"DAV: href"`
+}
+
+// Multistatus is the body of a 207 response
+type Multistatus struct {
+	XMLName   xml.Name   `xml:"DAV: multistatus"`
+	Responses []Response `xml:"response"`
+}
+
+// Response and Propstat elements inherit the DAV: namespace of the multistatus element
+type Response struct {
+	Href      string     `xml:"href"`
+	Propstats []Propstat `xml:"propstat,omitempty"`
+	Status    string     `xml:"status,omitempty"`
+}
+
+type Propstat struct {
+	Prop   Prop   `xml:"prop"`
+	Status string `xml:"status"`
+}
+
+type Prop struct {
+	Properties []Property
+}
+
+// Property is a property value, Inner holds the escaped XML content of the property element
+type Property struct {
+	XMLName xml.Name
+	Inner   string `xml:",innerxml"`
+}
+
+// Error is the body of a response to a request that failed a precondition
+type Error struct {
+	XMLName   xml.Name `xml:"DAV: error"`
+	Condition Property
+}
+
+// Status returns the status line used in multistatus responses
+func Status(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+// Text returns the property content for a text value
+func Text(value string) string {
+	b := strings.Builder{}
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// Href returns the property content for a property holding a single href
+func Href(href string) string {
+	return "<href xmlns=\"DAV:\">" + Text(href) + "</href>"
+}
+
+// Element returns the property content for an empty element such as <calendar/>
+func Element(name xml.Name, attributes ...xml.Attr) string {
+	b := strings.Builder{}
+	b.WriteString("<" + name.Local + ` xmlns="` + name.Space + `"`)
+	for _, attr := range attributes {
+		b.WriteString(" " + attr.Name.Local + `="` + Text(attr.Value) + `"`)
+	}
+	b.WriteString("/>")
+	return b.String()
+}
+
+// ReadPropFind reads a PROPFIND request body
+func ReadPropFind(r io.Reader) (PropFind, error) {
+	propFind := PropFind{}
+	err := xml.NewDecoder(io.LimitReader(r, maxRequestSize)).Decode(&propFind)
+	if err == io.EOF {
+		return PropFind{AllProp: &struct{}{}}, nil
+	}
+	if err != nil {
+		return propFind, fmt.Errorf("invalid PROPFIND body: %v", err)
+	}
+	if propFind.Prop == nil && propFind.PropName == nil {
+		propFind.AllProp = &struct{}{}
+	}
+	return propFind, nil
+}
+
+// ReadReport reads a REPORT request body
+func ReadReport(r io.Reader) (Report, error) {
+	report := Report{}
+	if err := xml.NewDecoder(io.LimitReader(r, maxRequestSize)).Decode(&report); err != nil {
+		return report, fmt.Errorf("invalid REPORT body: %v", err)
+	}
+	return report, nil
+}
+
+// ETag returns the entity tag of a todo, it changes whenever the todo is updated
+func ETag(t types.Todo) string {
+	return `"` + strconv.FormatInt(t.UpdatedAt.UnixNano(), 36) + `"`
+}
+
+// CTag returns the collection tag of a calendar holding todos, it changes whenever a todo is
+// created, updated or deleted
+func CTag(todos []types.Todo) string {
+	hash := sha256.New()
+	for _, t := range todos {
+		fmt.Fprintf(hash, "%s %s\n", t.Id, ETag(t))
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+}
+
+// MatchETag reports whether etag matches an If-Match or If-None-Match header value
+func MatchETag(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
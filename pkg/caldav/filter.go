@@ -0,0 +1,187 @@
+package caldav
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"todo-service/pkg/ical"
+)
+
+// Filter is the filter of a calendar-query report (RFC 4791 section 9.7)
+type Filter struct {
+	CompFilter CompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type CompFilter struct {
+	Name         string       `xml:"name,attr"`
+	IsNotDefined *struct{}    `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *TimeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	PropFilters  []PropFilter `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+	CompFilters  []CompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type PropFilter struct {
+	Name         string     `xml:"name,attr"`
+	IsNotDefined *struct{}  `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *TimeRange `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	TextMatch    *TextMatch `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+}
+
+type TimeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+type TextMatch struct {
+	Value           string `xml:",chardata"`
+	Collation       string `xml:"collation,attr"`
+	NegateCondition string `xml:"negate-condition,attr"`
+}
+
+// Match reports whether a calendar object matches the filter
+func (f *Filter) Match(calendar *ical.Component) (bool, error) {
+	if !strings.EqualFold(f.CompFilter.Name, calendar.Name) {
+		return false, nil
+	}
+	return f.CompFilter.match(calendar)
+}
+
+// match reports whether c, whose name matches the filter, satisfies the filter's conditions
+func (f *CompFilter) match(c *ical.Component) (bool, error) {
+	if f.TimeRange != nil {
+		ok, err := f.TimeRange.matchComponent(c)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	for _, propFilter := range f.PropFilters {
+		ok, err := propFilter.match(c)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	for _, child := range f.CompFilters {
+		ok, err := child.matchChildren(c)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchChildren reports whether the sub components of parent satisfy the filter, a filter matches
+// when any of the sub components named by it does
+func (f *CompFilter) matchChildren(parent *ical.Component) (bool, error) {
+	found := false
+	for _, c := range parent.Components {
+		if !strings.EqualFold(c.Name, f.Name) {
+			continue
+		}
+		found = true
+		if f.IsNotDefined != nil {
+			return false, nil
+		}
+		ok, err := f.match(c)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return !found && f.IsNotDefined != nil, nil
+}
+
+func (f *PropFilter) match(c *ical.Component) (bool, error) {
+	p := c.Get(strings.ToUpper(f.Name))
+	if f.IsNotDefined != nil {
+		return p == nil, nil
+	}
+	if p == nil {
+		return false, nil
+	}
+
+	if f.TimeRange != nil {
+		start, end, err := f.TimeRange.bounds()
+		if err != nil {
+			return false, err
+		}
+		t, err := p.Time()
+		if err != nil || t.Before(start) || !t.Before(end) {
+			return false, nil
+		}
+	}
+
+	if f.TextMatch != nil {
+		value, pattern := p.Text(), f.TextMatch.Value
+		if f.TextMatch.Collation != "i;octet" {
+			value, pattern = strings.ToLower(value), strings.ToLower(pattern)
+		}
+		matched := strings.Contains(value, pattern)
+		if f.TextMatch.NegateCondition == "yes" {
+			matched = !matched
+		}
+		return matched, nil
+	}
+	return true, nil
+}
+
+func (r *TimeRange) bounds() (time.Time, time.Time, error) {
+	start, end := time.Time{}, time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	var err error
+	if r.Start != "" {
+		p := ical.Property{Name: "start", Value: r.Start}
+		if start, err = p.Time(); err != nil {
+			return start, end, fmt.Errorf("invalid time-range: %v", err)
+		}
+	}
+	if r.End != "" {
+		p := ical.Property{Name: "end", Value: r.End}
+		if end, err = p.Time(); err != nil {
+			return start, end, fmt.Errorf("invalid time-range: %v", err)
+		}
+	}
+	return start, end, nil
+}
+
+// matchComponent reports whether a VTODO overlaps the time range following the rules in RFC 4791
+// section 9.9, other components always match
+func (r *TimeRange) matchComponent(c *ical.Component) (bool, error) {
+	start, end, err := r.bounds()
+	if err != nil {
+		return false, err
+	}
+	if c.Name != "VTODO" {
+		return true, nil
+	}
+
+	value := func(name string) (time.Time, bool) {
+		if p := c.Get(name); p != nil {
+			if t, err := p.Time(); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+	dtstart, hasStart := value("DTSTART")
+	due, hasDue := value("DUE")
+	completed, hasCompleted := value("COMPLETED")
+	created, hasCreated := value("CREATED")
+
+	switch {
+	case hasStart && hasDue:
+		return (start.Before(due) || !dtstart.Before(start)) && (end.After(dtstart) || !end.Before(due)), nil
+	case hasStart:
+		return !start.After(dtstart) && end.After(dtstart), nil
+	case hasDue:
+		return start.Before(due) && !end.Before(due), nil
+	case hasCompleted && hasCreated:
+		return (!start.After(created) || !start.After(completed)) && (!end.Before(created) || !end.Before(completed)), nil
+	case hasCompleted:
+		return !start.After(completed) && !end.Before(completed), nil
+	case hasCreated:
+		return end.After(created), nil
+	default:
+		return true, nil
+	}
+}
@@ -0,0 +1,99 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"todo-service/pkg/ical"
+)
+
+func parseCalendar(t *testing.T, lines ...string) *ical.Component {
+	t.Helper()
+	input := "BEGIN:VCALENDAR\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCALENDAR\r\n"
+	calendars, err := ical.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return calendars[0]
+}
+
+func TestFilterMatch(t *testing.T) {
+	open := parseCalendar(t, "BEGIN:VTODO", "SUMMARY:Pick up the groceries", "DUE:20240702T170000Z", "STATUS:NEEDS-ACTION", "END:VTODO")
+	completed := parseCalendar(t, "BEGIN:VTODO", "SUMMARY:Done", "CREATED:20240601T000000Z", "COMPLETED:20240605T000000Z", "STATUS:COMPLETED", "END:VTODO")
+
+	tests := []struct {
+		name          string
+		filter        string
+		wantOpen      bool
+		wantCompleted bool
+	}{
+		{name: "all todos", filter: `<C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"/></C:comp-filter>`, wantOpen: true, wantCompleted: true},
+		{name: "events", filter: `<C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT"/></C:comp-filter>`},
+		{
+			name:     "not completed",
+			filter:   `<C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"><C:prop-filter name="COMPLETED"><C:is-not-defined/></C:prop-filter></C:comp-filter></C:comp-filter>`,
+			wantOpen: true,
+		},
+		{
+			name:          "status text match",
+			filter:        `<C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"><C:prop-filter name="STATUS"><C:text-match negate-condition="yes">needs-action</C:text-match></C:prop-filter></C:comp-filter></C:comp-filter>`,
+			wantCompleted: true,
+		},
+		{
+			name:     "due in time range",
+			filter:   `<C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"><C:time-range start="20240702T000000Z" end="20240703T000000Z"/></C:comp-filter></C:comp-filter>`,
+			wantOpen: true,
+		},
+		{
+			name:          "completed in time range",
+			filter:        `<C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"><C:time-range start="20240601T000000Z" end="20240610T000000Z"/></C:comp-filter></C:comp-filter>`,
+			wantCompleted: true,
+		},
+		{
+			name:   "due property in time range",
+			filter: `<C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"><C:prop-filter name="DUE"><C:time-range end="20240701T000000Z"/></C:prop-filter></C:comp-filter></C:comp-filter>`,
+		},
+		{name: "other calendars", filter: `<C:comp-filter name="VCARD"/>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := Filter{}
+			body := `<C:filter xmlns:C="urn:ietf:params:xml:ns:caldav">` + tt.filter + `</C:filter>`
+			if err := xml.Unmarshal([]byte(body), &filter); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got, err := filter.Match(open); err != nil || got != tt.wantOpen {
+				t.Errorf("Match(open) = %v, %v, want %v", got, err, tt.wantOpen)
+			}
+			if got, err := filter.Match(completed); err != nil || got != tt.wantCompleted {
+				t.Errorf("Match(completed) = %v, %v, want %v", got, err, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestFilterInvalidTimeRange(t *testing.T) {
+	filter := Filter{CompFilter: CompFilter{Name: "VCALENDAR", CompFilters: []CompFilter{{Name: "VTODO", TimeRange: &TimeRange{Start: "yesterday"}}}}}
+	calendar := parseCalendar(t, "BEGIN:VTODO", "SUMMARY:todo", "END:VTODO")
+	if _, err := filter.Match(calendar); err == nil {
+		t.Error("Match() succeeded, want an error")
+	}
+}
+
+func TestMatchETag(t *testing.T) {
+	tests := map[string]bool{
+		`"abc"`:        true,
+		`W/"abc"`:      true,
+		`"xyz", "abc"`: true,
+		`*`:            true,
+		`"xyz"`:        false,
+		`abc`:          false,
+	}
+	for header, want := range tests {
+		if got := MatchETag(header, `"abc"`); got != want {
+			t.Errorf("MatchETag(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
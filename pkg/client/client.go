@@ -0,0 +1,260 @@
+// Package client is a Go client of the Todo REST API
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-service/pkg/types"
+)
+
+const (
+	defaultRetries = 3
+	defaultBackoff = 200 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// Client calls the Todo API of a remote server. Idempotent requests (GET, PUT and DELETE) are
+// retried with an exponential backoff when they fail with a network error or with a 429, 502, 503
+// or 504 response.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used to send requests, it defaults to http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets the number of times a failed request is retried and the delay before the first
+// retry, which doubles on every retry. Zero retries disables retrying.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// New returns a client of the server at baseURL (e.g. http://localhost:8080)
+func New(baseURL string, options ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid server URL %q: the scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{baseURL: u, httpClient: http.DefaultClient, retries: defaultRetries, backoff: defaultBackoff}
+	for _, option := range options {
+		option(c)
+	}
+	return c, nil
+}
+
+// ListTodos returns a page of at most limit todos starting at next, pass the Next of the returned
+// list to get the following page. A limit <= 0 uses the server's default.
+func (c *Client) ListTodos(ctx context.Context, limit int, next string) (types.TodoList, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if next != "" {
+		query.Set("next", next)
+	}
+
+	list := types.TodoList{}
+	err := c.do(ctx, http.MethodGet, "/todos", query, "", nil, &list)
+	return list, err
+}
+
+// Todos returns an iterator over every todo, read pageSize todos at a time
+func (c *Client) Todos(ctx context.Context, pageSize int) *TodoIterator {
+	return &TodoIterator{ctx: ctx, client: c, pageSize: pageSize}
+}
+
+func (c *Client) GetTodo(ctx context.Context, id string) (types.Todo, error) {
+	todo := types.Todo{}
+	err := c.do(ctx, http.MethodGet, "/todos/"+url.PathEscape(id), nil, "", nil, &todo)
+	return todo, err
+}
+
+func (c *Client) CreateTodo(ctx context.Context, todo types.TodoUpdate) (types.Todo, error) {
+	created := types.Todo{}
+	err := c.do(ctx, http.MethodPost, "/todos", nil, "application/json", todo, &created)
+	return created, err
+}
+
+// ReplaceTodo replaces every field of the todo with the given id
+func (c *Client) ReplaceTodo(ctx context.Context, id string, todo types.TodoUpdate) error {
+	return c.do(ctx, http.MethodPut, "/todos/"+url.PathEscape(id), nil, "application/json", todo, nil)
+}
+
+// UpdateTodo applies patch to the todo with the given id
+func (c *Client) UpdateTodo(ctx context.Context, id string, patch Patch) error {
+	return c.do(ctx, http.MethodPatch, "/todos/"+url.PathEscape(id), nil, "application/json-patch+json", patch, nil)
+}
+
+func (c *Client) DeleteTodo(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/todos/"+url.PathEscape(id), nil, "", nil, nil)
+}
+
+// do sends a request with body encoded as JSON, when set, and decodes the response into result,
+// when set. Responses other than 2xx are returned as an *Error.
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, contentType string, body any, result any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		res, err := c.httpClient.Do(req)
+		retry := attempt < c.retries && idempotent(method) && ctx.Err() == nil
+		if err != nil {
+			if retry {
+				if err := c.wait(ctx, attempt, ""); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		if res.StatusCode >= 200 && res.StatusCode < 300 {
+			defer drain(res.Body)
+			if result == nil || res.StatusCode == http.StatusNoContent {
+				return nil
+			}
+			return json.NewDecoder(res.Body).Decode(result)
+		}
+
+		apiErr := newError(res)
+		drain(res.Body)
+		if retry && retryable(res.StatusCode) {
+			if err := c.wait(ctx, attempt, res.Header.Get("Retry-After")); err != nil {
+				return err
+			}
+			continue
+		}
+		return apiErr
+	}
+}
+
+// wait sleeps before the next attempt, for as long as the server asked with Retry-After when it did
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter string) error {
+	delay := c.backoff << attempt
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+	if delay > maxBackoff || delay < 0 {
+		delay = maxBackoff
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+}
+
+func retryable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// TodoIterator iterates over todos a page at a time:
+//
+//	it := c.Todos(ctx, 100)
+//	for it.Next() {
+//		todo := it.Todo()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type TodoIterator struct {
+	ctx      context.Context
+	client   *Client
+	pageSize int
+	page     []types.Todo
+	next     string
+	started  bool
+	current  types.Todo
+	err      error
+}
+
+// Next advances to the next todo, it returns false once every todo was read or reading failed
+func (it *TodoIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || (it.started && it.next == "") {
+			return false
+		}
+		list, err := it.client.ListTodos(it.ctx, it.pageSize, it.next)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.started = true
+		it.page, it.next = list.Todos, list.Next
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Todo returns the current todo
+func (it *TodoIterator) Todo() types.Todo {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *TodoIterator) Err() error {
+	return it.err
+}
+
+// drain reads and discards what's left of body so the connection can be reused
+func drain(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 64*1024))
+	body.Close()
+}
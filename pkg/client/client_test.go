@@ -0,0 +1,186 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"todo-service/pkg/client"
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/openapi/openapitest"
+	"todo-service/pkg/routes"
+	"todo-service/pkg/types"
+)
+
+var now = time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+const firstId = "00000000-0000-7000-8000-000000000001"
+
+// newTestClient returns a client of a server running the REST routes of a service holding count
+// todos, which keeps the client in sync with the API it calls
+func newTestClient(t *testing.T, count int) *client.Client {
+	t.Helper()
+	service, err := todo.NewTodoService(todo.TodoServiceProps{
+		Storage:     fakes.NewStorage(),
+		Clock:       fakes.NewClock(now),
+		IdGenerator: &fakes.IdGenerator{},
+	})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+	for i := 0; i < count; i++ {
+		if _, err := service.CreateTodo(context.Background(), types.TodoUpdate{Summary: "Pick up the groceries"}); err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+	}
+
+	server := httptest.NewServer(openapitest.Mount(t, "/todos", routes.NewTodoRouter(service, 0, 0).Router))
+	t.Cleanup(server.Close)
+	c, err := client.New(server.URL, client.WithRetries(0, 0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, 0)
+
+	due := now.Add(24 * time.Hour)
+	created, err := c.CreateTodo(ctx, types.TodoUpdate{Summary: "Pick up the groceries", Due: &due, Priority: 3})
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+	if created.Id != firstId || created.Summary != "Pick up the groceries" || created.Priority != 3 {
+		t.Errorf("CreateTodo() = %+v", created)
+	}
+
+	if err := c.UpdateTodo(ctx, created.Id, client.Patch{client.SetDone(true), client.SetDue(nil), client.SetPriority(5)}); err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+	got, err := c.GetTodo(ctx, created.Id)
+	if err != nil || !got.Done || got.Due != nil || got.Priority != 5 || got.Summary != created.Summary {
+		t.Errorf("GetTodo() after UpdateTodo() = %+v, %v, want it done without a due date and a priority of 5", got, err)
+	}
+
+	if err := c.ReplaceTodo(ctx, created.Id, types.TodoUpdate{Summary: "replaced", Due: &due}); err != nil {
+		t.Fatalf("ReplaceTodo() error = %v", err)
+	}
+	got, err = c.GetTodo(ctx, created.Id)
+	if err != nil || got.Done || got.Due == nil || !got.Due.Equal(due) || got.Summary != "replaced" {
+		t.Errorf("GetTodo() after ReplaceTodo() = %+v, %v, want every field replaced", got, err)
+	}
+
+	if err := c.DeleteTodo(ctx, created.Id); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+	if _, err := c.GetTodo(ctx, created.Id); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetTodo() of a deleted todo error = %v, want %v", err, client.ErrNotFound)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, 1)
+
+	_, err := c.CreateTodo(ctx, types.TodoUpdate{Summary: "todo", Priority: 10})
+	var apiErr *client.Error
+	if !errors.Is(err, client.ErrBadRequest) || !errors.As(err, &apiErr) || apiErr.Message == "" {
+		t.Errorf("CreateTodo() with an invalid priority error = %v, want a bad request", err)
+	}
+
+	err = c.UpdateTodo(ctx, firstId, client.Patch{client.Test("/summary", "changed"), client.SetDone(true)})
+	if !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("UpdateTodo() with a failing test error = %v, want a bad request", err)
+	}
+	if errors.Is(err, client.ErrNotFound) {
+		t.Errorf("errors.Is(%v, ErrNotFound) = true, want false", err)
+	}
+}
+
+func TestTodoIterator(t *testing.T) {
+	c := newTestClient(t, 5)
+
+	it := c.Todos(context.Background(), 2)
+	ids := []string{}
+	for it.Next() {
+		ids = append(ids, it.Todo().Id)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(ids) != 5 || ids[0] != firstId || ids[4] != "00000000-0000-7000-8000-000000000005" {
+		t.Errorf("iterated ids = %v, want the 5 todos in order", ids)
+	}
+}
+
+func TestTodoIteratorEmpty(t *testing.T) {
+	it := newTestClient(t, 0).Todos(context.Background(), 2)
+	if it.Next() || it.Err() != nil {
+		t.Errorf("Next() = true or Err() = %v over no todos", it.Err())
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		wantAttempts int32
+	}{
+		{name: "retries unavailable servers", method: http.MethodGet, status: http.StatusServiceUnavailable, wantAttempts: 3},
+		{name: "retries rate limited requests", method: http.MethodDelete, status: http.StatusTooManyRequests, wantAttempts: 3},
+		{name: "doesn't retry client errors", method: http.MethodGet, status: http.StatusNotFound, wantAttempts: 1},
+		{name: "doesn't retry requests that aren't idempotent", method: http.MethodPost, status: http.StatusServiceUnavailable, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"status": "Failed", "error": "try again"}`))
+			}))
+			defer server.Close()
+
+			c, err := client.New(server.URL, client.WithRetries(2, time.Millisecond))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			ctx := context.Background()
+			switch tt.method {
+			case http.MethodGet:
+				_, err = c.GetTodo(ctx, "1")
+			case http.MethodDelete:
+				err = c.DeleteTodo(ctx, "1")
+			case http.MethodPost:
+				_, err = c.CreateTodo(ctx, types.TodoUpdate{Summary: "todo"})
+			}
+
+			var apiErr *client.Error
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status || apiErr.Message != "try again" {
+				t.Errorf("error = %v, want the %d error response", err, tt.status)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidURLs(t *testing.T) {
+	for _, baseURL := range []string{"localhost:8080", "ftp://example.com", "://"} {
+		if _, err := client.New(baseURL); err == nil {
+			t.Errorf("New(%q) succeeded, want an error", baseURL)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tink3rlabs/magic/types"
+)
+
+// Error is an error response of the Todo API. Use errors.Is with the sentinel errors below to tell
+// the kind of error apart, e.g. errors.Is(err, client.ErrNotFound).
+type Error struct {
+	StatusCode int
+	// Status and Message are the status and error fields of the magic error response
+	Status  string
+	Message string
+	// Details lists the validation errors of bad requests
+	Details []string
+}
+
+var (
+	ErrBadRequest         = &Error{StatusCode: http.StatusBadRequest}
+	ErrNotFound           = &Error{StatusCode: http.StatusNotFound}
+	ErrInternal           = &Error{StatusCode: http.StatusInternalServerError}
+	ErrServiceUnavailable = &Error{StatusCode: http.StatusServiceUnavailable}
+)
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	if len(e.Details) > 0 {
+		message = fmt.Sprintf("%s: %s", message, strings.Join(e.Details, "; "))
+	}
+	return fmt.Sprintf("todo API error %d: %s", e.StatusCode, message)
+}
+
+// Is reports whether target is the sentinel error of the status code of e
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.StatusCode == e.StatusCode
+}
+
+// newError reads the error response of res, responses that aren't magic error responses keep their
+// body as the message
+func newError(res *http.Response) *Error {
+	e := &Error{StatusCode: res.StatusCode, Status: http.StatusText(res.StatusCode)}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+
+	response := types.ErrorResponse{}
+	if err := json.Unmarshal(body, &response); err == nil && (response.Error != "" || response.Status != "") {
+		e.Status = response.Status
+		e.Message = response.Error
+		e.Details = response.Details
+		return e
+	}
+	e.Message = strings.TrimSpace(string(body))
+	return e
+}
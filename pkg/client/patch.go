@@ -0,0 +1,53 @@
+package client
+
+import "time"
+
+// Operation is a JSON Patch (RFC 6902) operation
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+	From  string `json:"from,omitempty"`
+}
+
+// Patch is a list of JSON Patch operations applied in order by UpdateTodo
+type Patch []Operation
+
+func Add(path string, value any) Operation {
+	return Operation{Op: "add", Path: path, Value: value}
+}
+
+func Replace(path string, value any) Operation {
+	return Operation{Op: "replace", Path: path, Value: value}
+}
+
+func Remove(path string) Operation {
+	return Operation{Op: "remove", Path: path}
+}
+
+// Test makes the patch fail unless the value at path equals value, e.g. to only update a todo that
+// wasn't changed since it was read
+func Test(path string, value any) Operation {
+	return Operation{Op: "test", Path: path, Value: value}
+}
+
+func SetSummary(summary string) Operation {
+	return Replace("/summary", summary)
+}
+
+func SetDone(done bool) Operation {
+	return Replace("/done", done)
+}
+
+// SetDue sets the due date of a todo, a nil due clears it. Fields that are empty are left out of
+// todos so the optional fields are set with add, which replaces existing values.
+func SetDue(due *time.Time) Operation {
+	if due == nil {
+		return Add("/due", nil)
+	}
+	return Add("/due", due.UTC())
+}
+
+func SetPriority(priority int) Operation {
+	return Add("/priority", priority)
+}
@@ -0,0 +1,16 @@
+package clock
+
+import "time"
+
+// Clock provides the current time, services depend on it instead of calling time.Now directly so
+// that time can be controlled in tests
+type Clock interface {
+	Now() time.Time
+}
+
+// System is a Clock backed by the system time
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now().UTC()
+}
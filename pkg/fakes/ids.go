@@ -0,0 +1,20 @@
+package fakes
+
+import (
+	"fmt"
+	"sync"
+)
+
+// IdGenerator is an ids.Generator that returns predictable, ascending, UUID formatted identifiers
+// (00000000-0000-7000-8000-000000000001, 00000000-0000-7000-8000-000000000002, ...)
+type IdGenerator struct {
+	mu   sync.Mutex
+	last int
+}
+
+func (g *IdGenerator) NewId() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.last++
+	return fmt.Sprintf("00000000-0000-7000-8000-%012d", g.last), nil
+}
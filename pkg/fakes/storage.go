@@ -0,0 +1,229 @@
+package fakes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tink3rlabs/magic/storage"
+)
+
+// Storage is an in-memory storage.StorageAdapter that doesn't need a database or migrations.
+//
+// Items are kept as their JSON representation in a table per item type, filters and sort keys
+// are matched against the JSON field names, which is how the todo-service types are mapped to
+// table columns by the real adapters. Statements passed to Execute are ignored.
+type Storage struct {
+	mu     sync.RWMutex
+	tables map[string][]map[string]any
+}
+
+func NewStorage() *Storage {
+	return &Storage{tables: map[string][]map[string]any{}}
+}
+
+func (s *Storage) Execute(statement string) error {
+	return nil
+}
+
+func (s *Storage) Ping() error {
+	return nil
+}
+
+func (s *Storage) GetType() storage.StorageAdapterType {
+	return storage.MEMORY
+}
+
+func (s *Storage) GetProvider() storage.StorageProviders {
+	return ""
+}
+
+func (s *Storage) GetSchemaName() string {
+	return ""
+}
+
+func (s *Storage) Create(item any) error {
+	record, err := toRecord(item)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	table := tableName(item)
+	if id, ok := record["id"]; ok {
+		for _, existing := range s.tables[table] {
+			if existing["id"] == id {
+				return fmt.Errorf("an item with id %v already exists in %s", id, table)
+			}
+		}
+	}
+	s.tables[table] = append(s.tables[table], record)
+	return nil
+}
+
+func (s *Storage) Get(dest any, filter map[string]any) error {
+	if len(filter) == 0 {
+		return errors.New("filtering is required when getting a resource")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.tables[tableName(dest)] {
+		if matches(record, filter) {
+			return fromRecord(record, dest)
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func (s *Storage) Update(item any, filter map[string]any) error {
+	if len(filter) == 0 {
+		return errors.New("filtering is required when updating a resource")
+	}
+	record, err := toRecord(item)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	table := tableName(item)
+	for i, existing := range s.tables[table] {
+		if matches(existing, filter) {
+			s.tables[table][i] = record
+			return nil
+		}
+	}
+	// Like the SQL adapter, updating a missing item creates it
+	s.tables[table] = append(s.tables[table], record)
+	return nil
+}
+
+func (s *Storage) Delete(item any, filter map[string]any) error {
+	if len(filter) == 0 {
+		return errors.New("filtering is required when deleting a resource")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	table := tableName(item)
+	remaining := []map[string]any{}
+	for _, record := range s.tables[table] {
+		if !matches(record, filter) {
+			remaining = append(remaining, record)
+		}
+	}
+	s.tables[table] = remaining
+	return nil
+}
+
+func (s *Storage) List(dest any, sortKey string, filter map[string]any, limit int, cursor string) (string, error) {
+	start, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode next cursor: %v", err)
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return "", errors.New("dest must be a pointer to a slice")
+	}
+	elemType := v.Elem().Type().Elem()
+	key := jsonFieldName(elemType, sortKey)
+
+	s.mu.RLock()
+	records := []map[string]any{}
+	for _, record := range s.tables[strings.ToLower(elemType.Name())+"s"] {
+		if matches(record, filter) && fmt.Sprint(record[key]) >= string(start) {
+			records = append(records, record)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return fmt.Sprint(records[i][key]) < fmt.Sprint(records[j][key])
+	})
+
+	next := ""
+	if len(records) > limit {
+		next = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(records[limit][key])))
+		records = records[:limit]
+	}
+
+	items := reflect.MakeSlice(v.Elem().Type(), 0, len(records))
+	for _, record := range records {
+		item := reflect.New(elemType)
+		if err := fromRecord(record, item.Interface()); err != nil {
+			return "", err
+		}
+		items = reflect.Append(items, item.Elem())
+	}
+	v.Elem().Set(items)
+
+	return next, nil
+}
+
+// tableName follows the naming of the real adapters, the lower cased plural type name
+func tableName(item any) string {
+	t := reflect.TypeOf(item)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return strings.ToLower(t.Name()) + "s"
+}
+
+func jsonFieldName(t reflect.Type, fieldName string) string {
+	field, ok := t.FieldByName(fieldName)
+	if !ok {
+		return strings.ToLower(fieldName)
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return fieldName
+	}
+	return name
+}
+
+func toRecord(item any) (map[string]any, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	record := map[string]any{}
+	err = json.Unmarshal(data, &record)
+	return record, err
+}
+
+func fromRecord(record map[string]any, dest any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func matches(record map[string]any, filter map[string]any) bool {
+	for key, value := range filter {
+		expected, err := toValue(value)
+		if err != nil || !reflect.DeepEqual(record[key], expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// toValue converts a filter value into its JSON decoded form so it can be compared with records
+func toValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
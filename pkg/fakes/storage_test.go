@@ -0,0 +1,33 @@
+package fakes
+
+import (
+	"testing"
+
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/store/storetest"
+	"todo-service/pkg/types"
+)
+
+func TestStorageContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storage.StorageAdapter { return NewStorage() })
+}
+
+func TestStorageRejectsDuplicateIds(t *testing.T) {
+	s := NewStorage()
+	if err := s.Create(types.Todo{Id: "1"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := s.Create(types.Todo{Id: "1"}); err == nil {
+		t.Error("Create() of a duplicate id succeeded, want an error")
+	}
+}
+
+func TestIdGeneratorIsAscending(t *testing.T) {
+	g := IdGenerator{}
+	first, _ := g.NewId()
+	second, _ := g.NewId()
+	if first >= second {
+		t.Errorf("NewId() = %q then %q, want ascending ids", first, second)
+	}
+}
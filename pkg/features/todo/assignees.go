@@ -0,0 +1,83 @@
+package todo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/types"
+)
+
+// MaxAssignees is the number of users a todo can be assigned to
+const MaxAssignees = 20
+
+// maxUserLength is the length of the longest user name todos can be assigned to
+const maxUserLength = 255
+
+// validateAssignees returns the assignees without duplicates, or a BadRequest when one of them
+// isn't a user name. Users added to current must be known users, users the todo is already
+// assigned to are kept even if they no longer are.
+func (t *todoService) validateAssignees(assignees types.Assignees, current types.Assignees) (types.Assignees, error) {
+	if len(assignees) == 0 {
+		return nil, nil
+	}
+
+	unique := types.Assignees{}
+	for _, user := range assignees {
+		if unique.Has(user) {
+			continue
+		}
+		if err := validateUser(user); err != nil {
+			return nil, err
+		}
+		if !current.Has(user) && len(t.users) > 0 && !slices.Contains(t.users, user) {
+			return nil, &serviceErrors.BadRequest{Message: fmt.Sprintf("assignee %q isn't a known user", user)}
+		}
+		unique = append(unique, user)
+	}
+	if len(unique) > MaxAssignees {
+		return nil, &serviceErrors.BadRequest{Message: fmt.Sprintf("a todo can't have more than %d assignees", MaxAssignees)}
+	}
+	return unique, nil
+}
+
+// validateUser checks user is a name todos can be assigned to. Quotes, backslashes and control
+// characters are rejected as they'd be escaped in the stored JSON array the filters match on.
+func validateUser(user string) error {
+	switch {
+	case user == "":
+		return &serviceErrors.BadRequest{Message: "assignees can't be empty"}
+	case len(user) > maxUserLength:
+		return &serviceErrors.BadRequest{Message: fmt.Sprintf("assignees can't be longer than %d characters", maxUserLength)}
+	case strings.ContainsFunc(user, func(r rune) bool { return r == '"' || r == '\\' || unicode.IsControl(r) }):
+		return &serviceErrors.BadRequest{Message: fmt.Sprintf("assignee %q can't contain quotes, backslashes or control characters", user)}
+	}
+	return nil
+}
+
+// assigned publishes an EventAssigned when the assignees of a todo changed, before is nil for
+// todos that were just created
+func (t *todoService) assigned(before *types.Todo, after types.Todo) {
+	current := types.Assignees{}
+	if before != nil {
+		current = before.Assignees
+	}
+
+	event := TodoEvent{Type: EventAssigned, Todo: after}
+	for _, user := range after.Assignees {
+		if !current.Has(user) {
+			event.Assigned = append(event.Assigned, user)
+		}
+	}
+	for _, user := range current {
+		if !after.Assignees.Has(user) {
+			event.Unassigned = append(event.Unassigned, user)
+		}
+	}
+	if len(event.Assigned) > 0 || len(event.Unassigned) > 0 {
+		t.events.publish(event)
+	}
+}
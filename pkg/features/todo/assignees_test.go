@@ -0,0 +1,128 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/types"
+)
+
+func TestFilterTodosByAssignee(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := newService(t, newAdapter(t))
+			// Every other todo is assigned to alice, the names look alike to catch partial matches
+			for i := 0; i < 5; i++ {
+				assignees := types.Assignees{"alice_"}
+				if i%2 == 0 {
+					assignees = types.Assignees{"bob", "alice"}
+				}
+				if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: fmt.Sprintf("todo %d", i), Assignees: assignees}); err != nil {
+					t.Fatalf("CreateTodo() error = %v", err)
+				}
+			}
+
+			filter := TodoFilter{Assignee: "alice"}
+			todos, next, err := service.FilterTodos(ctx, filter, 2, "")
+			if err != nil || len(todos) != 2 || next == "" {
+				t.Fatalf("FilterTodos() = %d todos, %q, %v, want 2 todos and a cursor", len(todos), next, err)
+			}
+			found := todos
+			todos, next, err = service.FilterTodos(ctx, filter, 2, next)
+			if err != nil || len(todos) != 1 || next != "" {
+				t.Fatalf("FilterTodos() of the last page = %d todos, %q, %v, want 1 todo and no cursor", len(todos), next, err)
+			}
+			found = append(found, todos...)
+			for i, todo := range found {
+				if want := fmt.Sprintf("todo %d", i*2); todo.Summary != want || !todo.Assignees.Has("alice") {
+					t.Errorf("FilterTodos() todo %d = %+v, want %q assigned to alice", i, todo, want)
+				}
+			}
+
+			if count, err := service.CountTodos(ctx, filter); err != nil || count != 3 {
+				t.Errorf("CountTodos() = %d, %v, want 3", count, err)
+			}
+			if todos, _, err := service.FilterTodos(ctx, TodoFilter{Assignee: "al%"}, 10, ""); err != nil || len(todos) != 0 {
+				t.Errorf("FilterTodos() of a wildcard = %d todos, %v, want none", len(todos), err)
+			}
+		})
+	}
+}
+
+func TestAssigneesValidation(t *testing.T) {
+	ctx := context.Background()
+	service, err := NewTodoService(TodoServiceProps{Storage: fakes.NewStorage(), Users: []string{"alice", "bob"}})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+
+	created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo", Assignees: types.Assignees{"alice", "bob", "alice"}})
+	if err != nil || !slices.Equal(created.Assignees, types.Assignees{"alice", "bob"}) {
+		t.Errorf("CreateTodo() = %v, %v, want the assignees without duplicates", created.Assignees, err)
+	}
+
+	tests := []struct {
+		name      string
+		assignees types.Assignees
+	}{
+		{name: "unknown user", assignees: types.Assignees{"mallory"}},
+		{name: "empty", assignees: types.Assignees{""}},
+		{name: "quote", assignees: types.Assignees{`al"ice`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo", Assignees: tt.assignees})
+			var badRequest *serviceErrors.BadRequest
+			if !errors.As(err, &badRequest) {
+				t.Errorf("CreateTodo() error = %v, want a BadRequest", err)
+			}
+		})
+	}
+}
+
+func TestAssignedEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service, _ := newService(t, fakes.NewStorage())
+	events := service.WatchTodos(ctx)
+
+	created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo", Assignees: types.Assignees{"alice"}})
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+	created.Assignees = types.Assignees{"bob"}
+	if err := service.UpdateTodo(ctx, created); err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+	created.Summary = "renamed"
+	if err := service.UpdateTodo(ctx, created); err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+
+	want := []TodoEvent{
+		{Type: EventCreated},
+		{Type: EventAssigned, Assigned: []string{"alice"}},
+		{Type: EventUpdated},
+		{Type: EventAssigned, Assigned: []string{"bob"}, Unassigned: []string{"alice"}},
+		// Changes that keep the assignees aren't assignments
+		{Type: EventUpdated},
+	}
+	for _, w := range want {
+		event := <-events
+		if event.Type != w.Type || !slices.Equal(event.Assigned, w.Assigned) || !slices.Equal(event.Unassigned, w.Unassigned) {
+			t.Errorf("event = %s %v %v, want %s %v %v", event.Type, event.Assigned, event.Unassigned, w.Type, w.Assigned, w.Unassigned)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %+v", event)
+	default:
+	}
+}
@@ -0,0 +1,251 @@
+package todo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/blob"
+	"todo-service/pkg/types"
+)
+
+// AttachmentLimits restricts the files that can be attached to todos
+type AttachmentLimits struct {
+	// MaxSize is the largest size of a file in bytes
+	MaxSize int64
+	// ContentTypes are the media types files can have
+	ContentTypes []string
+}
+
+// DefaultAttachmentLimits allow images, PDF documents and text files of up to 10 MiB
+var DefaultAttachmentLimits = AttachmentLimits{
+	MaxSize:      10 << 20,
+	ContentTypes: []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"},
+}
+
+var (
+	// ErrAttachmentTooLarge is returned when attaching a file larger than AttachmentLimits.MaxSize
+	ErrAttachmentTooLarge = errors.New("the file is too large")
+	// ErrAttachmentType is returned when attaching a file whose type isn't one of the allowed
+	// AttachmentLimits.ContentTypes
+	ErrAttachmentType = errors.New("the file type isn't allowed")
+)
+
+// maxAttachmentName is the largest number of bytes kept of the name of an attached file
+const maxAttachmentName = 255
+
+// attachmentFilter selects the attachments of the todo id
+func attachmentFilter(id string) map[string]any {
+	return map[string]any{"todoId": id}
+}
+
+// attachmentKey is the key of the blob holding the content of an attachment
+func attachmentKey(attachment types.Attachment) string {
+	return attachment.TodoId + "/" + attachment.Id
+}
+
+// attachmentName returns the base name of the uploaded file name, which some clients send as a
+// full path
+func attachmentName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		return "attachment"
+	}
+	if len(name) > maxAttachmentName {
+		name = strings.ToValidUTF8(name[:maxAttachmentName], "")
+	}
+	return name
+}
+
+func (t *todoService) ListAttachments(ctx context.Context, id string, limit int, cursor string) ([]types.Attachment, string, error) {
+	if _, err := t.GetTodo(ctx, id); err != nil {
+		return nil, "", err
+	}
+	attachments := []types.Attachment{}
+	next, err := t.storage.List(ctx, &attachments, "Id", attachmentFilter(id), limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	return attachments, next, nil
+}
+
+func (t *todoService) AddAttachment(ctx context.Context, id string, name string, contentType string, content io.Reader) (types.Attachment, error) {
+	if t.blobs == nil {
+		return types.Attachment{}, &serviceErrors.ServiceUnavailable{Message: "attachments aren't configured"}
+	}
+	if _, err := t.GetTodo(ctx, id); err != nil {
+		return types.Attachment{}, err
+	}
+
+	// The content is spooled to a temporary file while it's measured and hashed, so blob stores
+	// are given its size and files that are too large are rejected before they are stored
+	file, err := os.CreateTemp("", "todo-attachment-*")
+	if err != nil {
+		return types.Attachment{}, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(content, t.attachmentLimits.MaxSize+1))
+	if err != nil {
+		return types.Attachment{}, err
+	}
+	if size > t.attachmentLimits.MaxSize {
+		return types.Attachment{}, fmt.Errorf("%w, files can't be larger than %d bytes", ErrAttachmentTooLarge, t.attachmentLimits.MaxSize)
+	}
+	if size == 0 {
+		return types.Attachment{}, &serviceErrors.BadRequest{Message: "the file is empty"}
+	}
+
+	contentType, err = t.attachmentType(file, contentType)
+	if err != nil {
+		return types.Attachment{}, err
+	}
+
+	attachmentId, err := t.ids.NewId()
+	if err != nil {
+		return types.Attachment{}, err
+	}
+	attachment := types.Attachment{
+		Id:          attachmentId,
+		TodoId:      id,
+		Name:        attachmentName(name),
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		Author:      actor.FromContext(ctx),
+		CreatedAt:   t.clock.Now(),
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return types.Attachment{}, err
+	}
+	if err := t.blobs.Put(ctx, attachmentKey(attachment), file, size, contentType); err != nil {
+		return types.Attachment{}, err
+	}
+	if err := t.storage.Create(ctx, attachment); err != nil {
+		t.deleteBlob(ctx, attachment)
+		return types.Attachment{}, err
+	}
+	t.logger.Debug("attached file to todo", slog.String("id", id), slog.String("attachment", attachment.Id))
+	return attachment, nil
+}
+
+// attachmentType returns the type of the content of file, which is sniffed rather than trusting the
+// declared type clients send. The declared type is only used for content that can't be sniffed.
+func (t *todoService) attachmentType(file *os.File, declared string) (string, error) {
+	head := make([]byte, 512)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	contentType := http.DetectContentType(head[:n])
+	if strings.HasPrefix(contentType, "application/octet-stream") && declared != "" {
+		contentType = declared
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !slices.Contains(t.attachmentLimits.ContentTypes, mediaType) {
+		return "", fmt.Errorf("%w, files must be one of %s", ErrAttachmentType, strings.Join(t.attachmentLimits.ContentTypes, ", "))
+	}
+	return contentType, nil
+}
+
+func (t *todoService) GetAttachment(ctx context.Context, id string, attachmentId string) (types.Attachment, io.ReadCloser, error) {
+	if t.blobs == nil {
+		return types.Attachment{}, nil, &serviceErrors.ServiceUnavailable{Message: "attachments aren't configured"}
+	}
+	attachment, err := t.getAttachment(ctx, id, attachmentId)
+	if err != nil {
+		return types.Attachment{}, nil, err
+	}
+	content, err := t.blobs.Get(ctx, attachmentKey(attachment))
+	if errors.Is(err, blob.ErrNotFound) {
+		return types.Attachment{}, nil, &serviceErrors.NotFound{Message: fmt.Sprintf("the content of attachment %s is missing", attachmentId)}
+	}
+	if err != nil {
+		return types.Attachment{}, nil, err
+	}
+	return attachment, content, nil
+}
+
+func (t *todoService) DeleteAttachment(ctx context.Context, id string, attachmentId string) error {
+	attachment, err := t.getAttachment(ctx, id, attachmentId)
+	var notFound *serviceErrors.NotFound
+	if errors.As(err, &notFound) {
+		// Deleting a missing attachment succeeds without changing anything, like deleting a todo
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return t.deleteAttachment(ctx, attachment)
+}
+
+// getAttachment returns the attachment attachmentId of the todo id, attachments of other todos
+// aren't found
+func (t *todoService) getAttachment(ctx context.Context, id string, attachmentId string) (types.Attachment, error) {
+	attachment := types.Attachment{}
+	err := t.storage.Get(ctx, &attachment, map[string]any{"id": attachmentId})
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && attachment.TodoId != id) {
+		return types.Attachment{}, &serviceErrors.NotFound{Message: fmt.Sprintf("todo %s has no attachment %s", id, attachmentId)}
+	}
+	return attachment, err
+}
+
+// deleteAttachment deletes the content of attachment before the attachment, so content is never
+// left behind without an attachment pointing to it
+func (t *todoService) deleteAttachment(ctx context.Context, attachment types.Attachment) error {
+	if t.blobs != nil {
+		if err := t.blobs.Delete(ctx, attachmentKey(attachment)); err != nil {
+			return err
+		}
+	}
+	err := t.storage.Delete(ctx, &types.Attachment{}, map[string]any{"id": attachment.Id})
+	if err == nil {
+		t.logger.Debug("deleted attachment", slog.String("id", attachment.TodoId), slog.String("attachment", attachment.Id))
+	}
+	return err
+}
+
+func (t *todoService) deleteBlob(ctx context.Context, attachment types.Attachment) {
+	if err := t.blobs.Delete(ctx, attachmentKey(attachment)); err != nil {
+		t.logger.Error("failed to delete the content of attachment", slog.String("attachment", attachment.Id), slog.Any("error", err))
+	}
+}
+
+// deleteAttachments deletes the attachments of the deleted todo id and their content, stopping at
+// the first one that can't be deleted
+func (t *todoService) deleteAttachments(ctx context.Context, id string) {
+	for {
+		attachments := []types.Attachment{}
+		if _, err := t.storage.List(ctx, &attachments, "Id", attachmentFilter(id), indexPageSize, ""); err != nil {
+			t.logger.Error("failed to list the attachments of todo", slog.String("id", id), slog.Any("error", err))
+			return
+		}
+		if len(attachments) == 0 {
+			return
+		}
+		for _, attachment := range attachments {
+			if err := t.deleteAttachment(ctx, attachment); err != nil {
+				t.logger.Error("failed to delete attachment", slog.String("id", id), slog.String("attachment", attachment.Id), slog.Any("error", err))
+				return
+			}
+		}
+	}
+}
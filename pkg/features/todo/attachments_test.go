@@ -0,0 +1,145 @@
+package todo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/blob"
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/types"
+)
+
+// png is the start of a PNG image, enough for its type to be detected
+const png = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+func newAttachmentService(t *testing.T, adapter storage.StorageAdapter, limits AttachmentLimits) (TodoService, blob.Store) {
+	t.Helper()
+	blobs, err := blob.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+	service, err := NewTodoService(TodoServiceProps{
+		Storage:          adapter,
+		Clock:            fakes.NewClock(now),
+		IdGenerator:      &fakes.IdGenerator{},
+		Blobs:            blobs,
+		AttachmentLimits: limits,
+	})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+	return service, blobs
+}
+
+func TestTodoServiceAttachments(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			testAttachments(t, newAdapter(t))
+		})
+	}
+}
+
+func testAttachments(t *testing.T, adapter storage.StorageAdapter) {
+	ctx := context.Background()
+	service, blobs := newAttachmentService(t, adapter, AttachmentLimits{})
+	todo, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Pick up the groceries"})
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+
+	// The type is detected from the content rather than trusting the declared one
+	attachment, err := service.AddAttachment(ctx, todo.Id, `C:\Users\alice\receipt.png`, "application/pdf", strings.NewReader(png))
+	if err != nil {
+		t.Fatalf("AddAttachment() error = %v", err)
+	}
+	sum := sha256.Sum256([]byte(png))
+	want := types.Attachment{Id: attachment.Id, TodoId: todo.Id, Name: "receipt.png", ContentType: "image/png", Size: int64(len(png)), Checksum: hex.EncodeToString(sum[:]), Author: actor.Anonymous, CreatedAt: now}
+	if attachment != want {
+		t.Errorf("AddAttachment() = %+v, want %+v", attachment, want)
+	}
+
+	got, content, err := service.GetAttachment(ctx, todo.Id, attachment.Id)
+	if err != nil {
+		t.Fatalf("GetAttachment() error = %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if got != attachment || string(data) != png {
+		t.Errorf("GetAttachment() = %+v, %q, want the attachment and its content", got, data)
+	}
+	if _, _, err := service.GetAttachment(ctx, "another", attachment.Id); !isNotFound(err) {
+		t.Errorf("GetAttachment() through another todo error = %v, want not found", err)
+	}
+
+	list, _, err := service.ListAttachments(ctx, todo.Id, 10, "")
+	if err != nil || len(list) != 1 || list[0] != attachment {
+		t.Errorf("ListAttachments() = %+v, %v, want the attachment", list, err)
+	}
+
+	if err := service.DeleteAttachment(ctx, todo.Id, attachment.Id); err != nil {
+		t.Fatalf("DeleteAttachment() error = %v", err)
+	}
+	if _, err := blobs.Get(ctx, attachmentKey(attachment)); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("content of a deleted attachment error = %v, want ErrNotFound", err)
+	}
+	if err := service.DeleteAttachment(ctx, todo.Id, attachment.Id); err != nil {
+		t.Errorf("DeleteAttachment() of a deleted attachment error = %v, want nil", err)
+	}
+
+	// The attachments and their content are deleted with the todo
+	kept, err := service.AddAttachment(ctx, todo.Id, "notes.txt", "", strings.NewReader("Bring bags"))
+	if err != nil {
+		t.Fatalf("AddAttachment() error = %v", err)
+	}
+	if err := service.DeleteTodo(ctx, todo.Id); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+	if _, err := blobs.Get(ctx, attachmentKey(kept)); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("content of an attachment of a deleted todo error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTodoServiceAttachmentLimits(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newAttachmentService(t, fakes.NewStorage(), AttachmentLimits{MaxSize: 16, ContentTypes: []string{"image/png", "application/zip"}})
+	todo, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Pick up the groceries"})
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		content     string
+		wantErr     error
+	}{
+		{name: "allowed", content: png},
+		{name: "too large", content: png + "0123456789", wantErr: ErrAttachmentTooLarge},
+		{name: "type not allowed", contentType: "image/png", content: "<html></html>", wantErr: ErrAttachmentType},
+		{name: "declared type of unknown content", contentType: "application/zip", content: "\x00\x01\x02"},
+		{name: "unknown content", content: "\x00\x01\x02", wantErr: ErrAttachmentType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.AddAttachment(ctx, todo.Id, "file", tt.contentType, strings.NewReader(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AddAttachment() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := service.AddAttachment(ctx, "missing", "file", "", strings.NewReader(png)); !isNotFound(err) {
+		t.Errorf("AddAttachment() to a missing todo error = %v, want not found", err)
+	}
+	if _, err := service.AddAttachment(ctx, todo.Id, "file", "", strings.NewReader("")); err == nil {
+		t.Errorf("AddAttachment() of an empty file error = nil, want a bad request")
+	}
+}
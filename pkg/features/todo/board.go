@@ -0,0 +1,25 @@
+package todo
+
+import (
+	"context"
+
+	"todo-service/pkg/types"
+)
+
+func (t *todoService) TodoBoard(ctx context.Context, filter TodoFilter, limit int) (types.TodoBoard, error) {
+	board := types.TodoBoard{Columns: []types.BoardColumn{}}
+	for _, status := range t.workflow.Statuses {
+		filter.Status = status
+		todos, next, err := t.FilterTodos(ctx, filter, limit, "")
+		if err != nil {
+			return types.TodoBoard{}, err
+		}
+		board.Columns = append(board.Columns, types.BoardColumn{
+			Status: status,
+			Done:   t.workflow.isDone(status),
+			Todos:  todos,
+			Next:   next,
+		})
+	}
+	return board, nil
+}
@@ -0,0 +1,120 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/types"
+)
+
+// MaxCommentLength is the largest number of characters a comment can hold
+const MaxCommentLength = 10000
+
+// commentFilter selects the comments of the todo id
+func commentFilter(id string) map[string]any {
+	return map[string]any{"todoId": id}
+}
+
+func validateComment(comment types.CommentUpdate) error {
+	switch {
+	case strings.TrimSpace(comment.Body) == "":
+		return &serviceErrors.BadRequest{Message: "body is required"}
+	case utf8.RuneCountInString(comment.Body) > MaxCommentLength:
+		return &serviceErrors.BadRequest{Message: fmt.Sprintf("body can't be longer than %d characters", MaxCommentLength)}
+	}
+	return nil
+}
+
+func (t *todoService) ListComments(ctx context.Context, id string, limit int, cursor string) ([]types.Comment, string, error) {
+	if _, err := t.GetTodo(ctx, id); err != nil {
+		return nil, "", err
+	}
+	comments := []types.Comment{}
+	next, err := t.storage.List(ctx, &comments, "Id", commentFilter(id), limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	return comments, next, nil
+}
+
+func (t *todoService) CreateComment(ctx context.Context, id string, commentToCreate types.CommentUpdate) (types.Comment, error) {
+	if err := validateComment(commentToCreate); err != nil {
+		return types.Comment{}, err
+	}
+	if _, err := t.GetTodo(ctx, id); err != nil {
+		return types.Comment{}, err
+	}
+
+	commentId, err := t.ids.NewId()
+	if err != nil {
+		return types.Comment{}, err
+	}
+	comment := types.Comment{
+		Id:        commentId,
+		TodoId:    id,
+		Author:    actor.FromContext(ctx),
+		Body:      commentToCreate.Body,
+		CreatedAt: t.clock.Now(),
+	}
+	comment.UpdatedAt = comment.CreatedAt
+
+	err = t.storage.Create(ctx, comment)
+	if err == nil {
+		t.logger.Debug("created comment", slog.String("id", comment.Id), slog.String("todo", id))
+	}
+	return comment, err
+}
+
+func (t *todoService) UpdateComment(ctx context.Context, id string, commentId string, commentToUpdate types.CommentUpdate) (types.Comment, error) {
+	if err := validateComment(commentToUpdate); err != nil {
+		return types.Comment{}, err
+	}
+	comment, err := t.getComment(ctx, id, commentId)
+	if err != nil {
+		return types.Comment{}, err
+	}
+
+	// Edits keep the author, who wrote the comment in the first place
+	comment.Body = commentToUpdate.Body
+	comment.UpdatedAt = t.clock.Now()
+	err = t.storage.Update(ctx, comment, map[string]any{"id": commentId})
+	if err == nil {
+		t.logger.Debug("updated comment", slog.String("id", commentId), slog.String("todo", id))
+	}
+	return comment, err
+}
+
+func (t *todoService) DeleteComment(ctx context.Context, id string, commentId string) error {
+	_, err := t.getComment(ctx, id, commentId)
+	var notFound *serviceErrors.NotFound
+	if errors.As(err, &notFound) {
+		// Deleting a missing comment succeeds without changing anything, like deleting a todo
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = t.storage.Delete(ctx, &types.Comment{}, map[string]any{"id": commentId})
+	if err == nil {
+		t.logger.Debug("deleted comment", slog.String("id", commentId), slog.String("todo", id))
+	}
+	return err
+}
+
+// getComment returns the comment commentId of the todo id, comments of other todos aren't found
+func (t *todoService) getComment(ctx context.Context, id string, commentId string) (types.Comment, error) {
+	comment := types.Comment{}
+	err := t.storage.Get(ctx, &comment, map[string]any{"id": commentId})
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && comment.TodoId != id) {
+		return types.Comment{}, &serviceErrors.NotFound{Message: fmt.Sprintf("todo %s has no comment %s", id, commentId)}
+	}
+	return comment, err
+}
@@ -0,0 +1,112 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/types"
+)
+
+func TestTodoServiceComments(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := actor.NewContext(context.Background(), "alice")
+			service, clock := newService(t, newAdapter(t))
+			todo, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Pick up the groceries"})
+			if err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+
+			bodies := []string{"Check the **opening hours** first", "The shop closes at 6pm", "Done, see the [receipt](https://example.com)"}
+			created := []types.Comment{}
+			for _, body := range bodies {
+				comment, err := service.CreateComment(ctx, todo.Id, types.CommentUpdate{Body: body})
+				if err != nil {
+					t.Fatalf("CreateComment() error = %v", err)
+				}
+				created = append(created, comment)
+			}
+			if created[0].Author != "alice" || created[0].TodoId != todo.Id || !created[0].CreatedAt.Equal(now) {
+				t.Errorf("CreateComment() = %+v, want a comment by alice on the todo", created[0])
+			}
+
+			// Paging
+			first, next, err := service.ListComments(ctx, todo.Id, 2, "")
+			if err != nil || len(first) != 2 || next == "" || first[0].Body != bodies[0] {
+				t.Fatalf("ListComments() first page = %+v, %q, %v, want the first 2 comments and a cursor", first, next, err)
+			}
+			rest, _, err := service.ListComments(ctx, todo.Id, 2, next)
+			if err != nil || len(rest) != 1 || rest[0].Id != created[2].Id {
+				t.Errorf("ListComments() second page = %+v, %v, want the last comment", rest, err)
+			}
+
+			// Edits keep the author
+			clock.Advance(time.Minute)
+			edited, err := service.UpdateComment(context.Background(), todo.Id, created[1].Id, types.CommentUpdate{Body: "The shop closes at 7pm"})
+			if err != nil || edited.Author != "alice" || edited.Body != "The shop closes at 7pm" || !edited.UpdatedAt.Equal(now.Add(time.Minute)) {
+				t.Errorf("UpdateComment() = %+v, %v, want the edited comment by alice", edited, err)
+			}
+			if _, err := service.UpdateComment(ctx, "another", created[1].Id, types.CommentUpdate{Body: "Moved"}); !isNotFound(err) {
+				t.Errorf("UpdateComment() through another todo error = %v, want not found", err)
+			}
+
+			if err := service.DeleteComment(ctx, todo.Id, created[0].Id); err != nil {
+				t.Fatalf("DeleteComment() error = %v", err)
+			}
+			if err := service.DeleteComment(ctx, todo.Id, created[0].Id); err != nil {
+				t.Errorf("DeleteComment() of a deleted comment error = %v, want nil", err)
+			}
+			if comments, _, err := service.ListComments(ctx, todo.Id, 10, ""); err != nil || len(comments) != 2 {
+				t.Errorf("ListComments() after the deletion = %+v, %v, want 2 comments", comments, err)
+			}
+
+			// The comments are deleted with the todo
+			if err := service.DeleteTodo(ctx, todo.Id); err != nil {
+				t.Fatalf("DeleteTodo() error = %v", err)
+			}
+			if _, _, err := service.ListComments(ctx, todo.Id, 10, ""); !isNotFound(err) {
+				t.Errorf("ListComments() of a deleted todo error = %v, want not found", err)
+			}
+			if _, err := service.RevertTodo(ctx, todo.Id, firstRevision(t, service, todo.Id)); err != nil {
+				t.Fatalf("RevertTodo() error = %v", err)
+			}
+			if comments, _, err := service.ListComments(ctx, todo.Id, 10, ""); err != nil || len(comments) != 0 {
+				t.Errorf("ListComments() of a restored todo = %+v, %v, want none", comments, err)
+			}
+		})
+	}
+}
+
+func TestTodoServiceCommentValidation(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newService(t, adapters["fake"](t))
+	todo, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Pick up the groceries"})
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+
+	for _, body := range []string{"", "  \n", strings.Repeat("a", MaxCommentLength+1)} {
+		var badRequest *serviceErrors.BadRequest
+		if _, err := service.CreateComment(ctx, todo.Id, types.CommentUpdate{Body: body}); !errors.As(err, &badRequest) {
+			t.Errorf("CreateComment() of a %d characters body error = %v, want a bad request", len(body), err)
+		}
+	}
+	if _, err := service.CreateComment(ctx, "missing", types.CommentUpdate{Body: "Hello"}); !isNotFound(err) {
+		t.Errorf("CreateComment() on a missing todo error = %v, want not found", err)
+	}
+}
+
+func firstRevision(t *testing.T, service TodoService, id string) string {
+	t.Helper()
+	revisions, _, err := service.TodoHistory(context.Background(), id, 1, "")
+	if err != nil || len(revisions) == 0 {
+		t.Fatalf("TodoHistory() = %+v, %v, want a revision", revisions, err)
+	}
+	return revisions[0].Id
+}
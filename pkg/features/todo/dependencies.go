@@ -0,0 +1,217 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/types"
+)
+
+// dependencyId is the id of the dependency of the todo id on the todo blockerId
+func dependencyId(id string, blockerId string) string {
+	return id + ":" + blockerId
+}
+
+// listDependencies returns every dependency selected by filter
+func (t *todoService) listDependencies(ctx context.Context, filter map[string]any) ([]types.Dependency, error) {
+	dependencies := []types.Dependency{}
+	cursor := ""
+	for {
+		page := []types.Dependency{}
+		next, err := t.storage.List(ctx, &page, "Id", filter, indexPageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, page...)
+		if next == "" {
+			return dependencies, nil
+		}
+		cursor = next
+	}
+}
+
+// blockers returns the ids of the todos the todo id is blocked by
+func (t *todoService) blockers(ctx context.Context, id string) ([]string, error) {
+	dependencies, err := t.listDependencies(ctx, map[string]any{"todoId": id})
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, dependency := range dependencies {
+		ids = append(ids, dependency.BlockerId)
+	}
+	return ids, nil
+}
+
+// openBlockers returns the ids of the todos the todo id is blocked by that aren't done
+func (t *todoService) openBlockers(ctx context.Context, id string) ([]string, error) {
+	ids, err := t.blockers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	blockers, err := t.GetTodos(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	open := []string{}
+	for _, blocker := range blockers {
+		if !blocker.Done {
+			open = append(open, blocker.Id)
+		}
+	}
+	slices.Sort(open)
+	return open, nil
+}
+
+func (t *todoService) TodoDependencies(ctx context.Context, id string) (types.TodoDependencies, error) {
+	todo, err := t.GetTodo(ctx, id)
+	if err != nil {
+		return types.TodoDependencies{}, err
+	}
+	blockedBy, err := t.blockers(ctx, id)
+	if err != nil {
+		return types.TodoDependencies{}, err
+	}
+	blocking, err := t.listDependencies(ctx, map[string]any{"blockerId": id})
+	if err != nil {
+		return types.TodoDependencies{}, err
+	}
+	open, err := t.openBlockers(ctx, id)
+	if err != nil {
+		return types.TodoDependencies{}, err
+	}
+
+	dependencies := types.TodoDependencies{BlockedBy: blockedBy, Blocks: []string{}, Ready: !todo.Done && len(open) == 0}
+	for _, dependency := range blocking {
+		dependencies.Blocks = append(dependencies.Blocks, dependency.TodoId)
+	}
+	return dependencies, nil
+}
+
+func (t *todoService) AddDependency(ctx context.Context, id string, blockerId string) error {
+	if id == blockerId {
+		return &serviceErrors.BadRequest{Message: "a todo can't be blocked by itself"}
+	}
+	for _, todoId := range []string{id, blockerId} {
+		if _, err := t.GetTodo(ctx, todoId); err != nil {
+			return err
+		}
+	}
+
+	cycle, err := t.dependencyPath(ctx, blockerId, id)
+	if err != nil {
+		return err
+	}
+	if cycle != nil {
+		return &serviceErrors.BadRequest{Message: fmt.Sprintf("todo %s can't be blocked by todo %s as it would create a cycle: %s", id, blockerId, strings.Join(append([]string{id}, cycle...), " → "))}
+	}
+
+	dependency := types.Dependency{Id: dependencyId(id, blockerId), TodoId: id, BlockerId: blockerId, CreatedAt: t.clock.Now()}
+	// Dependencies are keyed by the two todos, adding one again keeps it
+	err = t.storage.Update(ctx, dependency, map[string]any{"id": dependency.Id})
+	if err == nil {
+		t.logger.Debug("added dependency", slog.String("id", id), slog.String("blocker", blockerId))
+	}
+	return err
+}
+
+// dependencyPath returns the ids of the todos leading from the todo from to the todo to through
+// what they are blocked by, starting with from and ending with to, or nil when to isn't reachable
+func (t *todoService) dependencyPath(ctx context.Context, from string, to string) ([]string, error) {
+	// previous holds the todo each visited todo was reached from
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			path := []string{}
+			for id := to; id != ""; id = previous[id] {
+				path = append([]string{id}, path...)
+			}
+			return path, nil
+		}
+
+		blockers, err := t.blockers(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		for _, blocker := range blockers {
+			if _, seen := previous[blocker]; !seen {
+				previous[blocker] = current
+				queue = append(queue, blocker)
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (t *todoService) RemoveDependency(ctx context.Context, id string, blockerId string) error {
+	// Removing a missing dependency succeeds without changing anything, like deleting a todo
+	err := t.storage.Delete(ctx, &types.Dependency{}, map[string]any{"id": dependencyId(id, blockerId)})
+	if err == nil {
+		t.logger.Debug("removed dependency", slog.String("id", id), slog.String("blocker", blockerId))
+	}
+	return err
+}
+
+// checkBlockers refuses to mark the todo id as done while todos it's blocked by are open, unless
+// the service allows it
+func (t *todoService) checkBlockers(ctx context.Context, id string) error {
+	if t.allowDoneWhileBlocked {
+		return nil
+	}
+	open, err := t.openBlockers(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(open) > 0 {
+		return &serviceErrors.BadRequest{Message: fmt.Sprintf("todo %s can't be done before the todos it's blocked by: %s", id, strings.Join(open, ", "))}
+	}
+	return nil
+}
+
+// blockedTodos returns the ids of the todos blocked by todos that aren't done
+func (t *todoService) blockedTodos(ctx context.Context) (map[string]bool, error) {
+	dependencies, err := t.listDependencies(ctx, map[string]any{})
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, dependency := range dependencies {
+		if !slices.Contains(ids, dependency.BlockerId) {
+			ids = append(ids, dependency.BlockerId)
+		}
+	}
+	blockers, err := t.GetTodos(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	open := map[string]bool{}
+	for _, blocker := range blockers {
+		open[blocker.Id] = !blocker.Done
+	}
+
+	blocked := map[string]bool{}
+	for _, dependency := range dependencies {
+		if open[dependency.BlockerId] {
+			blocked[dependency.TodoId] = true
+		}
+	}
+	return blocked, nil
+}
+
+// deleteDependencies deletes the dependencies of the deleted todo id, both on the todos it was
+// blocked by and of the todos it blocked
+func (t *todoService) deleteDependencies(ctx context.Context, id string) {
+	for _, filter := range []map[string]any{{"todoId": id}, {"blockerId": id}} {
+		if err := t.storage.Delete(ctx, &types.Dependency{}, filter); err != nil {
+			t.logger.Error("failed to delete the dependencies of todo", slog.String("id", id), slog.Any("error", err))
+		}
+	}
+}
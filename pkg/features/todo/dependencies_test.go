@@ -0,0 +1,123 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/types"
+)
+
+// createTodos creates count todos and returns their ids
+func createTodos(t *testing.T, service TodoService, count int) []string {
+	t.Helper()
+	ids := []string{}
+	for i := 0; i < count; i++ {
+		created, err := service.CreateTodo(context.Background(), types.TodoUpdate{Summary: "todo"})
+		if err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+		ids = append(ids, created.Id)
+	}
+	return ids
+}
+
+func isBadRequest(err error) bool {
+	var badRequest *serviceErrors.BadRequest
+	return errors.As(err, &badRequest)
+}
+
+func TestDependencies(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := newService(t, newAdapter(t))
+			ids := createTodos(t, service, 3)
+			first, second, third := ids[0], ids[1], ids[2]
+
+			// third is blocked by second, which is blocked by first
+			for _, dependency := range [][2]string{{third, second}, {second, first}, {third, second}} {
+				if err := service.AddDependency(ctx, dependency[0], dependency[1]); err != nil {
+					t.Fatalf("AddDependency(%s, %s) error = %v", dependency[0], dependency[1], err)
+				}
+			}
+
+			got, err := service.TodoDependencies(ctx, second)
+			want := types.TodoDependencies{BlockedBy: []string{first}, Blocks: []string{third}}
+			if err != nil || !slices.Equal(got.BlockedBy, want.BlockedBy) || !slices.Equal(got.Blocks, want.Blocks) || got.Ready {
+				t.Errorf("TodoDependencies() = %+v, %v, want %+v", got, err, want)
+			}
+
+			if err := service.AddDependency(ctx, first, third); !isBadRequest(err) || !strings.Contains(err.Error(), "cycle") {
+				t.Errorf("AddDependency() closing a cycle error = %v, want a BadRequest", err)
+			}
+			if err := service.AddDependency(ctx, first, first); !isBadRequest(err) {
+				t.Errorf("AddDependency() on itself error = %v, want a BadRequest", err)
+			}
+			if err := service.AddDependency(ctx, first, "missing"); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("AddDependency() on a missing todo error = %v, want %v", err, storage.ErrNotFound)
+			}
+
+			ready, _, err := service.FilterTodos(ctx, TodoFilter{Ready: true}, 10, "")
+			if err != nil || len(ready) != 1 || ready[0].Id != first {
+				t.Errorf("FilterTodos() of ready todos = %+v, %v, want the first todo", ready, err)
+			}
+
+			// second can't be done before first
+			todo, _ := service.GetTodo(ctx, second)
+			todo.Done = true
+			if err := service.UpdateTodo(ctx, todo); !isBadRequest(err) {
+				t.Errorf("UpdateTodo() of a blocked todo error = %v, want a BadRequest", err)
+			}
+			todo, _ = service.GetTodo(ctx, first)
+			todo.Done = true
+			if err := service.UpdateTodo(ctx, todo); err != nil {
+				t.Fatalf("UpdateTodo() error = %v", err)
+			}
+			ready, _, err = service.FilterTodos(ctx, TodoFilter{Ready: true}, 10, "")
+			if err != nil || len(ready) != 1 || ready[0].Id != second {
+				t.Errorf("FilterTodos() of ready todos after the first is done = %+v, %v, want the second todo", ready, err)
+			}
+			if count, err := service.CountTodos(ctx, TodoFilter{Ready: true}); err != nil || count != 1 {
+				t.Errorf("CountTodos() of ready todos = %d, %v, want 1", count, err)
+			}
+
+			if err := service.RemoveDependency(ctx, third, second); err != nil {
+				t.Fatalf("RemoveDependency() error = %v", err)
+			}
+			if err := service.RemoveDependency(ctx, third, second); err != nil {
+				t.Errorf("RemoveDependency() of a removed dependency error = %v, want nil", err)
+			}
+			if err := service.DeleteTodo(ctx, first); err != nil {
+				t.Fatalf("DeleteTodo() error = %v", err)
+			}
+			if got, err := service.TodoDependencies(ctx, second); err != nil || len(got.BlockedBy) != 0 || len(got.Blocks) != 0 || !got.Ready {
+				t.Errorf("TodoDependencies() after the removals = %+v, %v, want none", got, err)
+			}
+		})
+	}
+}
+
+func TestAllowDoneWhileBlocked(t *testing.T) {
+	ctx := context.Background()
+	service, err := NewTodoService(TodoServiceProps{Storage: fakes.NewStorage(), AllowDoneWhileBlocked: true})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+	ids := createTodos(t, service, 2)
+	if err := service.AddDependency(ctx, ids[1], ids[0]); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+
+	todo, _ := service.GetTodo(ctx, ids[1])
+	todo.Done = true
+	if err := service.UpdateTodo(ctx, todo); err != nil {
+		t.Errorf("UpdateTodo() of a blocked todo error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,80 @@
+package todo
+
+import (
+	"context"
+	"sync"
+
+	"todo-service/pkg/types"
+)
+
+// EventType is the kind of change a TodoEvent describes
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+	// EventAssigned follows the EventCreated or EventUpdated of a todo whose assignees changed
+	EventAssigned EventType = "assigned"
+)
+
+// TodoEvent describes a change made to a todo. Todo holds the todo after the change, only its Id
+// is set for deleted todos.
+type TodoEvent struct {
+	Type EventType
+	Todo types.Todo
+	// Assigned and Unassigned are the users an EventAssigned added to and removed from the todo
+	Assigned   []string
+	Unassigned []string
+}
+
+// eventBufferSize is the number of events a watcher can fall behind before it's dropped
+const eventBufferSize = 64
+
+// events fans out the changes made through a TodoService to its watchers. Changes made by other
+// instances of the service aren't seen.
+type events struct {
+	mu       sync.Mutex
+	watchers map[chan TodoEvent]struct{}
+}
+
+func (e *events) watch(ctx context.Context) <-chan TodoEvent {
+	ch := make(chan TodoEvent, eventBufferSize)
+
+	e.mu.Lock()
+	if e.watchers == nil {
+		e.watchers = map[chan TodoEvent]struct{}{}
+	}
+	e.watchers[ch] = struct{}{}
+	e.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		e.remove(ch)
+	}()
+	return ch
+}
+
+// publish sends event to every watcher. Watchers that fell too far behind are dropped by closing
+// their channel, so they know they missed events rather than silently skipping some.
+func (e *events) publish(event TodoEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for ch := range e.watchers {
+		select {
+		case ch <- event:
+		default:
+			delete(e.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (e *events) remove(ch chan TodoEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.watchers[ch]; ok {
+		delete(e.watchers, ch)
+		close(ch)
+	}
+}
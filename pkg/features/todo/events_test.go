@@ -0,0 +1,59 @@
+package todo
+
+import (
+	"context"
+	"testing"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/types"
+)
+
+func TestWatchTodos(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	service, _ := newService(t, fakes.NewStorage())
+	events := service.WatchTodos(ctx)
+
+	created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo"})
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+	created.Done = true
+	if err := service.UpdateTodo(ctx, created); err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+	if err := service.DeleteTodo(ctx, created.Id); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+
+	for _, want := range []EventType{EventCreated, EventUpdated, EventDeleted} {
+		event := <-events
+		if event.Type != want || event.Todo.Id != created.Id {
+			t.Errorf("event = %+v, want a %s event for %s", event, want, created.Id)
+		}
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Error("the events channel is still open after the context is done")
+	}
+}
+
+func TestWatchTodosDropsSlowWatchers(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newService(t, fakes.NewStorage())
+	events := service.WatchTodos(ctx)
+
+	for i := 0; i <= eventBufferSize; i++ {
+		if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo"}); err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+	if received != eventBufferSize {
+		t.Errorf("received %d events before the channel was closed, want %d", received, eventBufferSize)
+	}
+}
@@ -0,0 +1,183 @@
+package todo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"todo-service/pkg/store"
+	"todo-service/pkg/types"
+)
+
+// TodoFilter selects the todos FilterTodos lists, the zero value selects every todo
+type TodoFilter struct {
+	// Assignee selects the todos assigned to a user
+	Assignee string
+	// Status selects the todos with a status of the workflow
+	Status string
+	// Ready selects the open todos that aren't blocked by open todos
+	Ready bool
+}
+
+func (f TodoFilter) IsZero() bool {
+	return f == TodoFilter{}
+}
+
+// matcher returns the function reporting whether the filter selects a todo, for storage that
+// can't filter
+func (t *todoService) matcher(ctx context.Context, f TodoFilter) (func(types.Todo) bool, error) {
+	blocked := map[string]bool{}
+	if f.Ready {
+		var err error
+		if blocked, err = t.blockedTodos(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return func(todo types.Todo) bool {
+		return (f.Assignee == "" || todo.Assignees.Has(f.Assignee)) &&
+			(f.Status == "" || t.workflow.statusOf(todo) == f.Status) &&
+			(!f.Ready || (!todo.Done && !blocked[todo.Id]))
+	}, nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, with the escape character set by ESCAPE '!'
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// where adds the conditions of the filter to a query of the todos table
+func (f TodoFilter) where(db *gorm.DB) (*gorm.DB, error) {
+	if f.Assignee != "" {
+		// Assignees are stored as a JSON array, the assignee is encoded the way it's stored
+		encoded, err := json.Marshal(f.Assignee)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("assignees LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(string(encoded))+"%")
+	}
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	if f.Ready {
+		db = db.Where("done = ?", false).
+			Where("NOT EXISTS (SELECT 1 FROM dependencies JOIN todos AS blockers ON blockers.id = dependencies.blockerid WHERE dependencies.todoid = todos.id AND blockers.done = ?)", false)
+	}
+	return db, nil
+}
+
+// validateFilter checks the filter only selects statuses of the workflow
+func (t *todoService) validateFilter(filter TodoFilter) error {
+	if filter.Status != "" && !t.workflow.known(filter.Status) {
+		return unknownStatus(t.workflow, filter.Status)
+	}
+	return nil
+}
+
+func (t *todoService) FilterTodos(ctx context.Context, filter TodoFilter, limit int, cursor string) ([]types.Todo, string, error) {
+	if filter.IsZero() {
+		return t.ListTodos(ctx, limit, cursor)
+	}
+	if err := t.validateFilter(filter); err != nil {
+		return nil, "", err
+	}
+
+	todos := []types.Todo{}
+	if db, ok := store.GormDB(t.storage.Adapter()); ok {
+		start, err := store.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query, err := filter.where(db.WithContext(ctx).Where("id >= ?", start))
+		if err != nil {
+			return nil, "", err
+		}
+		// One more todo than requested tells where the next page starts
+		err = query.Order("id").Limit(limit + 1).Find(&todos).Error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, "", ctxErr
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		todos, next := nextPage(todos, limit)
+		return todos, next, nil
+	}
+
+	// Other adapters can't filter, their todos are read a page at a time until enough of them match
+	matches, err := t.matcher(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		page := []types.Todo{}
+		next, err := t.storage.List(ctx, &page, "Id", map[string]any{}, indexPageSize, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, todo := range page {
+			if matches(todo) {
+				todos = append(todos, todo)
+			}
+		}
+		if len(todos) > limit || next == "" {
+			todos, next := nextPage(todos, limit)
+			return todos, next, nil
+		}
+		cursor = next
+	}
+}
+
+// nextPage returns the first limit todos and the cursor of the todo following them, which is empty
+// when there is none
+func nextPage(todos []types.Todo, limit int) ([]types.Todo, string) {
+	if len(todos) <= limit {
+		return todos, ""
+	}
+	return todos[:limit], base64.StdEncoding.EncodeToString([]byte(todos[limit].Id))
+}
+
+func (t *todoService) CountTodos(ctx context.Context, filter TodoFilter) (int, error) {
+	if filter.IsZero() {
+		return t.storage.Count(ctx, &[]types.Todo{}, map[string]any{})
+	}
+	if err := t.validateFilter(filter); err != nil {
+		return 0, err
+	}
+
+	if db, ok := store.GormDB(t.storage.Adapter()); ok {
+		query, err := filter.where(db.WithContext(ctx).Model(&types.Todo{}))
+		if err != nil {
+			return 0, err
+		}
+		count := int64(0)
+		err = query.Count(&count).Error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, ctxErr
+		}
+		return int(count), err
+	}
+
+	matches, err := t.matcher(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	cursor := ""
+	for {
+		page := []types.Todo{}
+		next, err := t.storage.List(ctx, &page, "Id", map[string]any{}, indexPageSize, cursor)
+		if err != nil {
+			return 0, err
+		}
+		for _, todo := range page {
+			if matches(todo) {
+				count++
+			}
+		}
+		if next == "" {
+			return count, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,122 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/types"
+)
+
+// ConflictMode determines what happens when an imported todo has the id of an existing todo
+type ConflictMode string
+
+const (
+	// ConflictSkip keeps the existing todo and ignores the imported one
+	ConflictSkip ConflictMode = "skip"
+	// ConflictOverwrite replaces the existing todo with the imported one
+	ConflictOverwrite ConflictMode = "overwrite"
+	// ConflictNewId stores the imported todo under a newly generated id
+	ConflictNewId ConflictMode = "new-id"
+)
+
+var ConflictModes = []ConflictMode{ConflictSkip, ConflictOverwrite, ConflictNewId}
+
+// ImportAction describes what happened (or would happen during a dry run) to an imported todo
+type ImportAction string
+
+const (
+	ImportCreated     ImportAction = "created"
+	ImportOverwritten ImportAction = "overwritten"
+	ImportSkipped     ImportAction = "skipped"
+)
+
+type ImportOptions struct {
+	Conflict ConflictMode
+	// DryRun reports the action that would be taken without changing anything
+	DryRun bool
+}
+
+func ParseConflictMode(value string) (ConflictMode, error) {
+	for _, mode := range ConflictModes {
+		if string(mode) == value {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported conflict mode %q, supported modes are: %v", value, ConflictModes)
+}
+
+// ImportTodo stores todoToImport preserving its id and timestamps. Todos without an id get a new
+// one and missing timestamps are set to the current time.
+func (t *todoService) ImportTodo(ctx context.Context, todoToImport types.Todo, options ImportOptions) (types.Todo, ImportAction, error) {
+	if todoToImport.CreatedAt.IsZero() {
+		todoToImport.CreatedAt = t.clock.Now()
+	}
+	if todoToImport.UpdatedAt.IsZero() {
+		todoToImport.UpdatedAt = todoToImport.CreatedAt
+	}
+	// The tracked time of exported todos is summed from their time entries, which aren't imported
+	todoToImport.TrackedSeconds = 0
+	// Imported todos keep their status when the workflow knows it, others get the status their done
+	// flag stands for
+	if !t.workflow.known(todoToImport.Status) {
+		todoToImport.Status = ""
+	}
+	todoToImport.Status = t.workflow.statusOf(todoToImport)
+	todoToImport.Done = t.workflow.isDone(todoToImport.Status)
+	if todoToImport.Done && todoToImport.CompletedAt == nil {
+		completedAt := todoToImport.UpdatedAt
+		todoToImport.CompletedAt = &completedAt
+	}
+
+	exists := false
+	existing := types.Todo{}
+	if todoToImport.Id != "" {
+		err := t.storage.Get(ctx, &existing, map[string]any{"id": todoToImport.Id})
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return todoToImport, "", err
+		}
+		exists = err == nil
+	}
+
+	if exists {
+		switch options.Conflict {
+		case ConflictOverwrite:
+			if !options.DryRun {
+				if err := t.storage.Update(ctx, todoToImport, map[string]any{"id": todoToImport.Id}); err != nil {
+					return todoToImport, "", err
+				}
+				t.record(ctx, types.RevisionUpdated, todoToImport.Id, &existing, &todoToImport)
+				t.changed(TodoEvent{Type: EventUpdated, Todo: todoToImport})
+				t.assigned(&existing, todoToImport)
+			}
+			return todoToImport, ImportOverwritten, nil
+		case ConflictNewId:
+			todoToImport.Id = ""
+		default:
+			return todoToImport, ImportSkipped, nil
+		}
+	}
+
+	if todoToImport.Id == "" {
+		id, err := t.ids.NewId()
+		if err != nil {
+			return todoToImport, "", err
+		}
+		todoToImport.Id = id
+	}
+
+	if !options.DryRun {
+		if err := t.storage.Create(ctx, todoToImport); err != nil {
+			return todoToImport, "", err
+		}
+		t.logger.Debug("imported todo", slog.String("id", todoToImport.Id))
+		t.record(ctx, types.RevisionCreated, todoToImport.Id, nil, &todoToImport)
+		t.changed(TodoEvent{Type: EventCreated, Todo: todoToImport})
+		t.assigned(nil, todoToImport)
+	}
+	return todoToImport, ImportCreated, nil
+}
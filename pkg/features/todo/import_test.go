@@ -0,0 +1,106 @@
+package todo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo-service/pkg/types"
+)
+
+func TestImportTodo(t *testing.T) {
+	created := time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+	existing := types.Todo{Id: "existing", Summary: "existing", CreatedAt: created, UpdatedAt: created}
+	imported := types.Todo{Id: "existing", Summary: "imported", Done: true, CreatedAt: created, UpdatedAt: created.Add(time.Hour)}
+
+	tests := []struct {
+		name       string
+		todo       types.Todo
+		options    ImportOptions
+		wantAction ImportAction
+		wantStored map[string]types.Todo
+	}{
+		{
+			name:       "new todo keeps its id and timestamps",
+			todo:       types.Todo{Id: "new", Summary: "new", CreatedAt: created, UpdatedAt: created},
+			options:    ImportOptions{Conflict: ConflictSkip},
+			wantAction: ImportCreated,
+			wantStored: map[string]types.Todo{"new": {Id: "new", Summary: "new", CreatedAt: created, UpdatedAt: created}},
+		},
+		{
+			name:       "todo without id or timestamps",
+			todo:       types.Todo{Summary: "new"},
+			options:    ImportOptions{Conflict: ConflictSkip},
+			wantAction: ImportCreated,
+			wantStored: map[string]types.Todo{"00000000-0000-7000-8000-000000000001": {Id: "00000000-0000-7000-8000-000000000001", Summary: "new", CreatedAt: now, UpdatedAt: now}},
+		},
+		{
+			name:       "conflict skip",
+			todo:       imported,
+			options:    ImportOptions{Conflict: ConflictSkip},
+			wantAction: ImportSkipped,
+			wantStored: map[string]types.Todo{"existing": existing},
+		},
+		{
+			name:       "conflict overwrite",
+			todo:       imported,
+			options:    ImportOptions{Conflict: ConflictOverwrite},
+			wantAction: ImportOverwritten,
+			wantStored: map[string]types.Todo{"existing": imported},
+		},
+		{
+			name:       "conflict new id",
+			todo:       imported,
+			options:    ImportOptions{Conflict: ConflictNewId},
+			wantAction: ImportCreated,
+			wantStored: map[string]types.Todo{
+				"existing":                             existing,
+				"00000000-0000-7000-8000-000000000001": {Id: "00000000-0000-7000-8000-000000000001", Summary: "imported", Done: true, CreatedAt: created, UpdatedAt: created.Add(time.Hour)},
+			},
+		},
+		{
+			name:       "dry run doesn't change anything",
+			todo:       imported,
+			options:    ImportOptions{Conflict: ConflictOverwrite, DryRun: true},
+			wantAction: ImportOverwritten,
+			wantStored: map[string]types.Todo{"existing": existing},
+		},
+	}
+
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					ctx := context.Background()
+					service, _ := newService(t, newAdapter(t))
+					if _, _, err := service.ImportTodo(ctx, existing, ImportOptions{}); err != nil {
+						t.Fatalf("ImportTodo() of the existing todo error = %v", err)
+					}
+
+					_, action, err := service.ImportTodo(ctx, tt.todo, tt.options)
+					if err != nil {
+						t.Fatalf("ImportTodo() error = %v", err)
+					}
+					if action != tt.wantAction {
+						t.Errorf("ImportTodo() action = %s, want %s", action, tt.wantAction)
+					}
+					for id, want := range tt.wantStored {
+						got, err := service.GetTodo(ctx, id)
+						if err != nil || !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) || got.Summary != want.Summary || got.Done != want.Done {
+							t.Errorf("GetTodo(%q) = %+v, %v, want %+v", id, got, err, want)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestParseConflictMode(t *testing.T) {
+	if mode, err := ParseConflictMode("new-id"); err != nil || mode != ConflictNewId {
+		t.Errorf("ParseConflictMode(new-id) = %q, %v", mode, err)
+	}
+	if _, err := ParseConflictMode("merge"); err == nil {
+		t.Error("ParseConflictMode(merge) succeeded, want an error")
+	}
+}
@@ -0,0 +1,151 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/types"
+)
+
+// revisionFilter selects the revisions of the todo id
+func revisionFilter(id string) map[string]any {
+	return map[string]any{"todoId": id}
+}
+
+// record stores a revision of the todo id made by the actor of ctx, before is nil when the todo
+// didn't exist before the change and after when it doesn't exist after it. The change is already
+// stored, so a revision that can't be stored is logged rather than failing it.
+func (t *todoService) record(ctx context.Context, action types.RevisionAction, id string, before *types.Todo, after *types.Todo) types.TodoRevision {
+	revision, err := t.newRevision(ctx, action, id, before, after)
+	if err == nil {
+		err = t.storage.Create(ctx, revision)
+	}
+	if err != nil {
+		t.logger.Error("failed to record todo revision", slog.String("id", id), slog.String("action", string(action)), slog.Any("error", err))
+		return types.TodoRevision{}
+	}
+	t.track(ctx, revision)
+	return revision
+}
+
+func (t *todoService) newRevision(ctx context.Context, action types.RevisionAction, id string, before *types.Todo, after *types.Todo) (types.TodoRevision, error) {
+	revisionId, err := t.revisionIds.NewId()
+	if err != nil {
+		return types.TodoRevision{}, err
+	}
+	revision := types.TodoRevision{
+		Id:        revisionId,
+		TodoId:    id,
+		Action:    action,
+		Actor:     actor.FromContext(ctx),
+		CreatedAt: t.clock.Now(),
+		Changes:   "null",
+	}
+
+	if before != nil {
+		data, err := json.Marshal(before)
+		if err != nil {
+			return revision, err
+		}
+		revision.Before = types.JSON(data)
+	}
+	if after != nil {
+		data, err := json.Marshal(after)
+		if err != nil {
+			return revision, err
+		}
+		revision.After = types.JSON(data)
+		revision.Changes = revision.After
+	}
+	if before != nil && after != nil {
+		changes, err := jsonpatch.CreateMergePatch([]byte(revision.Before), []byte(revision.After))
+		if err != nil {
+			return revision, err
+		}
+		revision.Changes = types.JSON(changes)
+	}
+	return revision, nil
+}
+
+func (t *todoService) TodoHistory(ctx context.Context, id string, limit int, cursor string) ([]types.TodoRevision, string, error) {
+	revisions := []types.TodoRevision{}
+	next, err := t.storage.List(ctx, &revisions, "Id", revisionFilter(id), limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(revisions) == 0 && cursor == "" {
+		// A todo without revisions may predate the history, it only has no history when it
+		// doesn't exist
+		if _, err := t.GetTodo(ctx, id); err != nil {
+			return nil, "", err
+		}
+	}
+	return revisions, next, nil
+}
+
+func (t *todoService) RevertTodo(ctx context.Context, id string, revisionId string) (types.Todo, error) {
+	revision := types.TodoRevision{}
+	err := t.storage.Get(ctx, &revision, map[string]any{"id": revisionId})
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && revision.TodoId != id) {
+		return types.Todo{}, &serviceErrors.NotFound{Message: fmt.Sprintf("todo %s has no revision %s", id, revisionId)}
+	}
+	if err != nil {
+		return types.Todo{}, err
+	}
+	if revision.After == "" {
+		return types.Todo{}, &serviceErrors.BadRequest{Message: fmt.Sprintf("revision %s deleted the todo, revert to an earlier revision to restore it", revisionId)}
+	}
+
+	restored := types.Todo{}
+	if err := json.Unmarshal([]byte(revision.After), &restored); err != nil {
+		return types.Todo{}, fmt.Errorf("failed to read revision %s: %v", revisionId, err)
+	}
+
+	var before *types.Todo
+	current, err := t.GetTodo(ctx, id)
+	switch {
+	case err == nil:
+		before = &current
+	case !errors.Is(err, storage.ErrNotFound):
+		return types.Todo{}, err
+	}
+
+	// The restored todo goes through the checks of UpdateTodo, the workflow or the users may have
+	// changed since the revision and the todo may be blocked now
+	restored.Assignees, err = t.validateAssignees(restored.Assignees, current.Assignees)
+	if err != nil {
+		return types.Todo{}, err
+	}
+	if err := t.workflow.transition(&restored, before); err != nil {
+		return types.Todo{}, err
+	}
+	if before != nil && restored.Done && !before.Done {
+		if err := t.checkBlockers(ctx, id); err != nil {
+			return types.Todo{}, err
+		}
+	}
+
+	// The todo gets the content it had, including when it was completed, and is updated now
+	restored.UpdatedAt = t.clock.Now()
+	setCompletion(&restored, restored.CompletedAt != nil, restored.UpdatedAt)
+	if err := t.storage.Update(ctx, restored, map[string]any{"id": id}); err != nil {
+		return types.Todo{}, err
+	}
+	t.logger.Debug("reverted todo", slog.String("id", id), slog.String("revision", revisionId))
+	t.record(ctx, types.RevisionReverted, id, before, &restored)
+	if before == nil {
+		t.changed(TodoEvent{Type: EventCreated, Todo: restored})
+	} else {
+		t.changed(TodoEvent{Type: EventUpdated, Todo: restored})
+	}
+	t.assigned(before, restored)
+	return restored, nil
+}
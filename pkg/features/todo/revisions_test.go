@@ -0,0 +1,178 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/types"
+)
+
+func TestTodoServiceHistory(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := actor.NewContext(context.Background(), "alice")
+			service, _ := newService(t, newAdapter(t))
+
+			created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Pick up the groceries"})
+			if err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+			updated := created
+			updated.Summary = "Pick up the groceries and the mail"
+			if err := service.UpdateTodo(context.Background(), updated); err != nil {
+				t.Fatalf("UpdateTodo() error = %v", err)
+			}
+			if err := service.DeleteTodo(ctx, created.Id); err != nil {
+				t.Fatalf("DeleteTodo() error = %v", err)
+			}
+
+			revisions, next, err := service.TodoHistory(ctx, created.Id, 10, "")
+			if err != nil {
+				t.Fatalf("TodoHistory() error = %v", err)
+			}
+			if len(revisions) != 3 || next != "" {
+				t.Fatalf("TodoHistory() = %+v, %q, want 3 revisions", revisions, next)
+			}
+			wantActions := []types.RevisionAction{types.RevisionCreated, types.RevisionUpdated, types.RevisionDeleted}
+			wantActors := []string{"alice", actor.Anonymous, "alice"}
+			for i, revision := range revisions {
+				if revision.TodoId != created.Id || revision.Action != wantActions[i] || revision.Actor != wantActors[i] || !revision.CreatedAt.Equal(now) {
+					t.Errorf("revision %d = %+v, want a %s revision by %s", i, revision, wantActions[i], wantActors[i])
+				}
+			}
+			changes := map[string]any{}
+			if err := json.Unmarshal([]byte(revisions[1].Changes), &changes); err != nil || len(changes) != 1 || changes["summary"] != updated.Summary {
+				t.Errorf("changes of the update = %s, %v, want only the new summary", revisions[1].Changes, err)
+			}
+			if revisions[0].Before != "" || revisions[2].After != "" {
+				t.Errorf("revisions = %+v, want no todo before the creation and after the deletion", revisions)
+			}
+
+			// Paging
+			first, next, err := service.TodoHistory(ctx, created.Id, 2, "")
+			if err != nil || len(first) != 2 || next == "" {
+				t.Fatalf("TodoHistory() first page = %d revisions, %q, %v, want 2 and a cursor", len(first), next, err)
+			}
+			rest, _, err := service.TodoHistory(ctx, created.Id, 2, next)
+			if err != nil || len(rest) != 1 || rest[0].Id != revisions[2].Id {
+				t.Errorf("TodoHistory() second page = %+v, %v, want the deletion", rest, err)
+			}
+
+			if _, _, err := service.TodoHistory(ctx, "missing", 10, ""); !isNotFound(err) {
+				t.Errorf("TodoHistory() of a missing todo error = %v, want not found", err)
+			}
+		})
+	}
+}
+
+func TestTodoServiceRevertTodo(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service, clock := newService(t, newAdapter(t))
+
+			created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Pick up the groceries", Priority: 2})
+			if err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+			changed := created
+			changed.Summary = "Pay the rent"
+			changed.Done = true
+			if err := service.UpdateTodo(ctx, changed); err != nil {
+				t.Fatalf("UpdateTodo() error = %v", err)
+			}
+			if err := service.DeleteTodo(ctx, created.Id); err != nil {
+				t.Fatalf("DeleteTodo() error = %v", err)
+			}
+			revisions, _, err := service.TodoHistory(ctx, created.Id, 10, "")
+			if err != nil || len(revisions) != 3 {
+				t.Fatalf("TodoHistory() = %+v, %v, want 3 revisions", revisions, err)
+			}
+
+			clock.Advance(time.Hour)
+			restored, err := service.RevertTodo(ctx, created.Id, revisions[0].Id)
+			if err != nil {
+				t.Fatalf("RevertTodo() error = %v", err)
+			}
+			if restored.Summary != created.Summary || restored.Done || restored.Priority != 2 || !restored.CreatedAt.Equal(created.CreatedAt) || !restored.UpdatedAt.Equal(now.Add(time.Hour)) {
+				t.Errorf("RevertTodo() = %+v, want the created todo updated now", restored)
+			}
+			if stored, err := service.GetTodo(ctx, created.Id); err != nil || !stored.Equal(restored) {
+				t.Errorf("GetTodo() after RevertTodo() = %+v, %v, want %+v", stored, err, restored)
+			}
+
+			history, _, err := service.TodoHistory(ctx, created.Id, 10, "")
+			if err != nil || len(history) != 4 || history[3].Action != types.RevisionReverted || history[3].Before != "" {
+				t.Errorf("TodoHistory() after RevertTodo() = %+v, %v, want a revert restoring the deleted todo", history, err)
+			}
+
+			var badRequest *serviceErrors.BadRequest
+			if _, err := service.RevertTodo(ctx, created.Id, revisions[2].Id); !errors.As(err, &badRequest) {
+				t.Errorf("RevertTodo() to a deletion error = %v, want a bad request", err)
+			}
+			if _, err := service.RevertTodo(ctx, "another", revisions[0].Id); !isNotFound(err) {
+				t.Errorf("RevertTodo() with the revision of another todo error = %v, want not found", err)
+			}
+			if _, err := service.RevertTodo(ctx, created.Id, "missing"); !isNotFound(err) {
+				t.Errorf("RevertTodo() to a missing revision error = %v, want not found", err)
+			}
+		})
+	}
+}
+
+func TestRevertTodoChecksChanges(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newService(t, fakes.NewStorage())
+	ids := createTodos(t, service, 2)
+	blocked, err := service.GetTodo(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("GetTodo() error = %v", err)
+	}
+
+	blocked.Done = true
+	if err := service.UpdateTodo(ctx, blocked); err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+	for _, status := range []string{"in_progress", "blocked"} {
+		blocked.Status = status
+		if err := service.UpdateTodo(ctx, blocked); err != nil {
+			t.Fatalf("UpdateTodo() error = %v", err)
+		}
+	}
+	revisions, _, err := service.TodoHistory(ctx, blocked.Id, 10, "")
+	if err != nil || len(revisions) != 4 {
+		t.Fatalf("TodoHistory() = %+v, %v, want 4 revisions", revisions, err)
+	}
+
+	// Blocked todos can't be done in the default workflow
+	if _, err := service.RevertTodo(ctx, blocked.Id, revisions[1].Id); !isBadRequest(err) {
+		t.Errorf("RevertTodo() of a blocked todo to done error = %v, want a BadRequest", err)
+	}
+
+	blocked.Status = "todo"
+	if err := service.UpdateTodo(ctx, blocked); err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+	if err := service.AddDependency(ctx, blocked.Id, ids[1]); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+	if _, err := service.RevertTodo(ctx, blocked.Id, revisions[1].Id); !isBadRequest(err) {
+		t.Errorf("RevertTodo() to done with an open blocker error = %v, want a BadRequest", err)
+	}
+	if got, err := service.GetTodo(ctx, blocked.Id); err != nil || got.Status != "todo" {
+		t.Errorf("GetTodo() after refused reverts = %+v, %v, want the todo unchanged", got, err)
+	}
+}
+
+func isNotFound(err error) bool {
+	var notFound *serviceErrors.NotFound
+	return errors.As(err, &notFound) || errors.Is(err, storage.ErrNotFound)
+}
@@ -0,0 +1,209 @@
+package todo
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/tink3rlabs/magic/storage"
+	"gorm.io/gorm"
+
+	"todo-service/pkg/search"
+	"todo-service/pkg/store"
+	"todo-service/pkg/types"
+)
+
+// indexPageSize is the number of todos read at a time when building the in-process index
+const indexPageSize = 100
+
+// searchHit is a todo matching a search, Highlight is its summary with the matched terms surrounded
+// by search.MatchStart and search.MatchEnd. It is left empty by searches that can't highlight.
+type searchHit struct {
+	Id        string
+	Score     float64
+	Highlight string
+}
+
+// nativeSearch finds the todos containing every one of terms with the full-text search of a SQL
+// provider
+type nativeSearch func(db *gorm.DB, terms []string, limit int) ([]searchHit, error)
+
+// searcher searches todos with the full-text search the migrations set up for the SQL provider.
+// The memory adapter and storage without full-text search (DynamoDB, or sqlite without FTS5) are
+// searched with an in-process index instead.
+type searcher struct {
+	once   sync.Once
+	native nativeSearch
+	index  todoIndex
+}
+
+func (t *todoService) SearchTodos(ctx context.Context, query string, limit int) ([]types.TodoSearchResult, error) {
+	results := []types.TodoSearchResult{}
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	t.search.once.Do(func() {
+		t.search.native = nativeSearchOf(t.storage.Adapter())
+	})
+
+	var hits []searchHit
+	var err error
+	if t.search.native != nil {
+		db, _ := store.GormDB(t.storage.Adapter())
+		hits, err = t.search.native(db.WithContext(ctx), terms, limit)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+	} else {
+		hits, err = t.search.index.search(ctx, t.storage, terms, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, hit := range hits {
+		ids = append(ids, hit.Id)
+	}
+	todos, err := t.GetTodos(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byId := map[string]types.Todo{}
+	for _, todo := range todos {
+		byId[todo.Id] = todo
+	}
+	for _, hit := range hits {
+		todo, ok := byId[hit.Id]
+		if !ok {
+			// Deleted since it was found
+			continue
+		}
+		highlight, ok := search.HighlightMatches(hit.Highlight, todo.Summary)
+		if hit.Highlight == "" || !ok {
+			highlight = search.Highlight(todo.Summary, terms)
+		}
+		results = append(results, types.TodoSearchResult{Todo: todo, Score: hit.Score, Highlight: highlight})
+	}
+	return results, nil
+}
+
+// nativeSearchOf returns the full-text search of the storage provider of adapter, nil when todos
+// have to be searched with the in-process index
+func nativeSearchOf(adapter storage.StorageAdapter) nativeSearch {
+	db, ok := store.GormDB(adapter)
+	if !ok || adapter.GetType() == storage.MEMORY {
+		return nil
+	}
+	switch store.Provider(adapter) {
+	case storage.POSTGRESQL:
+		return postgresSearch
+	case storage.MYSQL:
+		return mysqlSearch
+	case storage.SQLITE:
+		// The FTS5 table is only created when the driver was built with FTS5
+		if db.Migrator().HasTable("todos_fts") {
+			return sqliteSearch
+		}
+	}
+	return nil
+}
+
+func postgresSearch(db *gorm.DB, terms []string, limit int) ([]searchHit, error) {
+	hits := []searchHit{}
+	err := db.Raw(`SELECT id, ts_rank(search_vector, query) AS score,
+			ts_headline('english', coalesce(summary, ''), query, ?) AS highlight
+		FROM todos, plainto_tsquery('english', ?) AS query
+		WHERE search_vector @@ query
+		ORDER BY score DESC, id
+		LIMIT ?`, "StartSel="+search.MatchStart+", StopSel="+search.MatchEnd+", HighlightAll=true", strings.Join(terms, " "), limit).Scan(&hits).Error
+	return hits, err
+}
+
+func mysqlSearch(db *gorm.DB, terms []string, limit int) ([]searchHit, error) {
+	// Every term is required, like with the other providers
+	query := "+" + strings.Join(terms, " +")
+	hits := []searchHit{}
+	err := db.Raw(`SELECT id, MATCH (summary) AGAINST (? IN BOOLEAN MODE) AS score
+		FROM todos
+		WHERE MATCH (summary) AGAINST (? IN BOOLEAN MODE)
+		ORDER BY score DESC, id
+		LIMIT ?`, query, query, limit).Scan(&hits).Error
+	return hits, err
+}
+
+func sqliteSearch(db *gorm.DB, terms []string, limit int) ([]searchHit, error) {
+	// Quoted terms are matched as strings rather than parsed as FTS5 query syntax
+	query := `"` + strings.Join(terms, `" "`) + `"`
+	hits := []searchHit{}
+	err := db.Raw(`SELECT todos.id AS id, -bm25(todos_fts) AS score, highlight(todos_fts, 0, ?, ?) AS highlight
+		FROM todos_fts JOIN todos ON todos.rowid = todos_fts.rowid
+		WHERE todos_fts MATCH ?
+		ORDER BY score DESC, todos.id
+		LIMIT ?`, search.MatchStart, search.MatchEnd, query, limit).Scan(&hits).Error
+	return hits, err
+}
+
+// todoIndex is the in-process search index of the todo summaries. It's built from storage by the
+// first search and then kept up to date with the changes made through the service, like events
+// changes made by other instances of the service aren't seen.
+type todoIndex struct {
+	mu    sync.Mutex
+	index *search.Index
+}
+
+func (i *todoIndex) search(ctx context.Context, s *store.Store, terms []string, limit int) ([]searchHit, error) {
+	i.mu.Lock()
+	if i.index == nil {
+		index, err := buildIndex(ctx, s)
+		if err != nil {
+			i.mu.Unlock()
+			return nil, err
+		}
+		i.index = index
+	}
+	index := i.index
+	i.mu.Unlock()
+
+	hits := []searchHit{}
+	for _, match := range index.Search(strings.Join(terms, " "), limit) {
+		hits = append(hits, searchHit{Id: match.Id, Score: match.Score})
+	}
+	return hits, nil
+}
+
+// apply updates the index with a change, changes made before the index is built are read from
+// storage when building it
+func (i *todoIndex) apply(event TodoEvent) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.index == nil {
+		return
+	}
+	if event.Type == EventDeleted {
+		i.index.Remove(event.Todo.Id)
+		return
+	}
+	i.index.Add(event.Todo.Id, event.Todo.Summary)
+}
+
+func buildIndex(ctx context.Context, s *store.Store) (*search.Index, error) {
+	index := search.NewIndex()
+	cursor := ""
+	for {
+		todos := []types.Todo{}
+		next, err := s.List(ctx, &todos, "Id", map[string]any{}, indexPageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for _, todo := range todos {
+			index.Add(todo.Id, todo.Summary)
+		}
+		if next == "" {
+			return index, nil
+		}
+		cursor = next
+	}
+}
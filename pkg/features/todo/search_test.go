@@ -0,0 +1,77 @@
+package todo
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"todo-service/pkg/types"
+)
+
+func TestTodoServiceSearchTodos(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := newService(t, newAdapter(t))
+			ids := map[string]string{}
+			for _, summary := range []string{"Pick up the groceries", "Pay the rent", "Groceries: milk, eggs and more groceries"} {
+				todo, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: summary})
+				if err != nil {
+					t.Fatalf("CreateTodo() error = %v", err)
+				}
+				ids[summary] = todo.Id
+			}
+
+			results, err := service.SearchTodos(ctx, "GROCERIES", 10)
+			if err != nil {
+				t.Fatalf("SearchTodos() error = %v", err)
+			}
+			if len(results) != 2 || results[0].Todo.Id != ids["Groceries: milk, eggs and more groceries"] || results[0].Score < results[1].Score {
+				t.Fatalf("SearchTodos() = %+v, want the todo mentioning groceries twice first", results)
+			}
+			if want := "Pick up the <mark>groceries</mark>"; results[1].Highlight != want {
+				t.Errorf("highlight = %q, want %q", results[1].Highlight, want)
+			}
+
+			if results, err := service.SearchTodos(ctx, "pay groceries", 10); err != nil || len(results) != 0 {
+				t.Errorf("SearchTodos() of words in different todos = %+v, %v, want no results", results, err)
+			}
+			if results, err := service.SearchTodos(ctx, "  !? ", 10); err != nil || len(results) != 0 {
+				t.Errorf("SearchTodos() without words = %+v, %v, want no results", results, err)
+			}
+			if results, err := service.SearchTodos(ctx, "groceries", 1); err != nil || len(results) != 1 {
+				t.Errorf("SearchTodos() with a limit of 1 = %+v, %v, want 1 result", results, err)
+			}
+
+			// Changes made after the first search are searchable
+			rent := ids["Pay the rent"]
+			if err := service.UpdateTodo(ctx, types.Todo{Id: rent, Summary: "Pay the groceries bill"}); err != nil {
+				t.Fatalf("UpdateTodo() error = %v", err)
+			}
+			if err := service.DeleteTodo(ctx, ids["Pick up the groceries"]); err != nil {
+				t.Fatalf("DeleteTodo() error = %v", err)
+			}
+			results, err = service.SearchTodos(ctx, "groceries", 10)
+			if err != nil {
+				t.Fatalf("SearchTodos() error = %v", err)
+			}
+			found := []string{}
+			for _, result := range results {
+				found = append(found, result.Todo.Id)
+			}
+			slices.Sort(found)
+			if want := []string{rent, ids["Groceries: milk, eggs and more groceries"]}; !slices.Equal(found, want) {
+				t.Errorf("SearchTodos() after the changes found %v, want %v", found, want)
+			}
+
+			// Highlights are HTML, the summary around the marks is escaped
+			if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Buy <b>soap</b> & towels"}); err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+			results, err = service.SearchTodos(ctx, "soap", 10)
+			if want := "Buy &lt;b&gt;<mark>soap</mark>&lt;/b&gt; &amp; towels"; err != nil || len(results) != 1 || results[0].Highlight != want {
+				t.Errorf("SearchTodos() = %+v, %v, want the highlight %q", results, err, want)
+			}
+		})
+	}
+}
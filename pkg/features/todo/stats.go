@@ -0,0 +1,337 @@
+package todo
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+	"gorm.io/gorm"
+
+	"todo-service/pkg/store"
+	"todo-service/pkg/types"
+)
+
+// StatsInterval is the length of the periods TodoStats counts the created and completed todos of
+type StatsInterval string
+
+const (
+	StatsDay  StatsInterval = "day"
+	StatsWeek StatsInterval = "week"
+)
+
+const (
+	// DefaultStatsRange is how far back TodoStats reports when the range has no start
+	DefaultStatsRange = 30 * 24 * time.Hour
+	// MaxStatsPeriods is the largest number of periods TodoStats reports at once
+	MaxStatsPeriods = 366
+)
+
+// StatsOptions select the range TodoStats reports on. The range ends now unless To is set, starts
+// DefaultStatsRange before its end unless From is set and is counted by StatsDay by default.
+type StatsOptions struct {
+	From     time.Time
+	To       time.Time
+	Interval StatsInterval
+}
+
+// statsDay is the layout of the UTC days the created and completed todos are counted by
+const statsDay = time.DateOnly
+
+// statsCounts are the numbers TodoStats is built from, whether they were computed by the database
+// or by scanning the todos
+type statsCounts struct {
+	// created and completed count the todos of the range by day
+	created   map[string]int
+	completed map[string]int
+	// completionSeconds sums the time the todos completed in the range took
+	completionSeconds float64
+	total             int
+	done              int
+	overdue           int
+	byStatus          map[string]int
+	byAssignee        map[string]*types.AssigneeStats
+}
+
+func newStatsCounts() *statsCounts {
+	return &statsCounts{
+		created:    map[string]int{},
+		completed:  map[string]int{},
+		byStatus:   map[string]int{},
+		byAssignee: map[string]*types.AssigneeStats{},
+	}
+}
+
+// assignee counts a todo assigned to the users of assignees
+func (c *statsCounts) assignee(assignees types.Assignees, done bool, overdue bool) {
+	for _, user := range assignees {
+		stats, ok := c.byAssignee[user]
+		if !ok {
+			stats = &types.AssigneeStats{Assignee: user}
+			c.byAssignee[user] = stats
+		}
+		switch {
+		case done:
+			stats.Done++
+		case overdue:
+			stats.Open++
+			stats.Overdue++
+		default:
+			stats.Open++
+		}
+	}
+}
+
+func (t *todoService) TodoStats(ctx context.Context, options StatsOptions) (types.TodoStats, error) {
+	now := t.clock.Now()
+	if options.To.IsZero() {
+		options.To = now
+	}
+	if options.From.IsZero() {
+		options.From = options.To.Add(-DefaultStatsRange)
+	}
+	if options.Interval == "" {
+		options.Interval = StatsDay
+	}
+	periods, err := statsPeriods(options)
+	if err != nil {
+		return types.TodoStats{}, err
+	}
+
+	var counts *statsCounts
+	db, ok := store.GormDB(t.storage.Adapter())
+	dialect := statsDialects[store.Provider(t.storage.Adapter())]
+	if ok && dialect != nil {
+		counts, err = t.queryStats(db.WithContext(ctx), dialect, options, now)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return types.TodoStats{}, ctxErr
+		}
+	} else {
+		counts, err = t.scanStats(ctx, options, now)
+	}
+	if err != nil {
+		return types.TodoStats{}, err
+	}
+
+	stats := types.TodoStats{
+		From:       options.From,
+		To:         options.To,
+		Interval:   string(options.Interval),
+		Periods:    periods,
+		Total:      counts.total,
+		Open:       counts.total - counts.done,
+		Done:       counts.done,
+		Overdue:    counts.overdue,
+		ByStatus:   []types.StatusStats{},
+		ByAssignee: []types.AssigneeStats{},
+	}
+	for i := range stats.Periods {
+		start := stats.Periods[i].Start
+		end := nextPeriod(start, options.Interval)
+		for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+			stats.Periods[i].Created += counts.created[day.Format(statsDay)]
+			stats.Periods[i].Completed += counts.completed[day.Format(statsDay)]
+		}
+		stats.Created += stats.Periods[i].Created
+		stats.Completed += stats.Periods[i].Completed
+	}
+	if stats.Completed > 0 {
+		average := int64(math.Round(counts.completionSeconds / float64(stats.Completed)))
+		stats.AverageCompletionSeconds = &average
+	}
+
+	for status, count := range counts.byStatus {
+		stats.ByStatus = append(stats.ByStatus, types.StatusStats{Status: status, Count: count})
+	}
+	slices.SortFunc(stats.ByStatus, func(a types.StatusStats, b types.StatusStats) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Status, b.Status))
+	})
+	for _, assignee := range counts.byAssignee {
+		stats.ByAssignee = append(stats.ByAssignee, *assignee)
+	}
+	slices.SortFunc(stats.ByAssignee, func(a types.AssigneeStats, b types.AssigneeStats) int {
+		return cmp.Or(cmp.Compare(b.Open+b.Done, a.Open+a.Done), cmp.Compare(a.Assignee, b.Assignee))
+	})
+	return stats, nil
+}
+
+// statsPeriods returns the periods of the range of options, each without todos
+func statsPeriods(options StatsOptions) ([]types.StatsPeriod, error) {
+	if options.Interval != StatsDay && options.Interval != StatsWeek {
+		return nil, &serviceErrors.BadRequest{Message: fmt.Sprintf("unsupported interval %q, intervals must be one of %s, %s", options.Interval, StatsDay, StatsWeek)}
+	}
+	if !options.From.Before(options.To) {
+		return nil, &serviceErrors.BadRequest{Message: "from must be before to"}
+	}
+
+	periods := []types.StatsPeriod{}
+	for start := periodStart(options.From, options.Interval); start.Before(options.To); start = nextPeriod(start, options.Interval) {
+		if len(periods) == MaxStatsPeriods {
+			return nil, &serviceErrors.BadRequest{Message: fmt.Sprintf("the range can't hold more than %d periods, use a shorter range or a longer interval", MaxStatsPeriods)}
+		}
+		periods = append(periods, types.StatsPeriod{Start: start})
+	}
+	return periods, nil
+}
+
+// periodStart returns the start of the period holding at, midnight UTC for days and Monday for weeks
+func periodStart(at time.Time, interval StatsInterval) time.Time {
+	day := at.UTC().Truncate(24 * time.Hour)
+	if interval == StatsWeek {
+		// Weekday counts from Sunday, weeks start on Monday
+		day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day
+}
+
+func nextPeriod(start time.Time, interval StatsInterval) time.Time {
+	if interval == StatsWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// scanStats counts the todos a page at a time, for storage that can't aggregate
+func (t *todoService) scanStats(ctx context.Context, options StatsOptions, now time.Time) (*statsCounts, error) {
+	counts := newStatsCounts()
+	inRange := func(at time.Time) bool {
+		return !at.Before(options.From) && at.Before(options.To)
+	}
+	cursor := ""
+	for {
+		page := []types.Todo{}
+		next, err := t.storage.List(ctx, &page, "Id", map[string]any{}, indexPageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for _, todo := range page {
+			if inRange(todo.CreatedAt) {
+				counts.created[todo.CreatedAt.UTC().Format(statsDay)]++
+			}
+			if todo.CompletedAt != nil && inRange(*todo.CompletedAt) {
+				counts.completed[todo.CompletedAt.UTC().Format(statsDay)]++
+				counts.completionSeconds += todo.CompletedAt.Sub(todo.CreatedAt).Seconds()
+			}
+
+			overdue := !todo.Done && todo.Due != nil && todo.Due.Before(now)
+			counts.total++
+			if todo.Done {
+				counts.done++
+			}
+			if overdue {
+				counts.overdue++
+			}
+			counts.byStatus[t.workflow.statusOf(todo)]++
+			counts.assignee(todo.Assignees, todo.Done, overdue)
+		}
+		if next == "" {
+			return counts, nil
+		}
+		cursor = next
+	}
+}
+
+// statsDialect holds the SQL expressions that differ between providers
+type statsDialect struct {
+	// day formats a timestamp column as its UTC day (statsDay)
+	day func(column string) string
+	// completionSeconds is the time from the creation to the completion of a todo in seconds
+	completionSeconds string
+}
+
+var statsDialects = map[storage.StorageProviders]*statsDialect{
+	storage.POSTGRESQL: {
+		day: func(column string) string {
+			return fmt.Sprintf("to_char(%s AT TIME ZONE 'UTC', 'YYYY-MM-DD')", column)
+		},
+		completionSeconds: "EXTRACT(EPOCH FROM completed_at - created_at)",
+	},
+	storage.MYSQL: {
+		// Times are stored in UTC
+		day: func(column string) string {
+			return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d')", column)
+		},
+		completionSeconds: "TIMESTAMPDIFF(SECOND, created_at, completed_at)",
+	},
+	storage.SQLITE: {
+		day: func(column string) string {
+			return fmt.Sprintf("date(%s)", column)
+		},
+		completionSeconds: "(julianday(completed_at) - julianday(created_at)) * 86400",
+	},
+}
+
+// queryStats counts the todos with aggregate queries
+func (t *todoService) queryStats(db *gorm.DB, dialect *statsDialect, options StatsOptions, now time.Time) (*statsCounts, error) {
+	counts := newStatsCounts()
+	from, to := options.From.UTC(), options.To.UTC()
+
+	for column, byDay := range map[string]map[string]int{"created_at": counts.created, "completed_at": counts.completed} {
+		days := []struct {
+			Day   string
+			Count int
+		}{}
+		err := db.Model(&types.Todo{}).
+			Select(dialect.day(column)+" AS day, COUNT(*) AS count").
+			Where(column+" >= ? AND "+column+" < ?", from, to).
+			Group("day").
+			Scan(&days).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range days {
+			byDay[day.Day] = day.Count
+		}
+	}
+
+	completion := sql.NullFloat64{}
+	err := db.Model(&types.Todo{}).
+		Select("SUM("+dialect.completionSeconds+")").
+		Where("completed_at >= ? AND completed_at < ?", from, to).
+		Row().Scan(&completion)
+	if err != nil {
+		return nil, err
+	}
+	counts.completionSeconds = completion.Float64
+
+	statuses := []struct {
+		Status string
+		Done   bool
+		Count  int
+	}{}
+	err = db.Model(&types.Todo{}).Select("status, done, COUNT(*) AS count").Group("status, done").Scan(&statuses).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		counts.total += status.Count
+		if status.Done {
+			counts.done += status.Count
+		}
+		counts.byStatus[status.Status] += status.Count
+	}
+
+	overdue := int64(0)
+	err = db.Model(&types.Todo{}).Where("done = ? AND due < ?", false, now.UTC()).Count(&overdue).Error
+	if err != nil {
+		return nil, err
+	}
+	counts.overdue = int(overdue)
+
+	// Assignees are stored as JSON arrays, which can't be grouped by the same way on every
+	// provider, so only the columns they are counted by are read
+	assigned := []types.Todo{}
+	err = db.Select("assignees, done, due").Where("assignees IS NOT NULL").Find(&assigned).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range assigned {
+		counts.assignee(todo.Assignees, todo.Done, !todo.Done && todo.Due != nil && todo.Due.Before(now))
+	}
+	return counts, nil
+}
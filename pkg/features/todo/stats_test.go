@@ -0,0 +1,100 @@
+package todo
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"todo-service/pkg/types"
+)
+
+func TestTodoStats(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service, clock := newService(t, newAdapter(t))
+
+			// now is a Monday, the first todo is done after 26 hours and the second is overdue
+			first, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "first", Assignees: types.Assignees{"alice"}})
+			if err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+			clock.Advance(26 * time.Hour)
+			first.Done = true
+			if err := service.UpdateTodo(ctx, first); err != nil {
+				t.Fatalf("UpdateTodo() error = %v", err)
+			}
+			due := now.Add(36 * time.Hour)
+			if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "second", Due: &due, Assignees: types.Assignees{"bob"}}); err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+			clock.Advance(7 * 24 * time.Hour)
+			if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "third", Status: "in_progress", Assignees: types.Assignees{"alice", "bob"}}); err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+
+			from, to := now.Add(-12*time.Hour), now.Add(9*24*time.Hour)
+			stats, err := service.TodoStats(ctx, StatsOptions{From: from, To: to})
+			if err != nil {
+				t.Fatalf("TodoStats() error = %v", err)
+			}
+			if len(stats.Periods) != 10 || stats.Periods[0].Created != 1 || stats.Periods[1].Completed != 1 || stats.Periods[8].Created != 1 {
+				t.Errorf("TodoStats() periods = %+v, want 10 days with the todos created on the first, second and ninth", stats.Periods)
+			}
+			if stats.Created != 3 || stats.Completed != 1 || stats.AverageCompletionSeconds == nil || *stats.AverageCompletionSeconds != 26*60*60 {
+				t.Errorf("TodoStats() = %+v, want 3 created and 1 completed in 26 hours", stats)
+			}
+			if stats.Total != 3 || stats.Open != 2 || stats.Done != 1 || stats.Overdue != 1 {
+				t.Errorf("TodoStats() = %+v, want 3 todos with 2 open, 1 done and 1 overdue", stats)
+			}
+			wantByStatus := []types.StatusStats{{Status: "done", Count: 1}, {Status: "in_progress", Count: 1}, {Status: "todo", Count: 1}}
+			if !slices.Equal(stats.ByStatus, wantByStatus) {
+				t.Errorf("TodoStats() by status = %+v, want %+v", stats.ByStatus, wantByStatus)
+			}
+			wantByAssignee := []types.AssigneeStats{{Assignee: "alice", Open: 1, Done: 1}, {Assignee: "bob", Open: 2, Overdue: 1}}
+			if !slices.Equal(stats.ByAssignee, wantByAssignee) {
+				t.Errorf("TodoStats() by assignee = %+v, want %+v", stats.ByAssignee, wantByAssignee)
+			}
+
+			stats, err = service.TodoStats(ctx, StatsOptions{From: from, To: to, Interval: StatsWeek})
+			wantPeriods := []types.StatsPeriod{
+				{Start: now.Truncate(24 * time.Hour), Created: 2, Completed: 1},
+				{Start: now.Truncate(24*time.Hour).AddDate(0, 0, 7), Created: 1},
+			}
+			if err != nil || !slices.EqualFunc(stats.Periods, wantPeriods, func(a types.StatsPeriod, b types.StatsPeriod) bool {
+				return a.Start.Equal(b.Start) && a.Created == b.Created && a.Completed == b.Completed
+			}) {
+				t.Errorf("TodoStats() by week = %+v, %v, want %+v", stats.Periods, err, wantPeriods)
+			}
+		})
+	}
+}
+
+func TestTodoStatsRange(t *testing.T) {
+	service, _ := newService(t, adapters["fake"](t))
+
+	stats, err := service.TodoStats(context.Background(), StatsOptions{})
+	if err != nil || !stats.To.Equal(now) || !stats.From.Equal(now.Add(-DefaultStatsRange)) || len(stats.Periods) != 31 {
+		t.Errorf("TodoStats() without a range = %+v, %v, want the 31 days before now", stats, err)
+	}
+	if stats.AverageCompletionSeconds != nil {
+		t.Errorf("TodoStats() without completed todos average = %d, want none", *stats.AverageCompletionSeconds)
+	}
+
+	tests := []struct {
+		name    string
+		options StatsOptions
+	}{
+		{name: "empty range", options: StatsOptions{From: now, To: now}},
+		{name: "unknown interval", options: StatsOptions{Interval: "month"}},
+		{name: "too many periods", options: StatsOptions{From: now.AddDate(-2, 0, 0), To: now}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.TodoStats(context.Background(), tt.options); !isBadRequest(err) {
+				t.Errorf("TodoStats() error = %v, want a BadRequest", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package todo
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/store"
+	"todo-service/pkg/types"
+)
+
+const (
+	// MaxTimeEntrySeconds is the most time a single time entry can log, a day
+	MaxTimeEntrySeconds = 24 * 60 * 60
+	// MaxTimeEntryNoteLength is the largest number of characters the note of a time entry can hold
+	MaxTimeEntryNoteLength = 1000
+)
+
+var (
+	// ErrTimerRunning is returned when starting a timer on a todo the user already runs a timer on
+	ErrTimerRunning = errors.New("a timer is already running")
+	// ErrNoTimer is returned when stopping a timer on a todo the user runs no timer on
+	ErrNoTimer = errors.New("no timer is running")
+)
+
+// timeEntryFilter selects the time entries of the todo id
+func timeEntryFilter(id string) map[string]any {
+	return map[string]any{"todoId": id}
+}
+
+func validateTimeEntry(entry types.TimeEntryCreate) error {
+	switch {
+	case entry.Seconds < 1 || entry.Seconds > MaxTimeEntrySeconds:
+		return &serviceErrors.BadRequest{Message: fmt.Sprintf("seconds must be between 1 and %d", MaxTimeEntrySeconds)}
+	case utf8.RuneCountInString(entry.Note) > MaxTimeEntryNoteLength:
+		return &serviceErrors.BadRequest{Message: fmt.Sprintf("note can't be longer than %d characters", MaxTimeEntryNoteLength)}
+	}
+	return nil
+}
+
+func (t *todoService) ListTimeEntries(ctx context.Context, id string, limit int, cursor string) ([]types.TimeEntry, string, error) {
+	if _, err := t.GetTodo(ctx, id); err != nil {
+		return nil, "", err
+	}
+	entries := []types.TimeEntry{}
+	next, err := t.storage.List(ctx, &entries, "Id", timeEntryFilter(id), limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, next, nil
+}
+
+func (t *todoService) AddTimeEntry(ctx context.Context, id string, entryToAdd types.TimeEntryCreate) (types.TimeEntry, error) {
+	if err := validateTimeEntry(entryToAdd); err != nil {
+		return types.TimeEntry{}, err
+	}
+	if _, err := t.GetTodo(ctx, id); err != nil {
+		return types.TimeEntry{}, err
+	}
+
+	now := t.clock.Now()
+	duration := time.Duration(entryToAdd.Seconds) * time.Second
+	startedAt := now.Add(-duration)
+	if entryToAdd.StartedAt != nil {
+		startedAt = *entryToAdd.StartedAt
+	}
+	endedAt := startedAt.Add(duration)
+	return t.createTimeEntry(ctx, types.TimeEntry{
+		TodoId:    id,
+		Author:    actor.FromContext(ctx),
+		StartedAt: startedAt,
+		EndedAt:   &endedAt,
+		Seconds:   entryToAdd.Seconds,
+		Note:      entryToAdd.Note,
+		CreatedAt: now,
+	})
+}
+
+func (t *todoService) DeleteTimeEntry(ctx context.Context, id string, entryId string) error {
+	_, err := t.getTimeEntry(ctx, id, entryId)
+	var notFound *serviceErrors.NotFound
+	if errors.As(err, &notFound) {
+		// Deleting a missing time entry succeeds without changing anything, like deleting a todo
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = t.storage.Delete(ctx, &types.TimeEntry{}, map[string]any{"id": entryId})
+	if err == nil {
+		t.logger.Debug("deleted time entry", slog.String("id", entryId), slog.String("todo", id))
+	}
+	return err
+}
+
+func (t *todoService) StartTimer(ctx context.Context, id string) (types.TimeEntry, error) {
+	if _, err := t.GetTodo(ctx, id); err != nil {
+		return types.TimeEntry{}, err
+	}
+	author := actor.FromContext(ctx)
+	running, err := t.runningTimer(ctx, id, author)
+	if err != nil {
+		return types.TimeEntry{}, err
+	}
+	if running != nil {
+		return types.TimeEntry{}, fmt.Errorf("%w on todo %s since %s", ErrTimerRunning, id, running.StartedAt.Format(time.RFC3339))
+	}
+
+	now := t.clock.Now()
+	return t.createTimeEntry(ctx, types.TimeEntry{TodoId: id, Author: author, StartedAt: now, CreatedAt: now})
+}
+
+func (t *todoService) StopTimer(ctx context.Context, id string) (types.TimeEntry, error) {
+	if _, err := t.GetTodo(ctx, id); err != nil {
+		return types.TimeEntry{}, err
+	}
+	running, err := t.runningTimer(ctx, id, actor.FromContext(ctx))
+	if err != nil {
+		return types.TimeEntry{}, err
+	}
+	if running == nil {
+		return types.TimeEntry{}, fmt.Errorf("%w on todo %s", ErrNoTimer, id)
+	}
+
+	entry := *running
+	endedAt := t.clock.Now().UTC()
+	// Timers log at most MaxTimeEntrySeconds like the entries logged by hand, a timer left running
+	// for longer ends that long after it started
+	if limit := entry.StartedAt.Add(MaxTimeEntrySeconds * time.Second); endedAt.After(limit) {
+		endedAt = limit.UTC()
+	}
+	entry.EndedAt = &endedAt
+	// Timers stopped within a second still log a second, entries always log some time
+	entry.Seconds = max(int64(endedAt.Sub(entry.StartedAt)/time.Second), 1)
+	err = t.storage.Update(ctx, entry, map[string]any{"id": entry.Id})
+	if err == nil {
+		t.logger.Debug("stopped timer", slog.String("id", entry.Id), slog.String("todo", id))
+	}
+	return entry, err
+}
+
+// createTimeEntry stores entry under a new id
+func (t *todoService) createTimeEntry(ctx context.Context, entry types.TimeEntry) (types.TimeEntry, error) {
+	entryId, err := t.ids.NewId()
+	if err != nil {
+		return types.TimeEntry{}, err
+	}
+	entry.Id = entryId
+	// Times are stored in UTC so the report compares them the same way on every database
+	entry.StartedAt = entry.StartedAt.UTC()
+	if entry.EndedAt != nil {
+		endedAt := entry.EndedAt.UTC()
+		entry.EndedAt = &endedAt
+	}
+	err = t.storage.Create(ctx, entry)
+	if err == nil {
+		t.logger.Debug("logged time", slog.String("id", entry.Id), slog.String("todo", entry.TodoId))
+	}
+	return entry, err
+}
+
+// runningTimer returns the timer author runs on the todo id, or nil when there is none
+func (t *todoService) runningTimer(ctx context.Context, id string, author string) (*types.TimeEntry, error) {
+	entries, err := t.listTimeEntries(ctx, map[string]any{"todoId": id, "author": author})
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Running() {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// getTimeEntry returns the time entry entryId of the todo id, entries of other todos aren't found
+func (t *todoService) getTimeEntry(ctx context.Context, id string, entryId string) (types.TimeEntry, error) {
+	entry := types.TimeEntry{}
+	err := t.storage.Get(ctx, &entry, map[string]any{"id": entryId})
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && entry.TodoId != id) {
+		return types.TimeEntry{}, &serviceErrors.NotFound{Message: fmt.Sprintf("todo %s has no time entry %s", id, entryId)}
+	}
+	return entry, err
+}
+
+// listTimeEntries returns every time entry selected by filter
+func (t *todoService) listTimeEntries(ctx context.Context, filter map[string]any) ([]types.TimeEntry, error) {
+	entries := []types.TimeEntry{}
+	cursor := ""
+	for {
+		page := []types.TimeEntry{}
+		next, err := t.storage.List(ctx, &page, "Id", filter, indexPageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page...)
+		if next == "" {
+			return entries, nil
+		}
+		cursor = next
+	}
+}
+
+func (t *todoService) TrackedTime(ctx context.Context, ids []string) (map[string]int64, error) {
+	tracked := map[string]int64{}
+	if len(ids) == 0 {
+		return tracked, nil
+	}
+
+	if db, ok := store.GormDB(t.storage.Adapter()); ok {
+		// Running timers log 0 seconds, so they add nothing to the sums
+		rows := []struct {
+			TodoId  string `gorm:"column:todoid"`
+			Seconds int64
+		}{}
+		err := db.WithContext(ctx).Model(&types.TimeEntry{}).
+			Select("todoid, SUM(seconds) AS seconds").
+			Where("todoid IN ?", ids).
+			Group("todoid").
+			Scan(&rows).Error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			tracked[row.TodoId] = row.Seconds
+		}
+		return tracked, nil
+	}
+
+	for _, id := range ids {
+		entries, err := t.listTimeEntries(ctx, timeEntryFilter(id))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			tracked[id] += entry.Seconds
+		}
+	}
+	return tracked, nil
+}
+
+func (t *todoService) TimeReport(ctx context.Context, from time.Time, to time.Time) (types.TimeReport, error) {
+	if !from.Before(to) {
+		return types.TimeReport{}, &serviceErrors.BadRequest{Message: "from must be before to"}
+	}
+
+	entries := []types.TimeEntry{}
+	if db, ok := store.GormDB(t.storage.Adapter()); ok {
+		err := db.WithContext(ctx).
+			Where("started_at >= ? AND started_at < ? AND ended_at IS NOT NULL", from.UTC(), to.UTC()).
+			Find(&entries).Error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return types.TimeReport{}, ctxErr
+		}
+		if err != nil {
+			return types.TimeReport{}, err
+		}
+	} else {
+		// Other adapters can't select a range, every time entry is read
+		all, err := t.listTimeEntries(ctx, map[string]any{})
+		if err != nil {
+			return types.TimeReport{}, err
+		}
+		for _, entry := range all {
+			if !entry.Running() && !entry.StartedAt.Before(from) && entry.StartedAt.Before(to) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	report := types.TimeReport{From: from, To: to}
+	byUser := map[string]int64{}
+	byTodo := map[string]int64{}
+	for _, entry := range entries {
+		report.TotalSeconds += entry.Seconds
+		byUser[entry.Author] += entry.Seconds
+		byTodo[entry.TodoId] += entry.Seconds
+	}
+	report.ByUser = timeTotals(byUser)
+	report.ByTodo = timeTotals(byTodo)
+	return report, nil
+}
+
+// timeTotals returns the totals the most time first, equal totals ordered by key
+func timeTotals(seconds map[string]int64) []types.TimeTotal {
+	totals := []types.TimeTotal{}
+	for key, value := range seconds {
+		totals = append(totals, types.TimeTotal{Key: key, Seconds: value})
+	}
+	slices.SortFunc(totals, func(a types.TimeTotal, b types.TimeTotal) int {
+		return cmp.Or(cmp.Compare(b.Seconds, a.Seconds), cmp.Compare(a.Key, b.Key))
+	})
+	return totals
+}
+
+// deleteTimeEntries deletes the time entries of the deleted todo id
+func (t *todoService) deleteTimeEntries(ctx context.Context, id string) {
+	if err := t.storage.Delete(ctx, &types.TimeEntry{}, timeEntryFilter(id)); err != nil {
+		t.logger.Error("failed to delete the time entries of todo", slog.String("id", id), slog.Any("error", err))
+	}
+}
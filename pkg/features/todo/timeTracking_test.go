@@ -0,0 +1,137 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/types"
+)
+
+func TestTimeTracking(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			alice := actor.NewContext(context.Background(), "alice")
+			bob := actor.NewContext(context.Background(), "bob")
+			service, clock := newService(t, newAdapter(t))
+			ids := createTodos(t, service, 2)
+			first, second := ids[0], ids[1]
+
+			if _, err := service.StartTimer(alice, first); err != nil {
+				t.Fatalf("StartTimer() error = %v", err)
+			}
+			if _, err := service.StartTimer(alice, first); !errors.Is(err, ErrTimerRunning) {
+				t.Errorf("StartTimer() while running error = %v, want %v", err, ErrTimerRunning)
+			}
+			if _, err := service.StopTimer(bob, first); !errors.Is(err, ErrNoTimer) {
+				t.Errorf("StopTimer() of another user error = %v, want %v", err, ErrNoTimer)
+			}
+			clock.Advance(30 * time.Minute)
+			stopped, err := service.StopTimer(alice, first)
+			if err != nil || stopped.Seconds != 1800 || stopped.Author != "alice" || stopped.Running() {
+				t.Errorf("StopTimer() = %+v, %v, want 1800 seconds logged by alice", stopped, err)
+			}
+
+			if _, err := service.AddTimeEntry(bob, first, types.TimeEntryCreate{Seconds: 600, Note: "review"}); err != nil {
+				t.Fatalf("AddTimeEntry() error = %v", err)
+			}
+			// Entries start their seconds before being logged unless they say when they started
+			startedAt := now.Add(time.Hour)
+			if _, err := service.AddTimeEntry(bob, second, types.TimeEntryCreate{Seconds: 3600, StartedAt: &startedAt}); err != nil {
+				t.Fatalf("AddTimeEntry() error = %v", err)
+			}
+			if _, err := service.AddTimeEntry(bob, first, types.TimeEntryCreate{Seconds: 0}); !isBadRequest(err) {
+				t.Errorf("AddTimeEntry() without time error = %v, want a BadRequest", err)
+			}
+			// Running timers add nothing until they are stopped
+			if _, err := service.StartTimer(bob, second); err != nil {
+				t.Fatalf("StartTimer() error = %v", err)
+			}
+
+			tracked, err := service.TrackedTime(context.Background(), []string{first, second, "missing"})
+			want := map[string]int64{first: 2400, second: 3600}
+			if err != nil || len(tracked) != len(want) || tracked[first] != want[first] || tracked[second] != want[second] {
+				t.Errorf("TrackedTime() = %v, %v, want %v", tracked, err, want)
+			}
+
+			report, err := service.TimeReport(context.Background(), now, now.Add(24*time.Hour))
+			if err != nil {
+				t.Fatalf("TimeReport() error = %v", err)
+			}
+			wantByUser := []types.TimeTotal{{Key: "bob", Seconds: 4200}, {Key: "alice", Seconds: 1800}}
+			wantByTodo := []types.TimeTotal{{Key: second, Seconds: 3600}, {Key: first, Seconds: 2400}}
+			if report.TotalSeconds != 6000 || !slices.Equal(report.ByUser, wantByUser) || !slices.Equal(report.ByTodo, wantByTodo) {
+				t.Errorf("TimeReport() = %+v, want 6000 seconds by %v and %v", report, wantByUser, wantByTodo)
+			}
+			report, err = service.TimeReport(context.Background(), now.Add(-24*time.Hour), now)
+			if err != nil || report.TotalSeconds != 0 || len(report.ByUser) != 0 {
+				t.Errorf("TimeReport() of the day before = %+v, %v, want no time", report, err)
+			}
+			if _, err := service.TimeReport(context.Background(), now, now); !isBadRequest(err) {
+				t.Errorf("TimeReport() of an empty range error = %v, want a BadRequest", err)
+			}
+
+			if err := service.DeleteTodo(context.Background(), second); err != nil {
+				t.Fatalf("DeleteTodo() error = %v", err)
+			}
+			tracked, err = service.TrackedTime(context.Background(), []string{second})
+			if err != nil || len(tracked) != 0 {
+				t.Errorf("TrackedTime() of a deleted todo = %v, %v, want no time", tracked, err)
+			}
+		})
+	}
+}
+
+func TestStopTimerLogsAtMostADay(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := actor.NewContext(context.Background(), "alice")
+			service, clock := newService(t, newAdapter(t))
+			id := createTodos(t, service, 1)[0]
+
+			started, err := service.StartTimer(ctx, id)
+			if err != nil {
+				t.Fatalf("StartTimer() error = %v", err)
+			}
+			// Left running for a week
+			clock.Advance(7 * 24 * time.Hour)
+			stopped, err := service.StopTimer(ctx, id)
+			if err != nil || stopped.Seconds != MaxTimeEntrySeconds || stopped.EndedAt == nil || !stopped.EndedAt.Equal(started.StartedAt.Add(24*time.Hour)) {
+				t.Errorf("StopTimer() = %+v, %v, want %d seconds ending a day after it started", stopped, err, MaxTimeEntrySeconds)
+			}
+			if tracked, err := service.TrackedTime(context.Background(), []string{id}); err != nil || tracked[id] != MaxTimeEntrySeconds {
+				t.Errorf("TrackedTime() = %v, %v, want %d seconds", tracked, err, MaxTimeEntrySeconds)
+			}
+		})
+	}
+}
+
+func TestDeleteTimeEntry(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newService(t, adapters["fake"](t))
+	ids := createTodos(t, service, 2)
+
+	entry, err := service.AddTimeEntry(ctx, ids[0], types.TimeEntryCreate{Seconds: 60})
+	if err != nil {
+		t.Fatalf("AddTimeEntry() error = %v", err)
+	}
+	// Entries are only deleted through the todo they were logged on
+	if err := service.DeleteTimeEntry(ctx, ids[1], entry.Id); err != nil {
+		t.Fatalf("DeleteTimeEntry() of another todo error = %v", err)
+	}
+	entries, _, err := service.ListTimeEntries(ctx, ids[0], 10, "")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ListTimeEntries() = %+v, %v, want the entry", entries, err)
+	}
+
+	if err := service.DeleteTimeEntry(ctx, ids[0], entry.Id); err != nil {
+		t.Fatalf("DeleteTimeEntry() error = %v", err)
+	}
+	entries, _, err = service.ListTimeEntries(ctx, ids[0], 10, "")
+	if err != nil || len(entries) != 0 {
+		t.Errorf("ListTimeEntries() after deleting = %+v, %v, want no entries", entries, err)
+	}
+}
@@ -1,82 +1,374 @@
 package todo
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"time"
 
-	"github.com/google/uuid"
-	"github.com/spf13/viper"
-
+	"todo-service/pkg/blob"
+	"todo-service/pkg/clock"
+	"todo-service/pkg/ids"
+	"todo-service/pkg/store"
 	"todo-service/pkg/types"
 
-	"github.com/tink3rlabs/magic/logger"
 	"github.com/tink3rlabs/magic/storage"
 )
 
-type TodoService struct {
-	storage storage.StorageAdapter
+// TodoService manages Todo items, it is the dependency of every transport (e.g. the REST router)
+type TodoService interface {
+	ListTodos(ctx context.Context, limit int, cursor string) ([]types.Todo, string, error)
+	// PreviousCursor returns the cursor of the page of limit todos listed before the page starting
+	// at cursor, ok is false when cursor is the first page or on storage that can only list forward.
+	// The cursor of the first page is empty.
+	PreviousCursor(ctx context.Context, limit int, cursor string) (prev string, ok bool, err error)
+	// FilterTodos lists the todos selected by filter the way ListTodos does. It reads pages of
+	// todos until enough of them match on storage that can't filter.
+	FilterTodos(ctx context.Context, filter TodoFilter, limit int, cursor string) ([]types.Todo, string, error)
+	// CountTodos returns the number of todos selected by filter, which reads every todo on storage
+	// that can't count
+	CountTodos(ctx context.Context, filter TodoFilter) (int, error)
+	// TodoBoard returns a column for every status of the workflow holding the first limit todos
+	// selected by filter that have the status
+	TodoBoard(ctx context.Context, filter TodoFilter, limit int) (types.TodoBoard, error)
+	// BackfillStatuses gives the todos stored before they had a status the status of the workflow
+	// their done flag stands for, and fails when todos have statuses the workflow doesn't list
+	BackfillStatuses(ctx context.Context) error
+	// TodoStats counts the todos created and completed in the range of options by period along with
+	// the number of open, done and overdue todos by status and by assignee
+	TodoStats(ctx context.Context, options StatsOptions) (types.TodoStats, error)
+	GetTodo(ctx context.Context, id string) (types.Todo, error)
+	// GetTodos returns the todos with the given ids, ids that don't exist are left out
+	GetTodos(ctx context.Context, ids []string) ([]types.Todo, error)
+	DeleteTodo(ctx context.Context, id string) error
+	UpdateTodo(ctx context.Context, todoToUpdate types.Todo) error
+	CreateTodo(ctx context.Context, todoToCreate types.TodoUpdate) (types.Todo, error)
+	ImportTodo(ctx context.Context, todoToImport types.Todo, options ImportOptions) (types.Todo, ImportAction, error)
+	// TodoHistory returns a page of the revisions of the todo id, the oldest first. Revisions are
+	// kept after the todo is deleted.
+	TodoHistory(ctx context.Context, id string, limit int, cursor string) ([]types.TodoRevision, string, error)
+	// RevertTodo restores the todo id to the way it was after the revision revisionId, recording
+	// the revert as a new revision. Deleted todos are restored as well, without the comments,
+	// attachments, dependencies and time entries deleted with them. The restored todo is checked
+	// the way UpdateTodo checks changes, so reverts the workflow or dependencies forbid are refused.
+	RevertTodo(ctx context.Context, id string, revisionId string) (types.Todo, error)
+	// Undo reverts the changes an undo token was issued for (see TrackChanges) when they were made
+	// within the undo window and the todos weren't changed since, returning ErrUndoExpired and
+	// ErrChangedSince otherwise
+	Undo(ctx context.Context, token string) error
+	// ListComments returns up to limit comments of the todo id starting at cursor, the oldest
+	// first, and the cursor of the next page
+	ListComments(ctx context.Context, id string, limit int, cursor string) ([]types.Comment, string, error)
+	// CreateComment adds a comment written by the actor of ctx to the todo id
+	CreateComment(ctx context.Context, id string, comment types.CommentUpdate) (types.Comment, error)
+	// UpdateComment replaces the body of the comment commentId of the todo id
+	UpdateComment(ctx context.Context, id string, commentId string, comment types.CommentUpdate) (types.Comment, error)
+	// DeleteComment deletes the comment commentId of the todo id, the comments of a todo are
+	// deleted with it
+	DeleteComment(ctx context.Context, id string, commentId string) error
+	// ListAttachments returns up to limit attachments of the todo id starting at cursor, the oldest
+	// first, and the cursor of the next page
+	ListAttachments(ctx context.Context, id string, limit int, cursor string) ([]types.Attachment, string, error)
+	// AddAttachment attaches the file name to the todo id, returning ErrAttachmentTooLarge and
+	// ErrAttachmentType for files the AttachmentLimits don't allow
+	AddAttachment(ctx context.Context, id string, name string, contentType string, content io.Reader) (types.Attachment, error)
+	// GetAttachment returns the attachment attachmentId of the todo id and its content, which the
+	// caller must close
+	GetAttachment(ctx context.Context, id string, attachmentId string) (types.Attachment, io.ReadCloser, error)
+	// DeleteAttachment deletes the attachment attachmentId of the todo id and its content, the
+	// attachments of a todo are deleted with it
+	DeleteAttachment(ctx context.Context, id string, attachmentId string) error
+	// TodoDependencies returns the todos the todo id is blocked by and the todos it blocks
+	TodoDependencies(ctx context.Context, id string) (types.TodoDependencies, error)
+	// AddDependency blocks the todo id by the todo blockerId, refusing dependencies that would
+	// create a cycle. Todos can't be marked as done while todos they are blocked by are open unless
+	// AllowDoneWhileBlocked is set.
+	AddDependency(ctx context.Context, id string, blockerId string) error
+	// RemoveDependency unblocks the todo id from the todo blockerId, the dependencies of a todo are
+	// deleted with it
+	RemoveDependency(ctx context.Context, id string, blockerId string) error
+	// ListTimeEntries returns up to limit time entries of the todo id starting at cursor, the
+	// oldest first, and the cursor of the next page
+	ListTimeEntries(ctx context.Context, id string, limit int, cursor string) ([]types.TimeEntry, string, error)
+	// AddTimeEntry logs time spent by the actor of ctx on the todo id
+	AddTimeEntry(ctx context.Context, id string, entry types.TimeEntryCreate) (types.TimeEntry, error)
+	// DeleteTimeEntry deletes the time entry entryId of the todo id, the time entries of a todo are
+	// deleted with it
+	DeleteTimeEntry(ctx context.Context, id string, entryId string) error
+	// StartTimer starts a timer of the actor of ctx on the todo id, returning ErrTimerRunning when
+	// the actor already runs one on it
+	StartTimer(ctx context.Context, id string) (types.TimeEntry, error)
+	// StopTimer stops the timer the actor of ctx runs on the todo id, logging the time since it was
+	// started, and returns ErrNoTimer when there is none
+	StopTimer(ctx context.Context, id string) (types.TimeEntry, error)
+	// TrackedTime returns the seconds logged on the todos with the given ids, leaving out running
+	// timers. Todos without logged time are left out.
+	TrackedTime(ctx context.Context, ids []string) (map[string]int64, error)
+	// TimeReport sums the time logged by user and by todo for the time entries started from from
+	// until to, leaving out running timers
+	TimeReport(ctx context.Context, from time.Time, to time.Time) (types.TimeReport, error)
+	// SearchTodos returns up to limit todos whose summary contains every word of query, the most
+	// relevant first
+	SearchTodos(ctx context.Context, query string, limit int) ([]types.TodoSearchResult, error)
+	// WatchTodos returns a channel receiving the changes made to todos until ctx is done, the
+	// channel is closed when ctx is done or when the watcher falls too far behind
+	WatchTodos(ctx context.Context) <-chan TodoEvent
 }
 
-func NewTodoService() *TodoService {
-	storageAdapter, err := storage.StorageAdapterFactory{}.GetInstance(
-		storage.StorageAdapterType(viper.GetString("storage.type")),
-		viper.GetStringMapString("storage.config"),
-	)
+// TodoServiceProps holds the dependencies of the TodoService. Storage is required, the rest default
+// to the system clock, UUIDv7 identifiers, the default logger, DefaultUndoWindow and
+// DefaultAttachmentLimits. Attachments are disabled without Blobs.
+type TodoServiceProps struct {
+	Storage     storage.StorageAdapter
+	Clock       clock.Clock
+	IdGenerator ids.Generator
+	Logger      *slog.Logger
+	// UndoWindow is how long changes can be undone
+	UndoWindow time.Duration
+	// UndoKey signs undo tokens. A random key is generated when empty, tokens then only work with
+	// the service that issued them.
+	UndoKey []byte
+	// Blobs stores the content of attachments
+	Blobs            blob.Store
+	AttachmentLimits AttachmentLimits
+	// Users are the users todos can be assigned to, any user can be assigned when empty
+	Users []string
+	// Workflow defines the statuses of todos, DefaultWorkflow when it has no statuses
+	Workflow Workflow
+	// AllowDoneWhileBlocked lets todos be marked as done before the todos they are blocked by
+	AllowDoneWhileBlocked bool
+}
 
-	if err != nil {
-		logger.Fatal("failed to create TodoService instance", slog.Any("error", err.Error()))
+type todoService struct {
+	storage *store.Store
+	clock   clock.Clock
+	ids     ids.Generator
+	// revisionIds are always UUIDv7 so revisions list in the order they were made
+	revisionIds ids.Generator
+	logger      *slog.Logger
+	undoWindow  time.Duration
+	undoKey     []byte
+	blobs       blob.Store
+	// attachmentLimits restrict the files attached to todos
+	attachmentLimits AttachmentLimits
+	users            []string
+	workflow         Workflow
+	// allowDoneWhileBlocked disables the check that todos marked as done aren't blocked
+	allowDoneWhileBlocked bool
+	events                events
+	search                searcher
+}
+
+func NewTodoService(props TodoServiceProps) (TodoService, error) {
+	if props.Storage == nil {
+		return nil, errors.New("a storage adapter is required to create a TodoService")
+	}
+
+	t := todoService{
+		storage:          store.New(props.Storage),
+		clock:            props.Clock,
+		ids:              props.IdGenerator,
+		revisionIds:      ids.UUIDv7{},
+		logger:           props.Logger,
+		undoWindow:       props.UndoWindow,
+		undoKey:          props.UndoKey,
+		blobs:            props.Blobs,
+		attachmentLimits: props.AttachmentLimits,
+		users:            props.Users,
+		workflow:         props.Workflow,
+	}
+	if t.clock == nil {
+		t.clock = clock.System{}
+	}
+	if t.ids == nil {
+		t.ids = ids.UUIDv7{}
+	}
+	if t.logger == nil {
+		t.logger = slog.Default()
+	}
+	if t.undoWindow <= 0 {
+		t.undoWindow = DefaultUndoWindow
+	}
+	if len(t.undoKey) == 0 {
+		t.undoKey = make([]byte, 32)
+		if _, err := rand.Read(t.undoKey); err != nil {
+			return nil, fmt.Errorf("failed to generate an undo key: %v", err)
+		}
+	}
+	if t.attachmentLimits.MaxSize <= 0 {
+		t.attachmentLimits.MaxSize = DefaultAttachmentLimits.MaxSize
 	}
-	t := TodoService{storage: storageAdapter}
-	return &t
+	if len(t.attachmentLimits.ContentTypes) == 0 {
+		t.attachmentLimits.ContentTypes = DefaultAttachmentLimits.ContentTypes
+	}
+	t.allowDoneWhileBlocked = props.AllowDoneWhileBlocked
+	if len(t.workflow.Statuses) == 0 {
+		t.workflow = DefaultWorkflow
+	}
+	if err := t.workflow.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid workflow: %v", err)
+	}
+	return &t, nil
 }
 
-func (t *TodoService) ListTodos(limit int, cursor string) ([]types.Todo, string, error) {
+func (t *todoService) ListTodos(ctx context.Context, limit int, cursor string) ([]types.Todo, string, error) {
 	todos := []types.Todo{}
-	next, err := t.storage.List(&todos, "Id", map[string]any{}, limit, cursor)
+	next, err := t.storage.List(ctx, &todos, "Id", map[string]any{}, limit, cursor)
 
 	return todos, next, err
 }
 
-func (t *TodoService) GetTodo(id string) (types.Todo, error) {
+func (t *todoService) PreviousCursor(ctx context.Context, limit int, cursor string) (string, bool, error) {
+	return t.storage.Previous(ctx, &[]types.Todo{}, "Id", limit, cursor)
+}
+
+func (t *todoService) GetTodo(ctx context.Context, id string) (types.Todo, error) {
 	todo := types.Todo{}
-	err := t.storage.Get(&todo, map[string]any{"id": id})
+	err := t.storage.Get(ctx, &todo, map[string]any{"id": id})
 	return todo, err
 }
 
-func (t *TodoService) DeleteTodo(id string) error {
-	return t.storage.Delete(&types.Todo{}, map[string]any{"id": id})
+func (t *todoService) GetTodos(ctx context.Context, ids []string) ([]types.Todo, error) {
+	todos := []types.Todo{}
+	if len(ids) == 0 {
+		return todos, nil
+	}
+	err := t.storage.GetMany(ctx, &todos, "id", ids)
+	return todos, err
+}
+
+func (t *todoService) DeleteTodo(ctx context.Context, id string) error {
+	current, err := t.GetTodo(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Deleting a missing todo succeeds without changing anything
+		return t.storage.Delete(ctx, &types.Todo{}, map[string]any{"id": id})
+	}
+	if err != nil {
+		return err
+	}
+
+	err = t.storage.Delete(ctx, &types.Todo{}, map[string]any{"id": id})
+	if err == nil {
+		t.logger.Debug("deleted todo", slog.String("id", id))
+		t.record(ctx, types.RevisionDeleted, id, &current, nil)
+		t.purge(ctx, id)
+		t.changed(TodoEvent{Type: EventDeleted, Todo: types.Todo{Id: id}})
+	}
+	return err
 }
 
-func (t *TodoService) UpdateTodo(todoToUpdate types.Todo) error {
-	return t.storage.Update(todoToUpdate, map[string]any{"id": todoToUpdate.Id})
+func (t *todoService) UpdateTodo(ctx context.Context, todoToUpdate types.Todo) error {
+	current := types.Todo{}
+	err := t.storage.Get(ctx, &current, map[string]any{"id": todoToUpdate.Id})
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	found := err == nil
+
+	todoToUpdate.Assignees, err = t.validateAssignees(todoToUpdate.Assignees, current.Assignees)
+	if err != nil {
+		return err
+	}
+	var before *types.Todo
+	if found {
+		before = &current
+	}
+	if err := t.workflow.transition(&todoToUpdate, before); err != nil {
+		return err
+	}
+	if found && todoToUpdate.Done && !current.Done {
+		if err := t.checkBlockers(ctx, todoToUpdate.Id); err != nil {
+			return err
+		}
+	}
+	// The tracked time is summed from the time entries rather than stored with the todo
+	todoToUpdate.TrackedSeconds = 0
+	todoToUpdate.UpdatedAt = t.clock.Now()
+	todoToUpdate.CompletedAt = current.CompletedAt
+	setCompletion(&todoToUpdate, current.Done, todoToUpdate.UpdatedAt)
+
+	err = t.storage.Update(ctx, todoToUpdate, map[string]any{"id": todoToUpdate.Id})
+	if err == nil {
+		t.logger.Debug("updated todo", slog.String("id", todoToUpdate.Id))
+		if found {
+			t.record(ctx, types.RevisionUpdated, todoToUpdate.Id, &current, &todoToUpdate)
+		} else {
+			t.record(ctx, types.RevisionCreated, todoToUpdate.Id, nil, &todoToUpdate)
+		}
+		t.changed(TodoEvent{Type: EventUpdated, Todo: todoToUpdate})
+		t.assigned(before, todoToUpdate)
+	}
+	return err
 }
 
-func (t *TodoService) CreateTodo(todoToCreate types.TodoUpdate) (types.Todo, error) {
+func (t *todoService) CreateTodo(ctx context.Context, todoToCreate types.TodoUpdate) (types.Todo, error) {
 	todo := types.Todo{}
 
-	// Using UUIDv7 in order to easily support cursor based pagination without extra fields
-	//
-	// From the RFC (https://datatracker.ietf.org/doc/rfc9562/)
-	//
-	// UUIDv7 features a time-ordered value field derived from the widely
-	// implemented and well-known Unix Epoch timestamp source, the number of
-	// milliseconds since midnight 1 Jan 1970 UTC, leap seconds excluded.
-	// Generally, UUIDv7 has improved entropy characteristics over UUIDv1
-	// (Section 5.1) or UUIDv6 (Section 5.6).
-	//
-	// UUIDv7 values are created by allocating a Unix timestamp in
-	// milliseconds in the most significant 48 bits and filling the
-	// remaining 74 bits, excluding the required version and variant bits,
-	// with random bits for each new UUIDv7 generated to provide uniqueness
-	// as per Section 6.9.
-	id, err := uuid.NewV7()
+	assignees, err := t.validateAssignees(todoToCreate.Assignees, nil)
 	if err != nil {
 		return todo, err
 	}
-
-	todo.Id = id.String()
 	todo.Summary = todoToCreate.Summary
 	todo.Done = todoToCreate.Done
+	todo.Status = todoToCreate.Status
+	todo.Due = todoToCreate.Due
+	todo.Priority = todoToCreate.Priority
+	todo.Assignees = assignees
+	todo.EstimateSeconds = todoToCreate.EstimateSeconds
+	if err := t.workflow.transition(&todo, nil); err != nil {
+		return types.Todo{}, err
+	}
+
+	id, err := t.ids.NewId()
+	if err != nil {
+		return types.Todo{}, err
+	}
+	todo.Id = id
+	todo.CreatedAt = t.clock.Now()
+	todo.UpdatedAt = todo.CreatedAt
+	setCompletion(&todo, false, todo.CreatedAt)
 
-	err = t.storage.Create(todo)
+	err = t.storage.Create(ctx, todo)
+	if err == nil {
+		t.logger.Debug("created todo", slog.String("id", todo.Id))
+		t.record(ctx, types.RevisionCreated, todo.Id, nil, &todo)
+		t.changed(TodoEvent{Type: EventCreated, Todo: todo})
+		t.assigned(nil, todo)
+	}
 	return todo, err
 }
+
+func (t *todoService) WatchTodos(ctx context.Context) <-chan TodoEvent {
+	return t.events.watch(ctx)
+}
+
+// changed publishes a change to the watchers and applies it to the search index
+func (t *todoService) changed(event TodoEvent) {
+	t.search.index.apply(event)
+	t.events.publish(event)
+}
+
+// purge deletes the comments, attachments, dependencies and time entries of the deleted todo id.
+// The todo is already deleted, so what can't be deleted is logged rather than failing it.
+func (t *todoService) purge(ctx context.Context, id string) {
+	if err := t.storage.Delete(ctx, &types.Comment{}, commentFilter(id)); err != nil {
+		t.logger.Error("failed to delete the comments of todo", slog.String("id", id), slog.Any("error", err))
+	}
+	t.deleteAttachments(ctx, id)
+	t.deleteDependencies(ctx, id)
+	t.deleteTimeEntries(ctx, id)
+}
+
+// setCompletion records when a todo was marked as done and clears the completion time of todos that
+// are no longer done
+func setCompletion(todo *types.Todo, wasDone bool, now time.Time) {
+	switch {
+	case !todo.Done:
+		todo.CompletedAt = nil
+	case !wasDone || todo.CompletedAt == nil:
+		todo.CompletedAt = &now
+	}
+}
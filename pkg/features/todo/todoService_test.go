@@ -0,0 +1,216 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/store/storetest"
+	"todo-service/pkg/types"
+)
+
+var now = time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+var adapters = map[string]storetest.AdapterFactory{
+	"fake":   func(t *testing.T) storage.StorageAdapter { return fakes.NewStorage() },
+	"memory": storetest.Memory,
+	"sqlite": storetest.SQLite,
+}
+
+func newService(t *testing.T, adapter storage.StorageAdapter) (TodoService, *fakes.Clock) {
+	t.Helper()
+	clock := fakes.NewClock(now)
+	service, err := NewTodoService(TodoServiceProps{
+		Storage:     adapter,
+		Clock:       clock,
+		IdGenerator: &fakes.IdGenerator{},
+	})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+	return service, clock
+}
+
+func TestNewTodoServiceRequiresStorage(t *testing.T) {
+	if _, err := NewTodoService(TodoServiceProps{}); err == nil {
+		t.Error("NewTodoService() without storage succeeded, want an error")
+	}
+}
+
+func TestTodoService(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service, clock := newService(t, newAdapter(t))
+
+			created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Pick up the groceries"})
+			if err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+			want := types.Todo{Id: "00000000-0000-7000-8000-000000000001", Summary: "Pick up the groceries", Status: "todo", CreatedAt: now, UpdatedAt: now}
+			if !created.Equal(want) {
+				t.Errorf("CreateTodo() = %+v, want %+v", created, want)
+			}
+
+			got, err := service.GetTodo(ctx, created.Id)
+			if err != nil || !got.Equal(want) {
+				t.Errorf("GetTodo() = %+v, %v, want %+v", got, err, want)
+			}
+
+			clock.Advance(time.Hour)
+			want.Done = true
+			if err := service.UpdateTodo(ctx, want); err != nil {
+				t.Fatalf("UpdateTodo() error = %v", err)
+			}
+			// Setting done alone moves the todo to the done status
+			want.Status = "done"
+			want.UpdatedAt = now.Add(time.Hour)
+			want.CompletedAt = &want.UpdatedAt
+			got, err = service.GetTodo(ctx, created.Id)
+			if err != nil || !got.Equal(want) {
+				t.Errorf("GetTodo() after UpdateTodo() = %+v, %v, want %+v", got, err, want)
+			}
+
+			if err := service.DeleteTodo(ctx, created.Id); err != nil {
+				t.Fatalf("DeleteTodo() error = %v", err)
+			}
+			if _, err := service.GetTodo(ctx, created.Id); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("GetTodo() after DeleteTodo() error = %v, want %v", err, storage.ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestTodoServiceListTodos(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := newService(t, newAdapter(t))
+			for i := 0; i < 3; i++ {
+				if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo"}); err != nil {
+					t.Fatalf("CreateTodo() error = %v", err)
+				}
+			}
+
+			todos, next, err := service.ListTodos(ctx, 2, "")
+			if err != nil || len(todos) != 2 || next == "" {
+				t.Fatalf("ListTodos() = %d todos, %q, %v, want 2 todos and a cursor", len(todos), next, err)
+			}
+
+			todos, next, err = service.ListTodos(ctx, 2, next)
+			if err != nil || len(todos) != 1 || next != "" {
+				t.Fatalf("ListTodos() of the last page = %d todos, %q, %v, want 1 todo and no cursor", len(todos), next, err)
+			}
+			if todos[0].Id != "00000000-0000-7000-8000-000000000003" {
+				t.Errorf("ListTodos() last page = %+v, want the third todo", todos)
+			}
+		})
+	}
+}
+
+func TestTodoServicePaging(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := newService(t, newAdapter(t))
+			for i := 0; i < 5; i++ {
+				if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo"}); err != nil {
+					t.Fatalf("CreateTodo() error = %v", err)
+				}
+			}
+
+			if count, err := service.CountTodos(ctx, TodoFilter{}); err != nil || count != 5 {
+				t.Errorf("CountTodos() = %d, %v, want 5", count, err)
+			}
+
+			// cursors holds the cursor of every page of 2 todos
+			cursors := []string{""}
+			for {
+				_, next, err := service.ListTodos(ctx, 2, cursors[len(cursors)-1])
+				if err != nil {
+					t.Fatalf("ListTodos() error = %v", err)
+				}
+				if next == "" {
+					break
+				}
+				cursors = append(cursors, next)
+			}
+			if len(cursors) != 3 {
+				t.Fatalf("got %d pages, want 3", len(cursors))
+			}
+
+			if name == "fake" {
+				// The fake storage can only list forward like DynamoDB
+				if _, ok, err := service.PreviousCursor(ctx, 2, cursors[2]); err != nil || ok {
+					t.Errorf("PreviousCursor() on storage that lists forward = %v, %v, want no previous page", ok, err)
+				}
+				return
+			}
+			for page := len(cursors) - 1; page > 0; page-- {
+				prev, ok, err := service.PreviousCursor(ctx, 2, cursors[page])
+				if err != nil || !ok || prev != cursors[page-1] {
+					t.Errorf("PreviousCursor() of page %d = %q, %v, %v, want %q", page, prev, ok, err, cursors[page-1])
+				}
+			}
+			if _, ok, err := service.PreviousCursor(ctx, 2, ""); err != nil || ok {
+				t.Errorf("PreviousCursor() of the first page = %v, %v, want no previous page", ok, err)
+			}
+
+			// Pages of another size start wherever the todos before the cursor allow
+			prev, ok, err := service.PreviousCursor(ctx, 3, cursors[2])
+			if err != nil || !ok {
+				t.Fatalf("PreviousCursor() with a larger page = %v, %v, want a previous page", ok, err)
+			}
+			todos, _, err := service.ListTodos(ctx, 3, prev)
+			if err != nil || len(todos) != 3 || todos[0].Id != "00000000-0000-7000-8000-000000000002" {
+				t.Errorf("ListTodos() of the previous page = %+v, %v, want the todos 2 to 4", todos, err)
+			}
+		})
+	}
+}
+
+func TestTodoServiceCompletion(t *testing.T) {
+	ctx := context.Background()
+	service, clock := newService(t, fakes.NewStorage())
+
+	created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo", Done: true})
+	if err != nil || created.CompletedAt == nil || !created.CompletedAt.Equal(now) {
+		t.Fatalf("CreateTodo() = %+v, %v, want it completed at %v", created, err, now)
+	}
+
+	// Updating a completed todo keeps the time it was completed
+	clock.Advance(time.Hour)
+	created.Summary = "renamed"
+	if err := service.UpdateTodo(ctx, created); err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+	got, err := service.GetTodo(ctx, created.Id)
+	if err != nil || got.CompletedAt == nil || !got.CompletedAt.Equal(now) {
+		t.Errorf("GetTodo() = %+v, %v, want it completed at %v", got, err, now)
+	}
+
+	got.Done = false
+	if err := service.UpdateTodo(ctx, got); err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+	if got, err = service.GetTodo(ctx, created.Id); err != nil || got.CompletedAt != nil {
+		t.Errorf("GetTodo() after reopening = %+v, %v, want no completion time", got, err)
+	}
+}
+
+func TestTodoServiceHonorsContext(t *testing.T) {
+	service, _ := newService(t, fakes.NewStorage())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("CreateTodo() error = %v, want %v", err, context.Canceled)
+	}
+	if _, _, err := service.ListTodos(ctx, 10, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListTodos() error = %v, want %v", err, context.Canceled)
+	}
+}
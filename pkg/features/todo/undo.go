@@ -0,0 +1,251 @@
+package todo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/types"
+)
+
+// DefaultUndoWindow is how long changes can be undone unless configured otherwise
+const DefaultUndoWindow = 10 * time.Minute
+
+var (
+	// ErrChangedSince is returned when undoing a change to a todo that was changed again since
+	ErrChangedSince = errors.New("the todo was changed since")
+	// ErrUndoExpired is returned when undoing a change made longer than the undo window ago
+	ErrUndoExpired = errors.New("the undo window has passed")
+)
+
+type changesKey struct{}
+
+// Changes collects the revisions recorded with a context returned by TrackChanges
+type Changes struct {
+	mu        sync.Mutex
+	revisions []string
+	// expires is when the first change leaves the undo window
+	expires time.Time
+	// key signs the undo token
+	key []byte
+}
+
+// TrackChanges returns a copy of ctx collecting the revisions the TodoService records with it, so
+// the changes made while handling a request can be undone together
+func TrackChanges(ctx context.Context) (context.Context, *Changes) {
+	changes := &Changes{}
+	return context.WithValue(ctx, changesKey{}, changes), changes
+}
+
+// UndoToken returns the token undoing the tracked changes, empty when nothing changed. The token
+// holds the revisions and the time it expires, signed so clients can't undo changes of others.
+func (c *Changes) UndoToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.revisions) == 0 {
+		return ""
+	}
+	payload := strconv.FormatInt(c.expires.Unix(), 10) + "," + strings.Join(c.revisions, ",")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(signUndo(c.key, payload))
+}
+
+func (c *Changes) add(revision types.TodoRevision, expires time.Time, key []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.revisions) == 0 {
+		c.expires = expires
+		c.key = key
+	}
+	c.revisions = append(c.revisions, revision.Id)
+}
+
+// track adds a recorded revision to the changes tracked by ctx
+func (t *todoService) track(ctx context.Context, revision types.TodoRevision) {
+	if changes, ok := ctx.Value(changesKey{}).(*Changes); ok && revision.Id != "" {
+		changes.add(revision, revision.CreatedAt.Add(t.undoWindow), t.undoKey)
+	}
+}
+
+func signUndo(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// undoRevisions returns the revisions of an undo token signed with the key of the service
+func (t *todoService) undoRevisions(token string) ([]string, error) {
+	invalid := &serviceErrors.BadRequest{Message: "invalid undo token"}
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, signUndo(t.undoKey, string(payload))) {
+		return nil, invalid
+	}
+
+	fields := strings.Split(string(payload), ",")
+	expires, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || len(fields) < 2 {
+		return nil, invalid
+	}
+	if t.clock.Now().After(time.Unix(expires, 0)) {
+		return nil, fmt.Errorf("%w, changes can only be undone for %s", ErrUndoExpired, t.undoWindow)
+	}
+	return fields[1:], nil
+}
+
+func (t *todoService) Undo(ctx context.Context, token string) error {
+	revisions, err := t.undoRevisions(token)
+	if err != nil {
+		return err
+	}
+
+	// Every todo is checked before any of them is restored, so the changes are undone together or
+	// not at all. Todos changed more than once are restored to the way they were before the first
+	// change when they are the way the last change left them.
+	changes := map[string]*types.TodoRevision{}
+	todos := []string{}
+	for _, id := range revisions {
+		revision := types.TodoRevision{}
+		err := t.storage.Get(ctx, &revision, map[string]any{"id": id})
+		if errors.Is(err, storage.ErrNotFound) {
+			return &serviceErrors.NotFound{Message: "the changes of the undo token don't exist"}
+		}
+		if err != nil {
+			return err
+		}
+		if t.clock.Now().Sub(revision.CreatedAt) > t.undoWindow {
+			return fmt.Errorf("%w, changes can only be undone for %s", ErrUndoExpired, t.undoWindow)
+		}
+		if change, ok := changes[revision.TodoId]; ok {
+			change.After = revision.After
+			continue
+		}
+		changes[revision.TodoId] = &revision
+		todos = append(todos, revision.TodoId)
+	}
+
+	for _, id := range todos {
+		if err := t.unchangedSince(ctx, *changes[id]); err != nil {
+			return err
+		}
+	}
+	for _, id := range todos {
+		if err := t.undo(ctx, *changes[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unchangedSince returns ErrChangedSince when the todo of change isn't the way change left it
+func (t *todoService) unchangedSince(ctx context.Context, change types.TodoRevision) error {
+	current, err := t.GetTodo(ctx, change.TodoId)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	exists := err == nil
+
+	switch {
+	case change.After == "" && !exists:
+		return nil
+	case change.After == "" || !exists:
+		return fmt.Errorf("%w, it was created or deleted again", ErrChangedSince)
+	}
+	after := types.Todo{}
+	if err := json.Unmarshal([]byte(change.After), &after); err != nil {
+		return fmt.Errorf("failed to read revision %s: %v", change.Id, err)
+	}
+	if !sameTodo(current, after) {
+		return fmt.Errorf("%w, it was last updated at %s", ErrChangedSince, current.UpdatedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// undo restores the todo of change to the way it was before it, which is recorded as a revert
+func (t *todoService) undo(ctx context.Context, change types.TodoRevision) error {
+	id := change.TodoId
+	var after *types.Todo
+	if change.After != "" {
+		after = &types.Todo{}
+		if err := json.Unmarshal([]byte(change.After), after); err != nil {
+			return fmt.Errorf("failed to read revision %s: %v", change.Id, err)
+		}
+	}
+
+	if change.Before == "" {
+		// Undoing a creation deletes the todo
+		if err := t.storage.Delete(ctx, &types.Todo{}, map[string]any{"id": id}); err != nil {
+			return err
+		}
+		t.logger.Debug("undid the creation of todo", slog.String("id", id))
+		t.record(ctx, types.RevisionDeleted, id, after, nil)
+		t.purge(ctx, id)
+		t.changed(TodoEvent{Type: EventDeleted, Todo: types.Todo{Id: id}})
+		return nil
+	}
+
+	before := types.Todo{}
+	if err := json.Unmarshal([]byte(change.Before), &before); err != nil {
+		return fmt.Errorf("failed to read revision %s: %v", change.Id, err)
+	}
+	// Like reverts, the todo gets the content it had and is updated now so clients syncing it
+	// see the change
+	before.UpdatedAt = t.clock.Now()
+	if err := t.storage.Update(ctx, before, map[string]any{"id": id}); err != nil {
+		return err
+	}
+	t.logger.Debug("undid a change to todo", slog.String("id", id), slog.String("revision", change.Id))
+	t.record(ctx, types.RevisionReverted, id, after, &before)
+	if after == nil {
+		t.changed(TodoEvent{Type: EventCreated, Todo: before})
+	} else {
+		t.changed(TodoEvent{Type: EventUpdated, Todo: before})
+	}
+	t.assigned(after, before)
+	return nil
+}
+
+// storedPrecision is how far apart the times of a todo and of the todo it was stored as can be,
+// providers round times to their precision (MySQL keeps milliseconds)
+const storedPrecision = time.Millisecond
+
+// sameTodo reports whether a and b hold the same values, times are the same within storedPrecision
+func sameTodo(a types.Todo, b types.Todo) bool {
+	return a.Id == b.Id &&
+		a.Summary == b.Summary &&
+		a.Done == b.Done &&
+		a.Status == b.Status &&
+		a.Priority == b.Priority &&
+		slices.Equal(a.Assignees, b.Assignees) &&
+		a.EstimateSeconds == b.EstimateSeconds &&
+		sameTimes(a.Due, b.Due) &&
+		sameTimes(a.CompletedAt, b.CompletedAt) &&
+		sameTimes(&a.CreatedAt, &b.CreatedAt) &&
+		sameTimes(&a.UpdatedAt, &b.UpdatedAt)
+}
+
+func sameTimes(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Sub(*b).Abs() < storedPrecision
+}
@@ -0,0 +1,161 @@
+package todo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/types"
+)
+
+func TestTodoServiceUndo(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			service, clock := newService(t, newAdapter(t))
+			created, err := service.CreateTodo(context.Background(), types.TodoUpdate{Summary: "Pick up the groceries"})
+			if err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+
+			// Changes made with the same context are undone together
+			ctx, changes := TrackChanges(context.Background())
+			updated := created
+			updated.Summary = "Pick up the mail"
+			if err := service.UpdateTodo(ctx, updated); err != nil {
+				t.Fatalf("UpdateTodo() error = %v", err)
+			}
+			if err := service.DeleteTodo(ctx, created.Id); err != nil {
+				t.Fatalf("DeleteTodo() error = %v", err)
+			}
+			token := changes.UndoToken()
+			if token == "" {
+				t.Fatal("UndoToken() = \"\", want a token")
+			}
+			if err := service.Undo(context.Background(), token); err != nil {
+				t.Fatalf("Undo() error = %v", err)
+			}
+			todo, err := service.GetTodo(context.Background(), created.Id)
+			if err != nil || todo.Summary != created.Summary {
+				t.Errorf("GetTodo() after the undo = %+v, %v, want the todo before the update", todo, err)
+			}
+
+			if err := service.Undo(context.Background(), token); !errors.Is(err, ErrChangedSince) {
+				t.Errorf("Undo() of an undone change error = %v, want ErrChangedSince", err)
+			}
+
+			// Undoing a creation deletes the todo
+			ctx, changes = TrackChanges(context.Background())
+			another, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Call the plumber"})
+			if err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+			if err := service.Undo(context.Background(), changes.UndoToken()); err != nil {
+				t.Fatalf("Undo() of a creation error = %v", err)
+			}
+			if _, err := service.GetTodo(context.Background(), another.Id); err == nil {
+				t.Errorf("GetTodo() after undoing the creation error = nil, want not found")
+			}
+
+			ctx, changes = TrackChanges(context.Background())
+			if err := service.DeleteTodo(ctx, created.Id); err != nil {
+				t.Fatalf("DeleteTodo() error = %v", err)
+			}
+			clock.Advance(DefaultUndoWindow + time.Second)
+			if err := service.Undo(context.Background(), changes.UndoToken()); !errors.Is(err, ErrUndoExpired) {
+				t.Errorf("Undo() after the undo window error = %v, want ErrUndoExpired", err)
+			}
+
+			var badRequest *serviceErrors.BadRequest
+			if err := service.Undo(context.Background(), "not a token"); !errors.As(err, &badRequest) {
+				t.Errorf("Undo() of an invalid token error = %v, want a bad request", err)
+			}
+		})
+	}
+}
+
+func TestUndoTokensAreSigned(t *testing.T) {
+	ctx := context.Background()
+	newService := func(key string) TodoService {
+		service, err := NewTodoService(TodoServiceProps{Storage: fakes.NewStorage(), Clock: fakes.NewClock(now), UndoKey: []byte(key)})
+		if err != nil {
+			t.Fatalf("NewTodoService() error = %v", err)
+		}
+		return service
+	}
+	service := newService("secret")
+	created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "Pick up the groceries"})
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+	tracked, changes := TrackChanges(ctx)
+	if err := service.DeleteTodo(tracked, created.Id); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+	token := changes.UndoToken()
+	payload, signature, _ := strings.Cut(token, ".")
+	history, _, err := service.TodoHistory(ctx, created.Id, 10, "")
+	if err != nil || len(history) != 2 {
+		t.Fatalf("TodoHistory() = %+v, %v, want 2 revisions", history, err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "unsigned revisions", token: base64.RawURLEncoding.EncodeToString([]byte(history[1].Id))},
+		{name: "other revisions", token: base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d,%s", now.Add(time.Hour).Unix(), history[0].Id))) + "." + signature},
+		{name: "invalid signature", token: payload + ".c2lnbmF0dXJl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.Undo(ctx, tt.token); !isBadRequest(err) {
+				t.Errorf("Undo() error = %v, want a BadRequest", err)
+			}
+		})
+	}
+	if err := newService("another secret").Undo(ctx, token); !isBadRequest(err) {
+		t.Errorf("Undo() of a token signed with another key error = %v, want a BadRequest", err)
+	}
+	if err := service.Undo(ctx, token); err != nil {
+		t.Errorf("Undo() error = %v", err)
+	}
+}
+
+func TestUndoDeletionRestoresTheTodoOnly(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newService(t, fakes.NewStorage())
+	ids := createTodos(t, service, 2)
+	if _, err := service.CreateComment(ctx, ids[0], types.CommentUpdate{Body: "On my way"}); err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+	if err := service.AddDependency(ctx, ids[0], ids[1]); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+
+	tracked, changes := TrackChanges(ctx)
+	if err := service.DeleteTodo(tracked, ids[0]); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+	if err := service.Undo(ctx, changes.UndoToken()); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+
+	if _, err := service.GetTodo(ctx, ids[0]); err != nil {
+		t.Errorf("GetTodo() after undoing the deletion error = %v", err)
+	}
+	comments, _, err := service.ListComments(ctx, ids[0], 10, "")
+	if err != nil || len(comments) != 0 {
+		t.Errorf("ListComments() after undoing the deletion = %+v, %v, want the comments deleted with the todo", comments, err)
+	}
+	dependencies, err := service.TodoDependencies(ctx, ids[0])
+	if err != nil || len(dependencies.BlockedBy) != 0 {
+		t.Errorf("TodoDependencies() after undoing the deletion = %+v, %v, want the dependencies deleted with the todo", dependencies, err)
+	}
+}
@@ -0,0 +1,197 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/store"
+	"todo-service/pkg/types"
+)
+
+// Workflow is the state machine of the statuses of todos. The done flag of a todo is derived from
+// its status, and setting the flag alone moves the todo to the first Done status or back to Initial.
+type Workflow struct {
+	// Statuses are the statuses todos can have, in the order the board lists them
+	Statuses []string
+	// Initial is the status of new todos that aren't done
+	Initial string
+	// Done are the statuses of done todos
+	Done []string
+	// Transitions lists the statuses each status can move to
+	Transitions map[string][]string
+}
+
+// DefaultWorkflow moves todos from todo to in_progress to done, possibly blocking them on the way
+var DefaultWorkflow = Workflow{
+	Statuses: []string{"todo", "in_progress", "blocked", "done"},
+	Initial:  "todo",
+	Done:     []string{"done"},
+	Transitions: map[string][]string{
+		"todo":        {"in_progress", "blocked", "done"},
+		"in_progress": {"todo", "blocked", "done"},
+		"blocked":     {"todo", "in_progress"},
+		"done":        {"todo", "in_progress"},
+	},
+}
+
+// statusPattern matches statuses. They are lowercase as configuration keys (the transitions) are
+// case insensitive.
+var statusPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Validate checks the workflow is consistent: statuses are unique and well-formed, and the initial,
+// done and transition statuses are among them
+func (w Workflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return errors.New("a workflow needs statuses")
+	}
+	for i, status := range w.Statuses {
+		if !statusPattern.MatchString(status) {
+			return fmt.Errorf("status %q must be lowercase letters, digits and underscores", status)
+		}
+		if slices.Contains(w.Statuses[:i], status) {
+			return fmt.Errorf("status %q is listed twice", status)
+		}
+	}
+	if !w.known(w.Initial) {
+		return fmt.Errorf("the initial status %q isn't one of the statuses", w.Initial)
+	}
+	if w.isDone(w.Initial) {
+		return fmt.Errorf("the initial status %q can't be a done status", w.Initial)
+	}
+	if len(w.Done) == 0 {
+		return errors.New("a workflow needs a done status")
+	}
+	for _, status := range w.Done {
+		if !w.known(status) {
+			return fmt.Errorf("the done status %q isn't one of the statuses", status)
+		}
+	}
+	for from, to := range w.Transitions {
+		if !w.known(from) {
+			return fmt.Errorf("the transitions from %q don't start from one of the statuses", from)
+		}
+		for _, status := range to {
+			if !w.known(status) {
+				return fmt.Errorf("the transition from %q to %q doesn't lead to one of the statuses", from, status)
+			}
+		}
+	}
+	return nil
+}
+
+func (w Workflow) known(status string) bool {
+	return slices.Contains(w.Statuses, status)
+}
+
+func (w Workflow) isDone(status string) bool {
+	return slices.Contains(w.Done, status)
+}
+
+// allows reports whether todos can move from one status to the other, staying in a status is
+// always allowed
+func (w Workflow) allows(from string, to string) bool {
+	return from == to || slices.Contains(w.Transitions[from], to)
+}
+
+// statusOf returns the status of a todo, todos stored before they had one get the status their
+// done flag stands for
+func (w Workflow) statusOf(todo types.Todo) string {
+	switch {
+	case todo.Status != "":
+		return todo.Status
+	case todo.Done:
+		return w.Done[0]
+	default:
+		return w.Initial
+	}
+}
+
+// transition sets the status and done flag of a todo changed from current, which is nil for new
+// todos. The status wins when both changed, the done flag alone moves the todo to the first done
+// status or back to the initial one. New todos can start in any status.
+func (w Workflow) transition(todo *types.Todo, current *types.Todo) error {
+	if current == nil {
+		todo.Status = w.statusOf(*todo)
+		if !w.known(todo.Status) {
+			return unknownStatus(w, todo.Status)
+		}
+		todo.Done = w.isDone(todo.Status)
+		return nil
+	}
+
+	from := w.statusOf(*current)
+	switch {
+	case todo.Status == "" || todo.Status == from:
+		todo.Status = from
+		if todo.Done != w.isDone(from) {
+			todo.Status = w.statusOf(types.Todo{Done: todo.Done})
+		}
+	case !w.known(todo.Status):
+		return unknownStatus(w, todo.Status)
+	}
+	if !w.allows(from, todo.Status) {
+		return &serviceErrors.BadRequest{Message: fmt.Sprintf("a todo can't move from %s to %s, %s todos can move to: %v", from, todo.Status, from, w.Transitions[from])}
+	}
+	todo.Done = w.isDone(todo.Status)
+	return nil
+}
+
+func unknownStatus(w Workflow, status string) error {
+	return &serviceErrors.BadRequest{Message: fmt.Sprintf("unknown status %q, statuses must be one of %v", status, w.Statuses)}
+}
+
+func (t *todoService) BackfillStatuses(ctx context.Context) error {
+	stored := map[string]bool{}
+	if db, ok := store.GormDB(t.storage.Adapter()); ok {
+		db = db.WithContext(ctx)
+		for done, status := range map[bool]string{true: t.workflow.Done[0], false: t.workflow.Initial} {
+			err := db.Model(&types.Todo{}).
+				Where("(status IS NULL OR status = '') AND done = ?", done).
+				Update("status", status).Error
+			if err != nil {
+				return fmt.Errorf("failed to backfill the status of todos: %v", err)
+			}
+		}
+		statuses := []string{}
+		if err := db.Model(&types.Todo{}).Distinct("status").Pluck("status", &statuses).Error; err != nil {
+			return fmt.Errorf("failed to read the statuses of todos: %v", err)
+		}
+		for _, status := range statuses {
+			stored[status] = true
+		}
+	} else {
+		// Other adapters derive the status of todos stored without one when reading them
+		cursor := ""
+		for {
+			page := []types.Todo{}
+			next, err := t.storage.List(ctx, &page, "Id", map[string]any{}, indexPageSize, cursor)
+			if err != nil {
+				return fmt.Errorf("failed to read the statuses of todos: %v", err)
+			}
+			for _, todo := range page {
+				stored[t.workflow.statusOf(todo)] = true
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+	}
+
+	unknown := []string{}
+	for status := range stored {
+		if !t.workflow.known(status) {
+			unknown = append(unknown, status)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("todos have statuses missing from the workflow %v: %v", t.workflow.Statuses, unknown)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/types"
+)
+
+func TestWorkflowValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow Workflow
+		wantErr  bool
+	}{
+		{name: "default", workflow: DefaultWorkflow},
+		{name: "no statuses", workflow: Workflow{}, wantErr: true},
+		{name: "uppercase status", workflow: Workflow{Statuses: []string{"Todo", "done"}, Initial: "Todo", Done: []string{"done"}}, wantErr: true},
+		{name: "duplicate status", workflow: Workflow{Statuses: []string{"todo", "todo", "done"}, Initial: "todo", Done: []string{"done"}}, wantErr: true},
+		{name: "unknown initial status", workflow: Workflow{Statuses: []string{"todo", "done"}, Initial: "new", Done: []string{"done"}}, wantErr: true},
+		{name: "done initial status", workflow: Workflow{Statuses: []string{"todo", "done"}, Initial: "done", Done: []string{"done"}}, wantErr: true},
+		{name: "no done status", workflow: Workflow{Statuses: []string{"todo", "done"}, Initial: "todo"}, wantErr: true},
+		{
+			name:     "unknown transition",
+			workflow: Workflow{Statuses: []string{"todo", "done"}, Initial: "todo", Done: []string{"done"}, Transitions: map[string][]string{"todo": {"review"}}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.workflow.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, want an error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newService(t, fakes.NewStorage())
+
+	created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo"})
+	if err != nil || created.Status != "todo" || created.Done {
+		t.Fatalf("CreateTodo() = %s done %v, %v, want an initial todo", created.Status, created.Done, err)
+	}
+
+	steps := []struct {
+		name       string
+		change     func(todo *types.Todo)
+		wantStatus string
+		wantDone   bool
+		wantErr    bool
+	}{
+		{name: "start", change: func(todo *types.Todo) { todo.Status = "in_progress" }, wantStatus: "in_progress"},
+		{name: "block", change: func(todo *types.Todo) { todo.Status = "blocked" }, wantStatus: "blocked"},
+		{name: "blocked todos can't be done", change: func(todo *types.Todo) { todo.Status = "done" }, wantStatus: "blocked", wantErr: true},
+		{name: "done flag follows the workflow too", change: func(todo *types.Todo) { todo.Done = true }, wantStatus: "blocked", wantErr: true},
+		{name: "unknown status", change: func(todo *types.Todo) { todo.Status = "review" }, wantStatus: "blocked", wantErr: true},
+		{name: "unblock", change: func(todo *types.Todo) { todo.Status = "in_progress" }, wantStatus: "in_progress"},
+		{name: "done flag", change: func(todo *types.Todo) { todo.Done = true }, wantStatus: "done", wantDone: true},
+		{name: "status wins over the done flag", change: func(todo *types.Todo) { todo.Status = "in_progress"; todo.Done = true }, wantStatus: "in_progress"},
+		{name: "empty status keeps the current one", change: func(todo *types.Todo) { todo.Status = "" }, wantStatus: "in_progress"},
+	}
+	for _, step := range steps {
+		current, err := service.GetTodo(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("GetTodo() error = %v", err)
+		}
+		step.change(&current)
+		err = service.UpdateTodo(ctx, current)
+		var badRequest *serviceErrors.BadRequest
+		if step.wantErr != errors.As(err, &badRequest) {
+			t.Errorf("%s: UpdateTodo() error = %v, want a BadRequest: %v", step.name, err, step.wantErr)
+		}
+		got, err := service.GetTodo(ctx, created.Id)
+		if err != nil || got.Status != step.wantStatus || got.Done != step.wantDone {
+			t.Errorf("%s: todo is %s done %v, %v, want %s done %v", step.name, got.Status, got.Done, err, step.wantStatus, step.wantDone)
+		}
+	}
+}
+
+func TestBackfillStatuses(t *testing.T) {
+	workflow := Workflow{Statuses: []string{"backlog", "closed"}, Initial: "backlog", Done: []string{"closed"}, Transitions: map[string][]string{}}
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			adapter := newAdapter(t)
+			// Todos stored before they had a status
+			for i, done := range []bool{false, true} {
+				todo := types.Todo{Id: fmt.Sprintf("00000000-0000-7000-8000-00000000000%d", i+1), Summary: "todo", Done: done, CreatedAt: now, UpdatedAt: now}
+				if err := adapter.Create(&todo); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+			service, err := NewTodoService(TodoServiceProps{Storage: adapter, Clock: fakes.NewClock(now), Workflow: workflow})
+			if err != nil {
+				t.Fatalf("NewTodoService() error = %v", err)
+			}
+
+			if err := service.BackfillStatuses(ctx); err != nil {
+				t.Fatalf("BackfillStatuses() error = %v", err)
+			}
+			for status, wantId := range map[string]string{"backlog": "00000000-0000-7000-8000-000000000001", "closed": "00000000-0000-7000-8000-000000000002"} {
+				todos, _, err := service.FilterTodos(ctx, TodoFilter{Status: status}, 10, "")
+				if err != nil || len(todos) != 1 || todos[0].Id != wantId {
+					t.Errorf("FilterTodos(%s) = %+v, %v, want %s", status, todos, err, wantId)
+				}
+			}
+
+			if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "closed", Status: "closed"}); err != nil {
+				t.Fatalf("CreateTodo() error = %v", err)
+			}
+			// The statuses of the other workflow are unknown to the default one
+			defaults, _ := newService(t, adapter)
+			if err := defaults.BackfillStatuses(ctx); err == nil || !strings.Contains(err.Error(), "closed") {
+				t.Errorf("BackfillStatuses() with another workflow error = %v, want the unknown statuses", err)
+			}
+		})
+	}
+}
+
+func TestTodoBoard(t *testing.T) {
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := newService(t, newAdapter(t))
+			for _, update := range []types.TodoUpdate{
+				{Summary: "first", Status: "in_progress"},
+				{Summary: "second", Done: true},
+				{Summary: "third", Status: "in_progress", Assignees: types.Assignees{"alice"}},
+				{Summary: "fourth"},
+			} {
+				if _, err := service.CreateTodo(ctx, update); err != nil {
+					t.Fatalf("CreateTodo() error = %v", err)
+				}
+			}
+
+			board, err := service.TodoBoard(ctx, TodoFilter{}, 1)
+			if err != nil {
+				t.Fatalf("TodoBoard() error = %v", err)
+			}
+			want := []struct {
+				status  string
+				summary string
+				next    bool
+			}{{"todo", "fourth", false}, {"in_progress", "first", true}, {"blocked", "", false}, {"done", "second", false}}
+			if len(board.Columns) != len(want) {
+				t.Fatalf("TodoBoard() = %d columns, want %d", len(board.Columns), len(want))
+			}
+			for i, w := range want {
+				column := board.Columns[i]
+				summary := ""
+				if len(column.Todos) > 0 {
+					summary = column.Todos[0].Summary
+				}
+				if column.Status != w.status || summary != w.summary || (column.Next != "") != w.next || column.Done != (w.status == "done") {
+					t.Errorf("column %d = %s %q next %q, want %s %q with a next page: %v", i, column.Status, summary, column.Next, w.status, w.summary, w.next)
+				}
+			}
+
+			if count, err := service.CountTodos(ctx, TodoFilter{Status: "in_progress", Assignee: "alice"}); err != nil || count != 1 {
+				t.Errorf("CountTodos() = %d, %v, want 1", count, err)
+			}
+			if _, err := service.CountTodos(ctx, TodoFilter{Status: "review"}); err == nil {
+				t.Error("CountTodos() of an unknown status succeeded, want an error")
+			}
+		})
+	}
+}
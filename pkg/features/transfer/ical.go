@@ -0,0 +1,164 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"todo-service/pkg/ical"
+	"todo-service/pkg/types"
+)
+
+// CalendarName is the display name of exported calendars
+const CalendarName = "Todos"
+
+// maxCalendarSize is the maximum size of an imported iCalendar file
+const maxCalendarSize = 10 * 1024 * 1024
+
+// ToVTodo maps a todo to an RFC 5545 VTODO component
+func ToVTodo(t types.Todo) *ical.Component {
+	c := &ical.Component{Name: "VTODO"}
+	c.Add(ical.Property{Name: "UID", Value: t.Id})
+	c.Add(ical.TimeProperty("DTSTAMP", t.UpdatedAt))
+	c.Add(ical.TimeProperty("CREATED", t.CreatedAt))
+	c.Add(ical.TimeProperty("LAST-MODIFIED", t.UpdatedAt))
+	c.Add(ical.TextProperty("SUMMARY", t.Summary))
+	if t.Due != nil {
+		c.Add(ical.TimeProperty("DUE", *t.Due))
+	}
+	if t.Priority > 0 {
+		c.Add(ical.Property{Name: "PRIORITY", Value: strconv.Itoa(t.Priority)})
+	}
+	if t.Done {
+		c.Add(ical.Property{Name: "STATUS", Value: "COMPLETED"})
+		if t.CompletedAt != nil {
+			c.Add(ical.TimeProperty("COMPLETED", *t.CompletedAt))
+		}
+	} else {
+		c.Add(ical.Property{Name: "STATUS", Value: "NEEDS-ACTION"})
+	}
+	return c
+}
+
+// FromComponent maps a VTODO or VEVENT component to a todo. Events become todos due when they end
+// (or start when they have no end). UIDs are only kept as ids when they are valid todo ids, other
+// calendar apps use UIDs that can't be stored as one so those todos get a new id.
+func FromComponent(c *ical.Component) (types.Todo, error) {
+	t := types.Todo{}
+	if c.Name != "VTODO" && c.Name != "VEVENT" {
+		return t, fmt.Errorf("unsupported component %s", c.Name)
+	}
+
+	if uid := c.Get("UID"); uid != nil {
+		if id, err := uuid.Parse(uid.Value); err == nil {
+			t.Id = id.String()
+		}
+	}
+	if summary := c.Get("SUMMARY"); summary != nil {
+		t.Summary = summary.Text()
+	}
+
+	var err error
+	if t.CreatedAt, err = optionalTime(c, "CREATED"); err != nil {
+		return t, err
+	}
+	if t.UpdatedAt, err = optionalTime(c, "LAST-MODIFIED"); err != nil {
+		return t, err
+	}
+
+	dueProperties := []string{"DUE"}
+	if c.Name == "VEVENT" {
+		dueProperties = []string{"DTEND", "DTSTART"}
+	}
+	for _, name := range dueProperties {
+		if p := c.Get(name); p != nil {
+			due, err := p.Time()
+			if err != nil {
+				return t, err
+			}
+			t.Due = &due
+			break
+		}
+	}
+
+	if p := c.Get("PRIORITY"); p != nil {
+		if t.Priority, err = strconv.Atoi(strings.TrimSpace(p.Value)); err != nil || t.Priority < 0 || t.Priority > 9 {
+			return t, fmt.Errorf("invalid PRIORITY value %q, priority must be between 0 and 9", p.Value)
+		}
+	}
+
+	if p := c.Get("STATUS"); p != nil && strings.EqualFold(p.Value, "COMPLETED") {
+		t.Done = true
+	}
+	if p := c.Get("COMPLETED"); p != nil {
+		completed, err := p.Time()
+		if err != nil {
+			return t, err
+		}
+		t.Done = true
+		t.CompletedAt = &completed
+	}
+	return t, nil
+}
+
+func optionalTime(c *ical.Component, name string) (time.Time, error) {
+	if p := c.Get(name); p != nil {
+		return p.Time()
+	}
+	return time.Time{}, nil
+}
+
+// icsDecoder reads VTODO and VEVENT components from an iCalendar file. Calendars are small and
+// their components can only be read once the whole file is parsed, so unlike the other formats the
+// file is read into memory.
+func icsDecoder(r io.Reader) func() (types.Todo, error) {
+	var components []*ical.Component
+	var parseErr error
+	parsed := false
+	return func() (types.Todo, error) {
+		if !parsed {
+			parsed = true
+			components, parseErr = readComponents(r)
+		}
+		if parseErr != nil {
+			return types.Todo{}, parseErr
+		}
+		if len(components) == 0 {
+			return types.Todo{}, io.EOF
+		}
+
+		c := components[0]
+		components = components[1:]
+		t, err := FromComponent(c)
+		if err != nil {
+			return t, &invalidRecord{err: err}
+		}
+		return t, nil
+	}
+}
+
+func readComponents(r io.Reader) ([]*ical.Component, error) {
+	limited := &io.LimitedReader{R: r, N: maxCalendarSize + 1}
+	calendars, err := ical.Parse(limited)
+	if limited.N <= 0 {
+		return nil, errors.New("the calendar is too large")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	components := []*ical.Component{}
+	for _, calendar := range calendars {
+		for _, c := range calendar.Components {
+			if c.Name == "VTODO" || c.Name == "VEVENT" {
+				components = append(components, c)
+			}
+		}
+	}
+	return components, nil
+}
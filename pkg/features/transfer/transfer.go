@@ -0,0 +1,394 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/ical"
+	"todo-service/pkg/types"
+)
+
+// Format is a serialization format todos can be exported to and imported from
+type Format string
+
+const (
+	NDJSON Format = "ndjson"
+	JSON   Format = "json"
+	CSV    Format = "csv"
+	ICS    Format = "ics"
+)
+
+var Formats = []Format{NDJSON, JSON, CSV, ICS}
+
+// pageSize is the number of todos read from storage at a time while exporting
+const pageSize = 100
+
+// maxRecordSize is the maximum size of a single NDJSON line
+const maxRecordSize = 1024 * 1024
+
+// MaxImportErrors is the number of failed records whose error is reported in an import summary
+const MaxImportErrors = 100
+
+var csvHeader = []string{"id", "summary", "done", "due", "priority", "completedAt", "createdAt", "updatedAt"}
+
+func ParseFormat(value string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(value) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format %q, supported formats are: %v", value, Formats)
+}
+
+// FormatFromContentType returns the format matching a Content-Type header value
+func FormatFromContentType(contentType string) (Format, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	for _, f := range Formats {
+		if strings.TrimSpace(mediaType) == f.ContentType() {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case JSON:
+		return "application/json"
+	case CSV:
+		return "text/csv"
+	case ICS:
+		return "text/calendar"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Export writes every todo to w. Todos are read from the service a page at a time and written as
+// they are read so exports of any size use a bounded amount of memory.
+func Export(ctx context.Context, service todo.TodoService, format Format, w io.Writer) error {
+	var write func(types.Todo) error
+	var finish func() error
+
+	switch format {
+	case NDJSON:
+		encoder := json.NewEncoder(w)
+		write = func(t types.Todo) error { return encoder.Encode(t) }
+		finish = func() error { return nil }
+	case JSON:
+		first := true
+		write = func(t types.Todo) error {
+			separator := ","
+			if first {
+				separator, first = "[", false
+			}
+			data, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "%s\n%s", separator, data)
+			return err
+		}
+		finish = func() error {
+			closing := "\n]\n"
+			if first {
+				closing = "[]\n"
+			}
+			_, err := io.WriteString(w, closing)
+			return err
+		}
+	case CSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvHeader); err != nil {
+			return err
+		}
+		write = func(t types.Todo) error {
+			return writer.Write([]string{
+				t.Id,
+				t.Summary,
+				strconv.FormatBool(t.Done),
+				formatOptionalTime(t.Due),
+				strconv.Itoa(t.Priority),
+				formatOptionalTime(t.CompletedAt),
+				formatTime(t.CreatedAt),
+				formatTime(t.UpdatedAt),
+			})
+		}
+		finish = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case ICS:
+		// The calendar is written in pieces so its todos can be streamed
+		calendar := ical.NewCalendar(CalendarName)
+		writer := ical.NewWriter(w)
+		if err := writer.Begin(calendar.Name); err != nil {
+			return err
+		}
+		if err := writer.WriteProperties(calendar); err != nil {
+			return err
+		}
+		write = func(t types.Todo) error { return writer.WriteComponent(ToVTodo(t)) }
+		finish = func() error { return writer.End(calendar.Name) }
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+
+	cursor := ""
+	for {
+		todos, next, err := service.ListTodos(ctx, pageSize, cursor)
+		if err != nil {
+			return err
+		}
+		for _, t := range todos {
+			if err := write(t); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if next == "" {
+			return finish()
+		}
+		cursor = next
+	}
+}
+
+// Import reads todos from r and imports them one at a time. Records that can't be decoded or
+// imported are reported in the summary and don't stop the import, an error is only returned when
+// the input can't be read any further.
+func Import(ctx context.Context, service todo.TodoService, format Format, r io.Reader, options todo.ImportOptions) (types.ImportSummary, error) {
+	summary := types.ImportSummary{DryRun: options.DryRun}
+
+	var next func() (types.Todo, error)
+	switch format {
+	case NDJSON:
+		next = ndjsonDecoder(r)
+	case JSON:
+		next = jsonDecoder(r)
+	case CSV:
+		next = csvDecoder(r)
+	case ICS:
+		next = icsDecoder(r)
+	default:
+		return summary, fmt.Errorf("unsupported format %q", format)
+	}
+
+	for record := 1; ; record++ {
+		t, err := next()
+		if errors.Is(err, io.EOF) {
+			return summary, nil
+		}
+
+		var invalid *invalidRecord
+		if errors.As(err, &invalid) {
+			fail(&summary, record, invalid.err)
+			continue
+		}
+		if err != nil {
+			return summary, fmt.Errorf("failed to read record %d: %w", record, err)
+		}
+
+		if strings.TrimSpace(t.Summary) == "" {
+			fail(&summary, record, errors.New("summary is required"))
+			continue
+		}
+
+		_, action, err := service.ImportTodo(ctx, t, options)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return summary, ctxErr
+		}
+		if err != nil {
+			fail(&summary, record, err)
+			continue
+		}
+
+		switch action {
+		case todo.ImportCreated:
+			summary.Created++
+		case todo.ImportOverwritten:
+			summary.Overwritten++
+		case todo.ImportSkipped:
+			summary.Skipped++
+		}
+	}
+}
+
+// fail counts record as failed in summary, only the errors of the first MaxImportErrors failed
+// records are kept so the summary stays small however many records fail
+func fail(summary *types.ImportSummary, record int, err error) {
+	summary.Failed++
+	if len(summary.Errors) < MaxImportErrors {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("record %d: %v", record, err))
+	}
+}
+
+// invalidRecord is returned by decoders for a record that can't be decoded when the records that
+// follow it can still be read
+type invalidRecord struct {
+	err error
+}
+
+func (e *invalidRecord) Error() string {
+	return e.err.Error()
+}
+
+func ndjsonDecoder(r io.Reader) func() (types.Todo, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	return func() (types.Todo, error) {
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			t := types.Todo{}
+			if err := json.Unmarshal(line, &t); err != nil {
+				return t, &invalidRecord{err: err}
+			}
+			return t, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return types.Todo{}, err
+		}
+		return types.Todo{}, io.EOF
+	}
+}
+
+func jsonDecoder(r io.Reader) func() (types.Todo, error) {
+	decoder := json.NewDecoder(r)
+	started := false
+	return func() (types.Todo, error) {
+		if !started {
+			started = true
+			token, err := decoder.Token()
+			if errors.Is(err, io.EOF) {
+				return types.Todo{}, io.EOF
+			}
+			if err != nil {
+				return types.Todo{}, err
+			}
+			if delim, ok := token.(json.Delim); !ok || delim != '[' {
+				return types.Todo{}, errors.New("expected a JSON array of todos")
+			}
+		}
+		if !decoder.More() {
+			return types.Todo{}, io.EOF
+		}
+		t := types.Todo{}
+		err := decoder.Decode(&t)
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			// The decoder already consumed the whole value so it can carry on with the next one
+			return t, &invalidRecord{err: err}
+		}
+		return t, err
+	}
+}
+
+func csvDecoder(r io.Reader) func() (types.Todo, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	var columns map[string]int
+	return func() (types.Todo, error) {
+		if columns == nil {
+			header, err := reader.Read()
+			if err != nil {
+				return types.Todo{}, err
+			}
+			columns = map[string]int{}
+			for i, name := range header {
+				columns[strings.TrimSpace(name)] = i
+			}
+			if _, ok := columns["summary"]; !ok {
+				return types.Todo{}, errors.New("the CSV header must contain a summary column")
+			}
+		}
+
+		row, err := reader.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return types.Todo{}, &invalidRecord{err: err}
+		}
+		if err != nil {
+			return types.Todo{}, err
+		}
+
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		t := types.Todo{Id: value("id"), Summary: value("summary")}
+		if done := value("done"); done != "" {
+			if t.Done, err = strconv.ParseBool(done); err != nil {
+				return t, &invalidRecord{err: fmt.Errorf("invalid done value %q", done)}
+			}
+		}
+		if priority := value("priority"); priority != "" {
+			if t.Priority, err = strconv.Atoi(priority); err != nil || t.Priority < 0 || t.Priority > 9 {
+				return t, &invalidRecord{err: fmt.Errorf("invalid priority value %q, priority must be between 0 and 9", priority)}
+			}
+		}
+		if t.Due, err = parseOptionalTime(value("due")); err != nil {
+			return t, &invalidRecord{err: err}
+		}
+		if t.CompletedAt, err = parseOptionalTime(value("completedAt")); err != nil {
+			return t, &invalidRecord{err: err}
+		}
+		if t.CreatedAt, err = parseTime(value("createdAt")); err != nil {
+			return t, &invalidRecord{err: err}
+		}
+		if t.UpdatedAt, err = parseTime(value("updatedAt")); err != nil {
+			return t, &invalidRecord{err: err}
+		}
+		return t, nil
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := parseTime(value)
+	return &t, err
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return t, fmt.Errorf("invalid timestamp %q, timestamps must be RFC 3339 formatted", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,230 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/types"
+)
+
+func newService(t *testing.T) todo.TodoService {
+	t.Helper()
+	service, err := todo.NewTodoService(todo.TodoServiceProps{
+		Storage:     fakes.NewStorage(),
+		Clock:       fakes.NewClock(time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)),
+		IdGenerator: &fakes.IdGenerator{},
+	})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+	return service
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	created := time.Date(2023, time.March, 4, 5, 6, 7, 800000000, time.UTC)
+	todos := []types.Todo{}
+	for i := 0; i < 250; i++ {
+		td := types.Todo{
+			Id:        fmt.Sprintf("00000000-0000-7000-8000-%012d", i+1),
+			Summary:   "todo, with \"quotes\"; a backslash \\ and a new line\nwhich is long enough to be folded in an iCalendar file",
+			Done:      i%2 == 0,
+			Priority:  i % 10,
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+			UpdatedAt: created.Add(time.Duration(i) * time.Hour),
+		}
+		if i%3 == 0 {
+			due := created.Add(time.Duration(i) * 24 * time.Hour)
+			td.Due = &due
+		}
+		todos = append(todos, td)
+	}
+
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			ctx := context.Background()
+			source := newService(t)
+			for _, td := range todos {
+				if _, _, err := source.ImportTodo(ctx, td, todo.ImportOptions{}); err != nil {
+					t.Fatalf("ImportTodo() error = %v", err)
+				}
+			}
+
+			exported := bytes.Buffer{}
+			if err := Export(ctx, source, format, &exported); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+
+			destination := newService(t)
+			summary, err := Import(ctx, destination, format, &exported, todo.ImportOptions{Conflict: todo.ConflictSkip})
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			if summary.Created != len(todos) || summary.Failed != 0 {
+				t.Fatalf("Import() = %+v, want %d created", summary, len(todos))
+			}
+
+			for _, td := range todos {
+				want, err := source.GetTodo(ctx, td.Id)
+				if err != nil {
+					t.Fatalf("GetTodo(%q) error = %v", td.Id, err)
+				}
+				if format == ICS {
+					// iCalendar timestamps have a one second resolution
+					want = truncate(want)
+				}
+				got, err := destination.GetTodo(ctx, want.Id)
+				if err != nil || !got.Equal(want) {
+					t.Fatalf("GetTodo(%q) = %+v, %v, want %+v", want.Id, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func truncate(t types.Todo) types.Todo {
+	t.CreatedAt = t.CreatedAt.Truncate(time.Second)
+	t.UpdatedAt = t.UpdatedAt.Truncate(time.Second)
+	if t.Due != nil {
+		due := t.Due.Truncate(time.Second)
+		t.Due = &due
+	}
+	if t.CompletedAt != nil {
+		completed := t.CompletedAt.Truncate(time.Second)
+		t.CompletedAt = &completed
+	}
+	return t
+}
+
+func TestExportEmpty(t *testing.T) {
+	tests := map[Format]string{
+		NDJSON: "",
+		JSON:   "[]\n",
+		CSV:    "id,summary,done,due,priority,completedAt,createdAt,updatedAt\n",
+		ICS:    "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//tink3rlabs//todo-service//EN\r\nCALSCALE:GREGORIAN\r\nX-WR-CALNAME:Todos\r\nEND:VCALENDAR\r\n",
+	}
+	for format, want := range tests {
+		exported := bytes.Buffer{}
+		if err := Export(context.Background(), newService(t), format, &exported); err != nil {
+			t.Fatalf("Export(%s) error = %v", format, err)
+		}
+		if exported.String() != want {
+			t.Errorf("Export(%s) = %q, want %q", format, exported.String(), want)
+		}
+	}
+}
+
+func TestImportReportsInvalidRecords(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      Format
+		input       string
+		wantCreated int
+		wantFailed  int
+		wantErr     bool
+	}{
+		{
+			name:        "ndjson",
+			format:      NDJSON,
+			input:       "{\"summary\": \"first\"}\nnot json\n\n{\"done\": true}\n{\"summary\": \"last\"}",
+			wantCreated: 2,
+			wantFailed:  2,
+		},
+		{
+			name:        "json",
+			format:      JSON,
+			input:       `[{"summary": "first"}, {"summary": 1}, {"summary": "last", "createdAt": "2024-07-01T12:00:00Z"}]`,
+			wantCreated: 2,
+			wantFailed:  1,
+		},
+		{
+			name:        "csv",
+			format:      CSV,
+			input:       "summary,done,createdAt\nfirst,true,\nsecond,maybe,\nthird,false,yesterday\nlast,,2024-07-01T12:00:00Z\n",
+			wantCreated: 2,
+			wantFailed:  2,
+		},
+		{
+			name:   "ics",
+			format: ICS,
+			input: strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"VERSION:2.0",
+				"BEGIN:VTODO",
+				"UID:not-a-uuid@example.com",
+				"SUMMARY:first",
+				"DUE;VALUE=DATE:20240705",
+				"END:VTODO",
+				"BEGIN:VEVENT",
+				"UID:event@example.com",
+				"SUMMARY:meeting",
+				"DTSTART;TZID=Europe/Berlin:20240705T100000",
+				"END:VEVENT",
+				"BEGIN:VTODO",
+				"SUMMARY:bad priority",
+				"PRIORITY:high",
+				"END:VTODO",
+				"BEGIN:VTODO",
+				"DUE:20240705T100000Z",
+				"END:VTODO",
+				"BEGIN:VTIMEZONE",
+				"TZID:Europe/Berlin",
+				"END:VTIMEZONE",
+				"END:VCALENDAR",
+			}, "\r\n"),
+			wantCreated: 2,
+			wantFailed:  2,
+		},
+		{name: "ics that isn't a calendar", format: ICS, input: "BEGIN:VTODO\r\nSUMMARY:first\r\n", wantErr: true},
+		{name: "json that isn't an array", format: JSON, input: `{"summary": "first"}`, wantErr: true},
+		{name: "csv without a summary column", format: CSV, input: "id,done\n1,true\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := Import(context.Background(), newService(t), tt.format, strings.NewReader(tt.input), todo.ImportOptions{Conflict: todo.ConflictSkip})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Import() error = %v, want error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if summary.Created != tt.wantCreated || summary.Failed != tt.wantFailed || len(summary.Errors) != tt.wantFailed {
+				t.Errorf("Import() = %+v, want %d created and %d failed", summary, tt.wantCreated, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestImportKeepsTheFirstErrors(t *testing.T) {
+	input := strings.Repeat("not json\n", MaxImportErrors+50)
+	summary, err := Import(context.Background(), newService(t), NDJSON, strings.NewReader(input), todo.ImportOptions{Conflict: todo.ConflictSkip})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if summary.Failed != MaxImportErrors+50 || len(summary.Errors) != MaxImportErrors {
+		t.Errorf("Import() = %d failed with %d errors, want %d failed with %d errors", summary.Failed, len(summary.Errors), MaxImportErrors+50, MaxImportErrors)
+	}
+}
+
+func TestFormatFromContentType(t *testing.T) {
+	tests := map[string]Format{
+		"application/x-ndjson":            NDJSON,
+		"application/json; charset=utf-8": JSON,
+		"text/csv":                        CSV,
+		"text/calendar":                   ICS,
+	}
+	for contentType, want := range tests {
+		if got, ok := FormatFromContentType(contentType); !ok || got != want {
+			t.Errorf("FormatFromContentType(%q) = %q, %v, want %q", contentType, got, ok, want)
+		}
+	}
+	if _, ok := FormatFromContentType("text/plain"); ok {
+		t.Error("FormatFromContentType(text/plain) succeeded, want no format")
+	}
+}
@@ -0,0 +1,227 @@
+package gql
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/types"
+)
+
+const (
+	// defaultPageSize matches the default limit of the REST API
+	defaultPageSize = 10
+	// listPageSize is the number of todos read from storage at a time when every todo is needed
+	listPageSize = 100
+)
+
+// Connection is a page of todos in the shape of a Relay connection
+type Connection struct {
+	Edges    []Edge       `json:"edges"`
+	Nodes    []types.Todo `json:"nodes"`
+	PageInfo PageInfo     `json:"pageInfo"`
+}
+
+type Edge struct {
+	Cursor string     `json:"cursor"`
+	Node   types.Todo `json:"node"`
+}
+
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor,omitempty"`
+}
+
+// Filter narrows down the todos returned by the todos query, unset fields match every todo
+type Filter struct {
+	Done            *bool
+	SummaryContains string
+	DueBefore       *time.Time
+	DueAfter        *time.Time
+}
+
+func (f Filter) isZero() bool {
+	return f == Filter{}
+}
+
+func (f Filter) match(t types.Todo) bool {
+	if f.Done != nil && t.Done != *f.Done {
+		return false
+	}
+	if f.SummaryContains != "" && !strings.Contains(strings.ToLower(t.Summary), strings.ToLower(f.SummaryContains)) {
+		return false
+	}
+	if f.DueBefore != nil && (t.Due == nil || !t.Due.Before(*f.DueBefore)) {
+		return false
+	}
+	if f.DueAfter != nil && (t.Due == nil || !t.Due.After(*f.DueAfter)) {
+		return false
+	}
+	return true
+}
+
+// Order is the order the todos query returns todos in, ties are broken by id
+type Order struct {
+	Field      string
+	Descending bool
+}
+
+const (
+	OrderById        = "ID"
+	OrderByCreatedAt = "CREATED_AT"
+	OrderByUpdatedAt = "UPDATED_AT"
+	OrderByDue       = "DUE"
+	OrderByPriority  = "PRIORITY"
+	OrderBySummary   = "SUMMARY"
+)
+
+func (o Order) isDefault() bool {
+	return o == Order{Field: OrderById}
+}
+
+func (o Order) compare(a types.Todo, b types.Todo) int {
+	c := 0
+	switch o.Field {
+	case OrderByCreatedAt:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case OrderByUpdatedAt:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	case OrderByDue:
+		// Todos without a due date come last whatever the direction
+		switch {
+		case a.Due == nil && b.Due == nil:
+		case a.Due == nil:
+			return 1
+		case b.Due == nil:
+			return -1
+		default:
+			c = a.Due.Compare(*b.Due)
+		}
+	case OrderByPriority:
+		c = cmp.Compare(a.Priority, b.Priority)
+	case OrderBySummary:
+		c = strings.Compare(a.Summary, b.Summary)
+	}
+	if c == 0 {
+		c = strings.Compare(a.Id, b.Id)
+	}
+	if o.Descending {
+		return -c
+	}
+	return c
+}
+
+// cursor is the position after an edge, encoded as an opaque string. Todos listed in the default
+// order are read a page at a time from the TodoService, the position is then its cursor (Next) and
+// the number of todos to skip from there (Skip). Filtered or sorted todos are read at once and the
+// position is an Offset into them.
+type cursor struct {
+	Sorted bool   `json:"sorted,omitempty"`
+	Next   string `json:"next,omitempty"`
+	Skip   int    `json:"skip,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string, maxPageSize int) (cursor, error) {
+	c := cursor{}
+	if s == "" {
+		return c, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	// Cursors aren't signed, a Skip larger than a page would read as many todos as the client asks for
+	if err != nil || c.Skip < 0 || c.Skip > maxPageSize || c.Offset < 0 {
+		return c, badUserInput("after isn't a valid cursor")
+	}
+	return c, nil
+}
+
+// listTodos returns the connection of the first todos after the after cursor, first can't be
+// larger than maxPageSize
+func listTodos(ctx context.Context, service todo.TodoService, first int, maxPageSize int, after string, filter Filter, order Order) (Connection, error) {
+	if first < 0 || first > maxPageSize {
+		return Connection{}, badUserInput(fmt.Sprintf("first must be between 0 and %d", maxPageSize))
+	}
+	position, err := decodeCursor(after, maxPageSize)
+	if err != nil {
+		return Connection{}, err
+	}
+
+	sorted := !filter.isZero() || !order.isDefault()
+	if after != "" && position.Sorted != sorted {
+		return Connection{}, badUserInput("after is a cursor of a query with a different filter or order")
+	}
+	if sorted {
+		return listSortedTodos(ctx, service, first, position, filter, order)
+	}
+
+	connection := Connection{Edges: []Edge{}, Nodes: []types.Todo{}}
+	if first == 0 {
+		return connection, nil
+	}
+	// Reading only the todos needed never reads past the page of the TodoService, so the cursor it
+	// returns is the position right after the last todo
+	todos, next, err := service.ListTodos(ctx, position.Skip+first, position.Next)
+	if err != nil {
+		return Connection{}, err
+	}
+	for i := position.Skip; i < len(todos); i++ {
+		edgeCursor := cursor{Next: position.Next, Skip: i + 1}
+		if i == len(todos)-1 && next != "" {
+			edgeCursor = cursor{Next: next}
+		}
+		connection.add(todos[i], edgeCursor)
+	}
+	connection.PageInfo.HasNextPage = next != ""
+	return connection, nil
+}
+
+// listSortedTodos reads every todo to filter and sort them, there's no index to do so in storage
+func listSortedTodos(ctx context.Context, service todo.TodoService, first int, position cursor, filter Filter, order Order) (Connection, error) {
+	todos := []types.Todo{}
+	next := ""
+	for {
+		page, pageNext, err := service.ListTodos(ctx, listPageSize, next)
+		if err != nil {
+			return Connection{}, err
+		}
+		for _, t := range page {
+			if filter.match(t) {
+				todos = append(todos, t)
+			}
+		}
+		if pageNext == "" {
+			break
+		}
+		next = pageNext
+	}
+	slices.SortFunc(todos, order.compare)
+
+	connection := Connection{Edges: []Edge{}, Nodes: []types.Todo{}}
+	end := min(position.Offset+first, len(todos))
+	for i := position.Offset; i < end; i++ {
+		connection.add(todos[i], cursor{Sorted: true, Offset: i + 1})
+	}
+	connection.PageInfo.HasNextPage = end < len(todos)
+	return connection, nil
+}
+
+func (c *Connection) add(t types.Todo, position cursor) {
+	edge := Edge{Cursor: position.encode(), Node: t}
+	c.Edges = append(c.Edges, edge)
+	c.Nodes = append(c.Nodes, t)
+	c.PageInfo.EndCursor = edge.Cursor
+}
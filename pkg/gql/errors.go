@@ -0,0 +1,61 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+)
+
+// Error codes reported in the extensions of GraphQL errors, following the codes used by most
+// GraphQL servers
+const (
+	CodeBadUserInput        = "BAD_USER_INPUT"
+	CodeNotFound            = "NOT_FOUND"
+	CodeQueryTooComplex     = "QUERY_TOO_COMPLEX"
+	CodeTimeout             = "TIMEOUT"
+	CodeInternalServerError = "INTERNAL_SERVER_ERROR"
+)
+
+// Error is an error reported to GraphQL clients along with a code telling what kind of error it is
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions implements gqlerrors.ExtendedError
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code}
+}
+
+func badUserInput(message string) *Error {
+	return &Error{Code: CodeBadUserInput, Message: message}
+}
+
+// toError maps the errors returned by the TodoService to errors safe to report to clients, like the
+// error handler of the REST routes internal errors are logged rather than reported
+func toError(ctx context.Context, err error) error {
+	var gqlErr *Error
+	var badRequest *serviceErrors.BadRequest
+	switch {
+	case errors.As(err, &gqlErr):
+		return gqlErr
+	case errors.As(err, &badRequest):
+		return badUserInput(badRequest.Message)
+	case errors.Is(err, storage.ErrNotFound):
+		return &Error{Code: CodeNotFound, Message: "Todo not found"}
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return &Error{Code: CodeTimeout, Message: "the request timed out"}
+	case errors.Is(err, context.Canceled):
+		return err
+	default:
+		slog.Error("graphql request failed", slog.Any("error", err))
+		return &Error{Code: CodeInternalServerError, Message: "internal server error"}
+	}
+}
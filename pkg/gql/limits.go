@@ -0,0 +1,141 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound the cost of a single GraphQL operation, a limit <= 0 disables it except for
+// MaxPageSize which then defaults to DefaultLimits.MaxPageSize
+type Limits struct {
+	// MaxDepth is the maximum nesting of fields, top level fields have a depth of 1
+	MaxDepth int
+	// MaxComplexity is the maximum number of fields an operation may resolve, the fields selected
+	// under a list field count once for every item it may return (e.g. its first argument)
+	MaxComplexity int
+	// MaxPageSize is the largest number of todos a page may hold, service.maxLimit of the REST API
+	MaxPageSize int
+}
+
+// DefaultLimits fit a page of MaxPageSize todos with all their fields
+var DefaultLimits = Limits{MaxDepth: 8, MaxComplexity: 2000, MaxPageSize: 100}
+
+// Check reports an error when the operation of doc named operationName, or any of its operations
+// when operationName is empty, exceeds the limits. It runs before the document is validated so
+// unknown fragments are ignored and fragment cycles are cut short, validation reports both.
+func (l Limits) Check(doc *ast.Document, operationName string, variables map[string]interface{}) *Error {
+	operations := []*ast.OperationDefinition{}
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operations = append(operations, d)
+			}
+		case *ast.FragmentDefinition:
+			fragments[d.Name.Value] = d
+		}
+	}
+
+	m := measure{fragments: fragments, variables: variables, visiting: map[string]bool{}}
+	for _, operation := range operations {
+		depth, complexity := m.selectionSet(operation.SelectionSet)
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return &Error{Code: CodeQueryTooComplex, Message: fmt.Sprintf("the query has a depth of %d which exceeds the maximum of %d", depth, l.MaxDepth)}
+		}
+		if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+			return &Error{Code: CodeQueryTooComplex, Message: fmt.Sprintf("the query has a complexity of %d which exceeds the maximum of %d", complexity, l.MaxComplexity)}
+		}
+	}
+	return nil
+}
+
+type measure struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+// selectionSet returns the depth and the complexity of set
+func (m measure) selectionSet(set *ast.SelectionSet) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	depth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		d, c := 0, 0
+		switch s := selection.(type) {
+		case *ast.Field:
+			d, c = m.selectionSet(s.SelectionSet)
+			d, c = d+1, saturatingAdd(1, saturatingMul(m.multiplier(s), c))
+		case *ast.InlineFragment:
+			d, c = m.selectionSet(s.SelectionSet)
+		case *ast.FragmentSpread:
+			fragment, ok := m.fragments[s.Name.Value]
+			if !ok || m.visiting[s.Name.Value] {
+				continue
+			}
+			m.visiting[s.Name.Value] = true
+			d, c = m.selectionSet(fragment.SelectionSet)
+			delete(m.visiting, s.Name.Value)
+		}
+		depth = max(depth, d)
+		complexity = saturatingAdd(complexity, c)
+	}
+	return depth, complexity
+}
+
+// multiplier returns the number of items field may return, the value of its first argument or 1
+// for fields that don't have one
+func (m measure) multiplier(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch v := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			if n, ok := intValue(m.variables[v.Name.Value]); ok && n > 0 {
+				return n
+			}
+		}
+		return defaultPageSize
+	}
+	return 1
+}
+
+// intValue converts a variable value, decoded from JSON, to an int
+func intValue(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+// saturatingAdd and saturatingMul keep the complexity of absurd queries from overflowing, both
+// expect non negative operands
+func saturatingAdd(a int, b int) int {
+	if a > maxInt-b {
+		return maxInt
+	}
+	return a + b
+}
+
+func saturatingMul(a int, b int) int {
+	if b != 0 && a > maxInt/b {
+		return maxInt
+	}
+	return a * b
+}
+
+const maxInt = int(^uint(0) >> 1)
@@ -0,0 +1,53 @@
+package gql
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestLimitsCheck(t *testing.T) {
+	limits := Limits{MaxDepth: 3, MaxComplexity: 50}
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		wantErr   bool
+	}{
+		{name: "within limits", query: `{ todos(first: 5) { nodes { id summary } } }`},
+		{name: "too deep", query: `{ todos { edges { node { id } } } }`, wantErr: true},
+		{name: "lists multiply the complexity", query: `{ todos(first: 30) { nodes { id } } }`, wantErr: true},
+		{name: "first defaults to the page size", query: `{ todos { nodes { id summary done priority } } }`},
+		{name: "first from a variable", query: `query($n: Int) { todos(first: $n) { nodes { id } } }`, variables: map[string]interface{}{"n": float64(40)}, wantErr: true},
+		{name: "fragments count", query: `{ todos(first: 10) { nodes { ...f } } } fragment f on Todo { id summary done priority due }`, wantErr: true},
+		{name: "fragment cycles are cut short", query: `{ todo(id: "1") { ...a } } fragment a on Todo { id ...b } fragment b on Todo { ...a }`},
+		{name: "every operation is checked", query: `query a { todo(id: "1") { id } } query b { todos(first: 90) { nodes { id } } }`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			checkErr := limits.Check(doc, "", tt.variables)
+			if tt.wantErr && (checkErr == nil || checkErr.Code != CodeQueryTooComplex) {
+				t.Errorf("Check() error = %v, want a %s error", checkErr, CodeQueryTooComplex)
+			}
+			if !tt.wantErr && checkErr != nil {
+				t.Errorf("Check() error = %v, want none", checkErr)
+			}
+		})
+	}
+}
+
+func TestLimitsCheckSaturates(t *testing.T) {
+	query := `{ todos(first: 1000000000) { edges { node { id } } } a: todos(first: 1000000000) { edges { cursor } } }`
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if checkErr := (Limits{MaxComplexity: 1000}).Check(doc, "", nil); checkErr == nil {
+		t.Error("Check() of a huge query succeeded, want an error")
+	}
+}
@@ -0,0 +1,63 @@
+package gql
+
+import (
+	"context"
+	"time"
+
+	"github.com/graph-gophers/dataloader"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/types"
+)
+
+// loaderWait is how long a loader waits for more keys before reading a batch. GraphQL resolves the
+// fields of a level before waiting on any of them so the keys of a level arrive almost at once.
+const loaderWait = 2 * time.Millisecond
+
+type loaderKey struct{}
+
+// withLoader returns a context holding a todo loader, it batches the todos read while resolving a
+// single operation into one TodoService call and caches them for the rest of the operation
+func withLoader(ctx context.Context, service todo.TodoService) context.Context {
+	batch := func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		results := make([]*dataloader.Result, len(keys))
+		todos, err := service.GetTodos(ctx, keys.Keys())
+		if err != nil {
+			for i := range results {
+				results[i] = &dataloader.Result{Error: err}
+			}
+			return results
+		}
+
+		byId := make(map[string]types.Todo, len(todos))
+		for _, t := range todos {
+			byId[t.Id] = t
+		}
+		for i, key := range keys {
+			// Todos that don't exist resolve to null rather than to an error
+			result := &dataloader.Result{}
+			if t, ok := byId[key.String()]; ok {
+				result.Data = t
+			}
+			results[i] = result
+		}
+		return results
+	}
+	return context.WithValue(ctx, loaderKey{}, dataloader.NewBatchedLoader(batch, dataloader.WithWait(loaderWait)))
+}
+
+// loadTodo returns a thunk resolving to the todo with the given id, or to nil when it doesn't exist
+func loadTodo(ctx context.Context, id string) func() (interface{}, error) {
+	thunk := loader(ctx).Load(ctx, dataloader.StringKey(id))
+	return func() (interface{}, error) {
+		t, err := thunk()
+		if err != nil {
+			return nil, toError(ctx, err)
+		}
+		return t, nil
+	}
+}
+
+func loader(ctx context.Context) *dataloader.Loader {
+	return ctx.Value(loaderKey{}).(*dataloader.Loader)
+}
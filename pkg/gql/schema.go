@@ -0,0 +1,440 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/types"
+)
+
+// errWatcherBehind ends the subscriptions that fell too far behind the changes made to todos
+var errWatcherBehind = errors.New("the subscription fell too far behind and missed events, query the todos again before subscribing")
+
+var todoType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Todo",
+	Description: "A Todo item",
+	Fields: graphql.Fields{
+		"id":              &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Description: "The Todo's identifier"},
+		"summary":         &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "The Todo's summary"},
+		"done":            &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Description: "An indicator that tells if the Todo item is complete, derived from its status"},
+		"status":          &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "The Todo's status in the workflow configured by the service"},
+		"due":             &graphql.Field{Type: graphql.DateTime, Description: "The time the Todo is due"},
+		"priority":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "The Todo's priority from 1 (highest) to 9 (lowest), 0 means undefined"},
+		"assignees":       &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Description: "The users the Todo is assigned to", Resolve: resolveAssignees},
+		"estimateSeconds": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "How long the Todo is expected to take in seconds, 0 means undefined"},
+		"completedAt":     &graphql.Field{Type: graphql.DateTime, Description: "The time the Todo was marked as done"},
+		"createdAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Description: "The time the Todo was created"},
+		"updatedAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Description: "The time the Todo was last changed"},
+	},
+})
+
+var edgeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TodoEdge",
+	Fields: graphql.Fields{
+		"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "The position of the todo, use it as the after argument to get the todos that follow"},
+		"node":   &graphql.Field{Type: graphql.NewNonNull(todoType)},
+	},
+})
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"endCursor":   &graphql.Field{Type: graphql.String, Description: "The cursor of the last todo of the page"},
+	},
+})
+
+var connectionType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "TodoConnection",
+	Description: "A page of todos",
+	Fields: graphql.Fields{
+		"edges":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+		"nodes":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoType)))},
+		"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+	},
+})
+
+var filterType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "TodoFilter",
+	Description: "Narrows down the todos, a todo has to match every field that is set",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"done":            &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+		"summaryContains": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Case insensitive text the summary contains"},
+		"dueBefore":       &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"dueAfter":        &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+	},
+})
+
+var orderFieldType = graphql.NewEnum(graphql.EnumConfig{
+	Name: "TodoOrderField",
+	Values: graphql.EnumValueConfigMap{
+		OrderById:        &graphql.EnumValueConfig{Value: OrderById},
+		OrderByCreatedAt: &graphql.EnumValueConfig{Value: OrderByCreatedAt},
+		OrderByUpdatedAt: &graphql.EnumValueConfig{Value: OrderByUpdatedAt},
+		OrderByDue:       &graphql.EnumValueConfig{Value: OrderByDue, Description: "Todos without a due date come last"},
+		OrderByPriority:  &graphql.EnumValueConfig{Value: OrderByPriority},
+		OrderBySummary:   &graphql.EnumValueConfig{Value: OrderBySummary},
+	},
+})
+
+var orderDirectionType = graphql.NewEnum(graphql.EnumConfig{
+	Name: "OrderDirection",
+	Values: graphql.EnumValueConfigMap{
+		"ASC":  &graphql.EnumValueConfig{Value: false},
+		"DESC": &graphql.EnumValueConfig{Value: true},
+	},
+})
+
+var orderType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "TodoOrder",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"field":     &graphql.InputObjectFieldConfig{Type: orderFieldType, DefaultValue: OrderById},
+		"direction": &graphql.InputObjectFieldConfig{Type: orderDirectionType, DefaultValue: false},
+	},
+})
+
+var createInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateTodoInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"summary":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"done":     &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false},
+		"due":      &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"priority": &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 0},
+	},
+})
+
+var replaceInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "ReplaceTodoInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"summary":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"done":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Boolean)},
+		"due":      &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"priority": &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 0},
+	},
+})
+
+var updateInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "UpdateTodoInput",
+	Description: "The fields to change, fields that aren't set keep their value. Use replaceTodo to clear the due date.",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"summary":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"done":            &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+		"status":          &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Moves the todo to a status its current status can move to, it sets done when both are set"},
+		"due":             &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"priority":        &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"estimateSeconds": &graphql.InputObjectFieldConfig{Type: graphql.Int},
+	},
+})
+
+var eventTypeType = graphql.NewEnum(graphql.EnumConfig{
+	Name: "TodoEventType",
+	Values: graphql.EnumValueConfigMap{
+		"CREATED":  &graphql.EnumValueConfig{Value: todo.EventCreated},
+		"UPDATED":  &graphql.EnumValueConfig{Value: todo.EventUpdated},
+		"DELETED":  &graphql.EnumValueConfig{Value: todo.EventDeleted},
+		"ASSIGNED": &graphql.EnumValueConfig{Value: todo.EventAssigned, Description: "Follows the change of a todo whose assignees changed"},
+	},
+})
+
+var eventType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "TodoEvent",
+	Description: "A change made to a todo, only the id of deleted todos is set",
+	Fields: graphql.Fields{
+		"type": &graphql.Field{
+			Type: graphql.NewNonNull(eventTypeType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(todo.TodoEvent).Type, nil
+			},
+		},
+		"todo": &graphql.Field{
+			Type: graphql.NewNonNull(todoType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(todo.TodoEvent).Todo, nil
+			},
+		},
+		"assigned": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			Description: "The users an ASSIGNED event assigned the todo to",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return nonNil(p.Source.(todo.TodoEvent).Assigned), nil
+			},
+		},
+		"unassigned": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			Description: "The users an ASSIGNED event unassigned from the todo",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return nonNil(p.Source.(todo.TodoEvent).Unassigned), nil
+			},
+		},
+	},
+})
+
+// resolveAssignees returns the assignees of a todo, an empty list rather than null when there are none
+func resolveAssignees(p graphql.ResolveParams) (interface{}, error) {
+	return nonNil(p.Source.(types.Todo).Assignees), nil
+}
+
+func nonNil(users []string) []string {
+	if users == nil {
+		return []string{}
+	}
+	return users
+}
+
+// newSchema returns the GraphQL schema of the todo API, it resolves fields using service and
+// returns pages of at most maxPageSize todos
+func newSchema(service todo.TodoService, maxPageSize int) (graphql.Schema, error) {
+	r := resolvers{service: service, maxPageSize: maxPageSize}
+	idArgs := graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"todo": &graphql.Field{
+				Type:        todoType,
+				Description: "Returns the todo with the given id, or null when it doesn't exist",
+				Args:        idArgs,
+				Resolve:     r.todo,
+			},
+			"todos": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "Returns a page of todos, pass the endCursor of a page as after to get the next one",
+				Args: graphql.FieldConfigArgument{
+					"first":   &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize, Description: fmt.Sprintf("The number of todos to return, at most %d", maxPageSize)},
+					"after":   &graphql.ArgumentConfig{Type: graphql.String},
+					"filter":  &graphql.ArgumentConfig{Type: filterType},
+					"orderBy": &graphql.ArgumentConfig{Type: orderType},
+				},
+				Resolve: r.todos,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTodo": &graphql.Field{
+				Type:    graphql.NewNonNull(todoType),
+				Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createInputType)}},
+				Resolve: r.createTodo,
+			},
+			"replaceTodo": &graphql.Field{
+				Type: graphql.NewNonNull(todoType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(replaceInputType)},
+				},
+				Resolve: r.replaceTodo,
+			},
+			"updateTodo": &graphql.Field{
+				Type: graphql.NewNonNull(todoType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateInputType)},
+				},
+				Resolve: r.updateTodo,
+			},
+			"deleteTodo": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Deletes a todo and returns its id",
+				Args:        idArgs,
+				Resolve:     r.deleteTodo,
+			},
+		},
+	})
+
+	subscription := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"todoChanged": &graphql.Field{
+				Type:        graphql.NewNonNull(eventType),
+				Description: "Streams the changes made to todos from the moment the subscription starts",
+				Subscribe:   r.watchTodos,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err, ok := p.Source.(error); ok {
+						return nil, err
+					}
+					return p.Source, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation, Subscription: subscription})
+}
+
+type resolvers struct {
+	service     todo.TodoService
+	maxPageSize int
+}
+
+func (r resolvers) todo(p graphql.ResolveParams) (interface{}, error) {
+	return loadTodo(p.Context, p.Args["id"].(string)), nil
+}
+
+func (r resolvers) todos(p graphql.ResolveParams) (interface{}, error) {
+	after, _ := p.Args["after"].(string)
+
+	filter := Filter{}
+	if f, ok := p.Args["filter"].(map[string]interface{}); ok {
+		if done, ok := f["done"].(bool); ok {
+			filter.Done = &done
+		}
+		filter.SummaryContains, _ = f["summaryContains"].(string)
+		filter.DueBefore = timeArg(f, "dueBefore")
+		filter.DueAfter = timeArg(f, "dueAfter")
+	}
+
+	order := Order{Field: OrderById}
+	if o, ok := p.Args["orderBy"].(map[string]interface{}); ok {
+		order.Field, _ = o["field"].(string)
+		order.Descending, _ = o["direction"].(bool)
+	}
+
+	connection, err := listTodos(p.Context, r.service, p.Args["first"].(int), r.maxPageSize, after, filter, order)
+	if err != nil {
+		return nil, toError(p.Context, err)
+	}
+	return connection, nil
+}
+
+func (r resolvers) createTodo(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	update := types.TodoUpdate{
+		Summary:  input["summary"].(string),
+		Done:     input["done"].(bool),
+		Due:      timeArg(input, "due"),
+		Priority: input["priority"].(int),
+	}
+	if err := validatePriority(update.Priority); err != nil {
+		return nil, err
+	}
+
+	created, err := r.service.CreateTodo(p.Context, update)
+	if err != nil {
+		return nil, toError(p.Context, err)
+	}
+	return created, nil
+}
+
+func (r resolvers) replaceTodo(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Args["id"].(string)
+	input := p.Args["input"].(map[string]interface{})
+
+	current, err := r.service.GetTodo(p.Context, id)
+	if err != nil {
+		return nil, toError(p.Context, err)
+	}
+	current.Summary = input["summary"].(string)
+	current.Done = input["done"].(bool)
+	current.Due = timeArg(input, "due")
+	current.Priority = input["priority"].(int)
+	return r.update(p.Context, current)
+}
+
+func (r resolvers) updateTodo(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Args["id"].(string)
+	input := p.Args["input"].(map[string]interface{})
+
+	current, err := r.service.GetTodo(p.Context, id)
+	if err != nil {
+		return nil, toError(p.Context, err)
+	}
+	if summary, ok := input["summary"].(string); ok {
+		current.Summary = summary
+	}
+	if done, ok := input["done"].(bool); ok {
+		current.Done = done
+	}
+	if status, ok := input["status"].(string); ok {
+		current.Status = status
+	}
+	if due := timeArg(input, "due"); due != nil {
+		current.Due = due
+	}
+	if priority, ok := input["priority"].(int); ok {
+		current.Priority = priority
+	}
+	if estimate, ok := input["estimateSeconds"].(int); ok {
+		if estimate < 0 {
+			return nil, badUserInput("estimateSeconds can't be negative")
+		}
+		current.EstimateSeconds = int64(estimate)
+	}
+	return r.update(p.Context, current)
+}
+
+// update stores t and returns it as stored
+func (r resolvers) update(ctx context.Context, t types.Todo) (interface{}, error) {
+	if err := validatePriority(t.Priority); err != nil {
+		return nil, err
+	}
+	if err := r.service.UpdateTodo(ctx, t); err != nil {
+		return nil, toError(ctx, err)
+	}
+
+	updated, err := r.service.GetTodo(ctx, t.Id)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	return updated, nil
+}
+
+func (r resolvers) deleteTodo(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Args["id"].(string)
+	if err := r.service.DeleteTodo(p.Context, id); err != nil {
+		return nil, toError(p.Context, err)
+	}
+	return id, nil
+}
+
+// watchTodos forwards the changes made to todos to the subscription until its context is done
+func (r resolvers) watchTodos(p graphql.ResolveParams) (interface{}, error) {
+	events := r.service.WatchTodos(p.Context)
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		for {
+			var payload interface{}
+			event, ok := <-events
+			switch {
+			case ok:
+				payload = event
+			case p.Context.Err() != nil:
+				return
+			default:
+				payload = errWatcherBehind
+			}
+
+			select {
+			case out <- payload:
+			case <-p.Context.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// validatePriority applies the constraint the REST API enforces with its JSON schema
+func validatePriority(priority int) error {
+	if priority < 0 || priority > 9 {
+		return badUserInput("priority must be between 0 and 9")
+	}
+	return nil
+}
+
+func timeArg(args map[string]interface{}, name string) *time.Time {
+	if t, ok := args[name].(time.Time); ok {
+		return &t
+	}
+	return nil
+}
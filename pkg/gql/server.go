@@ -0,0 +1,144 @@
+// Package gql implements the GraphQL API, a transport over the same TodoService as the REST routes
+package gql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"todo-service/pkg/features/todo"
+)
+
+// Request is a GraphQL request as sent over HTTP
+//
+// @openapi
+// components:
+//
+//	schemas:
+//	  GraphQLRequest:
+//	    type: object
+//	    properties:
+//	      query:
+//	        type: string
+//	        description: The GraphQL document
+//	        example: '{ todos(first: 5) { nodes { id summary done } pageInfo { hasNextPage endCursor } } }'
+//	      operationName:
+//	        type: string
+//	        description: The operation of the document to run, required when it has several
+//	      variables:
+//	        type: object
+//	        description: The values of the variables of the operation
+//	    required:
+//	      - query
+//	  GraphQLResponse:
+//	    type: object
+//	    properties:
+//	      data:
+//	        type: object
+//	        description: The result of the operation
+//	      errors:
+//	        type: array
+//	        items:
+//	          type: object
+//	          properties:
+//	            message:
+//	              type: string
+//	            path:
+//	              type: array
+//	              items: {}
+//	            extensions:
+//	              type: object
+//	              properties:
+//	                code:
+//	                  type: string
+//	                  example: NOT_FOUND
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Operation is a parsed and validated request, ready to be executed
+type Operation struct {
+	request  Request
+	document *ast.Document
+	// Type is the type of the operation: query, mutation or subscription
+	Type string
+}
+
+type Server struct {
+	schema  graphql.Schema
+	service todo.TodoService
+	limits  Limits
+}
+
+// NewServer returns a Server resolving operations with service. It panics if the schema is invalid,
+// which can only be caused by a programming error.
+func NewServer(service todo.TodoService, limits Limits) *Server {
+	if limits.MaxPageSize <= 0 {
+		limits.MaxPageSize = DefaultLimits.MaxPageSize
+	}
+	schema, err := newSchema(service, limits.MaxPageSize)
+	if err != nil {
+		panic(fmt.Sprintf("invalid GraphQL schema: %v", err))
+	}
+	return &Server{schema: schema, service: service, limits: limits}
+}
+
+// Parse parses and validates a request, the returned errors are meant to be reported to the client
+func (s *Server) Parse(req Request) (*Operation, []gqlerrors.FormattedError) {
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+	// The limits are checked first as validating an expensive query is itself expensive
+	if err := s.limits.Check(document, req.OperationName, req.Variables); err != nil {
+		return nil, []gqlerrors.FormattedError{formatError(err)}
+	}
+	if result := graphql.ValidateDocument(&s.schema, document, nil); !result.IsValid {
+		return nil, result.Errors
+	}
+
+	operation := &Operation{request: req, document: document}
+	for _, definition := range document.Definitions {
+		if d, ok := definition.(*ast.OperationDefinition); ok && (req.OperationName == "" || (d.Name != nil && d.Name.Value == req.OperationName)) {
+			operation.Type = d.Operation
+			return operation, nil
+		}
+	}
+	return nil, []gqlerrors.FormattedError{formatError(badUserInput(fmt.Sprintf("unknown operation %q", req.OperationName)))}
+}
+
+// formatError formats errors raised outside of resolvers, which graphql-go formats without their
+// extensions
+func formatError(err *Error) gqlerrors.FormattedError {
+	formatted := gqlerrors.FormatError(err)
+	formatted.Extensions = err.Extensions()
+	return formatted
+}
+
+// Execute runs a query or a mutation
+func (s *Server) Execute(ctx context.Context, operation *Operation) *graphql.Result {
+	return graphql.Execute(s.params(ctx, operation))
+}
+
+// Subscribe runs a subscription, the returned channel receives a result per event and is closed
+// once ctx is done or the subscription ends
+func (s *Server) Subscribe(ctx context.Context, operation *Operation) <-chan *graphql.Result {
+	return graphql.ExecuteSubscription(s.params(ctx, operation))
+}
+
+func (s *Server) params(ctx context.Context, operation *Operation) graphql.ExecuteParams {
+	return graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           operation.document,
+		OperationName: operation.request.OperationName,
+		Args:          operation.request.Variables,
+		Context:       withLoader(ctx, s.service),
+	}
+}
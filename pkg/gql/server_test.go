@@ -0,0 +1,365 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/types"
+)
+
+var now = time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+const (
+	firstId  = "00000000-0000-7000-8000-000000000001"
+	secondId = "00000000-0000-7000-8000-000000000002"
+)
+
+// countingService counts the calls made to GetTodos
+type countingService struct {
+	todo.TodoService
+	getTodos atomic.Int32
+}
+
+func (s *countingService) GetTodos(ctx context.Context, ids []string) ([]types.Todo, error) {
+	s.getTodos.Add(1)
+	return s.TodoService.GetTodos(ctx, ids)
+}
+
+// newTestServer returns a server over a service holding count todos with ascending ids, summaries
+// and due dates
+func newTestServer(t *testing.T, count int) (*Server, *countingService) {
+	t.Helper()
+	service, err := todo.NewTodoService(todo.TodoServiceProps{
+		Storage:     fakes.NewStorage(),
+		Clock:       fakes.NewClock(now),
+		IdGenerator: &fakes.IdGenerator{},
+	})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+	for i := 0; i < count; i++ {
+		due := now.Add(time.Duration(count-i) * time.Hour)
+		update := types.TodoUpdate{Summary: fmt.Sprintf("todo %d", i+1), Done: i%2 == 1, Due: &due, Priority: i % 10}
+		if _, err := service.CreateTodo(context.Background(), update); err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+	}
+	counting := &countingService{TodoService: service}
+	return NewServer(counting, DefaultLimits), counting
+}
+
+// do runs query and decodes its data into dest, it fails the test on errors
+func do(t *testing.T, server *Server, query string, variables map[string]interface{}, dest any) {
+	t.Helper()
+	result := run(t, server, query, variables)
+	if result.HasErrors() {
+		t.Fatalf("%s errors = %v", query, result.Errors)
+	}
+	b, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := json.Unmarshal(b, dest); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+}
+
+func run(t *testing.T, server *Server, query string, variables map[string]interface{}) *graphql.Result {
+	t.Helper()
+	operation, errs := server.Parse(Request{Query: query, Variables: variables})
+	if errs != nil {
+		return &graphql.Result{Errors: errs}
+	}
+	return server.Execute(context.Background(), operation)
+}
+
+func errorCode(result *graphql.Result) string {
+	if len(result.Errors) == 0 {
+		return ""
+	}
+	code, _ := result.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func TestTodoQueryBatchesReads(t *testing.T) {
+	server, service := newTestServer(t, 2)
+
+	var data map[string]*types.Todo
+	do(t, server, fmt.Sprintf(`{ a: todo(id: %q) { id summary } b: todo(id: %q) { id } missing: todo(id: "missing") { id } }`, firstId, secondId), nil, &data)
+
+	if data["a"] == nil || data["a"].Summary != "todo 1" || data["b"] == nil || data["b"].Id != secondId || data["missing"] != nil {
+		t.Errorf("data = %+v, want both todos and null for the missing one", data)
+	}
+	if calls := service.getTodos.Load(); calls != 1 {
+		t.Errorf("GetTodos() was called %d times, want 1", calls)
+	}
+}
+
+type connection struct {
+	Todos struct {
+		Edges []struct {
+			Cursor string `json:"cursor"`
+		} `json:"edges"`
+		Nodes    []types.Todo `json:"nodes"`
+		PageInfo PageInfo     `json:"pageInfo"`
+	} `json:"todos"`
+}
+
+func (c connection) ids() []string {
+	ids := []string{}
+	for _, t := range c.Todos.Nodes {
+		ids = append(ids, t.Id)
+	}
+	return ids
+}
+
+const todosQuery = `query($first: Int, $after: String, $filter: TodoFilter, $orderBy: TodoOrder) {
+	todos(first: $first, after: $after, filter: $filter, orderBy: $orderBy) {
+		edges { cursor }
+		nodes { id summary done due priority }
+		pageInfo { hasNextPage endCursor }
+	}
+}`
+
+// pages lists every todo a page at a time and returns the pages of ids
+func pages(t *testing.T, server *Server, variables map[string]interface{}) [][]string {
+	t.Helper()
+	result := [][]string{}
+	for {
+		var data connection
+		do(t, server, todosQuery, variables, &data)
+		result = append(result, data.ids())
+		if !data.Todos.PageInfo.HasNextPage {
+			return result
+		}
+		if len(result) > 10 {
+			t.Fatal("too many pages")
+		}
+		variables["after"] = data.Todos.PageInfo.EndCursor
+	}
+}
+
+func TestTodosQueryPagination(t *testing.T) {
+	server, _ := newTestServer(t, 5)
+	id := func(n int) string { return fmt.Sprintf("00000000-0000-7000-8000-%012d", n) }
+
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		want      [][]string
+	}{
+		{
+			name:      "default order",
+			variables: map[string]interface{}{"first": 2},
+			want:      [][]string{{id(1), id(2)}, {id(3), id(4)}, {id(5)}},
+		},
+		{
+			name:      "filtered",
+			variables: map[string]interface{}{"first": 1, "filter": map[string]interface{}{"done": false, "summaryContains": "TODO"}},
+			want:      [][]string{{id(1)}, {id(3)}, {id(5)}},
+		},
+		{
+			name:      "due before",
+			variables: map[string]interface{}{"filter": map[string]interface{}{"dueBefore": now.Add(3 * time.Hour).Format(time.RFC3339)}},
+			want:      [][]string{{id(4), id(5)}},
+		},
+		{
+			name:      "sorted",
+			variables: map[string]interface{}{"first": 3, "orderBy": map[string]interface{}{"field": "DUE"}},
+			want:      [][]string{{id(5), id(4), id(3)}, {id(2), id(1)}},
+		},
+		{
+			name:      "sorted descending",
+			variables: map[string]interface{}{"first": 4, "orderBy": map[string]interface{}{"field": "PRIORITY", "direction": "DESC"}},
+			want:      [][]string{{id(5), id(4), id(3), id(2)}, {id(1)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pages(t, server, tt.variables)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTodosQueryEdgeCursors(t *testing.T) {
+	server, _ := newTestServer(t, 5)
+
+	var data connection
+	do(t, server, todosQuery, map[string]interface{}{"first": 3}, &data)
+	if len(data.Todos.Edges) != 3 {
+		t.Fatalf("edges = %+v, want 3", data.Todos.Edges)
+	}
+
+	// Resuming from any edge returns the todos that follow it
+	do(t, server, todosQuery, map[string]interface{}{"first": 2, "after": data.Todos.Edges[0].Cursor}, &data)
+	if got := data.ids(); fmt.Sprint(got) != fmt.Sprint([]string{secondId, "00000000-0000-7000-8000-000000000003"}) {
+		t.Errorf("todos after the first edge = %v, want the second and the third", got)
+	}
+}
+
+func TestTodosQueryErrors(t *testing.T) {
+	server, _ := newTestServer(t, 2)
+
+	sorted := run(t, server, todosQuery, map[string]interface{}{"first": 1, "orderBy": map[string]interface{}{"field": "DUE"}})
+	b, _ := json.Marshal(sorted.Data)
+	var data connection
+	_ = json.Unmarshal(b, &data)
+
+	tests := map[string]map[string]interface{}{
+		"first too large":      {"first": DefaultLimits.MaxPageSize + 1},
+		"invalid cursor":       {"after": "not a cursor"},
+		"cursor mismatched":    {"after": data.Todos.PageInfo.EndCursor},
+		"cursor skips too far": {"after": cursor{Skip: 1000000000}.encode()},
+	}
+	for name, variables := range tests {
+		t.Run(name, func(t *testing.T) {
+			if code := errorCode(run(t, server, todosQuery, variables)); code != CodeBadUserInput {
+				t.Errorf("error code = %q, want %q", code, CodeBadUserInput)
+			}
+		})
+	}
+}
+
+func TestTodosQueryMaxPageSize(t *testing.T) {
+	_, service := newTestServer(t, 3)
+	server := NewServer(service, Limits{MaxPageSize: 2})
+
+	var data connection
+	do(t, server, todosQuery, map[string]interface{}{"first": 2}, &data)
+	if len(data.Todos.Nodes) != 2 {
+		t.Errorf("todos(first: 2) = %d todos, want 2", len(data.Todos.Nodes))
+	}
+	if code := errorCode(run(t, server, todosQuery, map[string]interface{}{"first": 3})); code != CodeBadUserInput {
+		t.Errorf("todos(first: 3) error code = %q, want %q", code, CodeBadUserInput)
+	}
+}
+
+func TestMutations(t *testing.T) {
+	server, _ := newTestServer(t, 0)
+
+	var created struct {
+		CreateTodo types.Todo `json:"createTodo"`
+	}
+	do(t, server, `mutation { createTodo(input: {summary: "Pick up the groceries", priority: 3, due: "2024-07-02T17:00:00Z"}) { id summary done due priority } }`, nil, &created)
+	if created.CreateTodo.Id != firstId || created.CreateTodo.Priority != 3 || created.CreateTodo.Due == nil {
+		t.Fatalf("createTodo = %+v", created.CreateTodo)
+	}
+
+	var updated struct {
+		UpdateTodo types.Todo `json:"updateTodo"`
+	}
+	do(t, server, `mutation($id: ID!) { updateTodo(id: $id, input: {done: true}) { id summary done due priority completedAt } }`, map[string]interface{}{"id": firstId}, &updated)
+	if got := updated.UpdateTodo; !got.Done || got.Summary != "Pick up the groceries" || got.Priority != 3 || got.Due == nil || got.CompletedAt == nil {
+		t.Errorf("updateTodo = %+v, want it done with its other fields unchanged", got)
+	}
+
+	var replaced struct {
+		ReplaceTodo types.Todo `json:"replaceTodo"`
+	}
+	do(t, server, `mutation($id: ID!) { replaceTodo(id: $id, input: {summary: "replaced", done: false}) { id summary done due priority } }`, map[string]interface{}{"id": firstId}, &replaced)
+	if got := replaced.ReplaceTodo; got.Summary != "replaced" || got.Done || got.Priority != 0 || got.Due != nil {
+		t.Errorf("replaceTodo = %+v, want every field replaced", got)
+	}
+
+	var deleted map[string]interface{}
+	do(t, server, fmt.Sprintf(`mutation { deleteTodo(id: %q) }`, firstId), nil, &deleted)
+	if deleted["deleteTodo"] != firstId {
+		t.Errorf("deleteTodo = %v, want %s", deleted["deleteTodo"], firstId)
+	}
+
+	if code := errorCode(run(t, server, fmt.Sprintf(`mutation { updateTodo(id: %q, input: {done: true}) { id } }`, firstId), nil)); code != CodeNotFound {
+		t.Errorf("updateTodo of a deleted todo error code = %q, want %q", code, CodeNotFound)
+	}
+	if code := errorCode(run(t, server, `mutation { createTodo(input: {summary: "todo", priority: 10}) { id } }`, nil)); code != CodeBadUserInput {
+		t.Errorf("createTodo with an invalid priority error code = %q, want %q", code, CodeBadUserInput)
+	}
+}
+
+func TestUpdateTodoRejectsTransitions(t *testing.T) {
+	// The second todo is done, which can't move to blocked in the default workflow
+	server, _ := newTestServer(t, 2)
+
+	for _, status := range []string{"blocked", "nope"} {
+		result := run(t, server, `mutation($id: ID!, $status: String) { updateTodo(id: $id, input: {status: $status}) { id } }`, map[string]interface{}{"id": secondId, "status": status})
+		if code := errorCode(result); code != CodeBadUserInput {
+			t.Errorf("updateTodo to %s error code = %q, want %q: %v", status, code, CodeBadUserInput, result.Errors)
+		}
+	}
+}
+
+func TestUpdateTodoRejectsBlockedTodos(t *testing.T) {
+	server, service := newTestServer(t, 3)
+	// The third todo is open like the first one it's blocked by
+	thirdId := "00000000-0000-7000-8000-000000000003"
+	if err := service.AddDependency(context.Background(), thirdId, firstId); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+
+	result := run(t, server, `mutation($id: ID!) { updateTodo(id: $id, input: {done: true}) { id } }`, map[string]interface{}{"id": thirdId})
+	if code := errorCode(result); code != CodeBadUserInput {
+		t.Errorf("updateTodo of a blocked todo error code = %q, want %q: %v", code, CodeBadUserInput, result.Errors)
+	}
+}
+
+func TestParseRejectsExpensiveQueries(t *testing.T) {
+	server, _ := newTestServer(t, 0)
+	_, errs := server.Parse(Request{Query: `{ todos(first: 100) { edges { node { id summary done due priority completedAt createdAt updatedAt } cursor } nodes { id summary done due priority completedAt createdAt updatedAt } } }`})
+	if len(errs) != 1 || errs[0].Extensions["code"] != CodeQueryTooComplex {
+		t.Errorf("Parse() errors = %v, want a %s error", errs, CodeQueryTooComplex)
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	server, service := newTestServer(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	operation, errs := server.Parse(Request{Query: `subscription { todoChanged { type todo { id summary } } }`})
+	if errs != nil {
+		t.Fatalf("Parse() errors = %v", errs)
+	}
+	results := server.Subscribe(ctx, operation)
+
+	// The subscription starts watching asynchronously, keep changing todos until an event arrives
+	deadline := time.After(5 * time.Second)
+	for {
+		if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo"}); err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+		select {
+		case result := <-results:
+			if result.HasErrors() {
+				t.Fatalf("subscription errors = %v", result.Errors)
+			}
+			var data struct {
+				TodoChanged struct {
+					Type string     `json:"type"`
+					Todo types.Todo `json:"todo"`
+				} `json:"todoChanged"`
+			}
+			b, _ := json.Marshal(result.Data)
+			if err := json.Unmarshal(b, &data); err != nil || data.TodoChanged.Type != "CREATED" || data.TodoChanged.Todo.Summary != "todo" {
+				t.Errorf("subscription result = %s, want a CREATED event", b)
+			}
+			cancel()
+			for range results {
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("timed out waiting for an event")
+		}
+	}
+}
@@ -0,0 +1,311 @@
+// Package ical reads and writes iCalendar (RFC 5545) data.
+//
+// It implements the content line format (line folding, property parameters and text escaping) and
+// the component structure, mapping components to domain types is left to the caller.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	ProductId = "-//tink3rlabs//todo-service//EN"
+
+	dateTimeFormat    = "20060102T150405Z"
+	localTimeFormat   = "20060102T150405"
+	dateFormat        = "20060102"
+	maxLineOctets     = 75
+	maxContentOctets  = 1024 * 1024
+	componentBegin    = "BEGIN"
+	componentEnd      = "END"
+	calendarComponent = "VCALENDAR"
+)
+
+// Property is a single content line, e.g. DUE;VALUE=DATE:20240701
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Text returns the unescaped value of a TEXT property
+func (p *Property) Text() string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(p.Value)
+}
+
+// Time returns the value of a DATE or DATE-TIME property. Times with a TZID parameter are read in
+// that time zone, floating times and dates are read as UTC.
+func (p *Property) Time() (time.Time, error) {
+	location := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.Trim(tzid, `"`)); err == nil {
+			location = l
+		}
+	}
+
+	value := strings.TrimSpace(p.Value)
+	for _, format := range []string{dateTimeFormat, localTimeFormat, dateFormat} {
+		if len(value) != len(format) {
+			continue
+		}
+		if t, err := time.ParseInLocation(format, value, location); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s value %q", p.Name, p.Value)
+}
+
+// Component is a calendar component such as VCALENDAR, VTODO or VEVENT
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// Get returns the first property named name, or nil
+func (c *Component) Get(name string) *Property {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// Add appends a property to the component
+func (c *Component) Add(p Property) {
+	c.Properties = append(c.Properties, p)
+}
+
+// TextProperty returns a TEXT property with value escaped
+func TextProperty(name string, value string) Property {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return Property{Name: name, Value: r.Replace(value)}
+}
+
+// TimeProperty returns a DATE-TIME property with t in UTC
+func TimeProperty(name string, t time.Time) Property {
+	return Property{Name: name, Value: t.UTC().Format(dateTimeFormat)}
+}
+
+// NewCalendar returns an empty VCALENDAR component
+func NewCalendar(name string) *Component {
+	c := &Component{Name: calendarComponent}
+	c.Add(Property{Name: "VERSION", Value: "2.0"})
+	c.Add(Property{Name: "PRODID", Value: ProductId})
+	c.Add(Property{Name: "CALSCALE", Value: "GREGORIAN"})
+	if name != "" {
+		c.Add(TextProperty("X-WR-CALNAME", name))
+	}
+	return c
+}
+
+// Writer writes content lines, folding lines longer than 75 octets as required by RFC 5545
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Begin starts a component, components must be ended with End
+func (w *Writer) Begin(name string) error {
+	return w.WriteProperty(Property{Name: componentBegin, Value: name})
+}
+
+func (w *Writer) End(name string) error {
+	return w.WriteProperty(Property{Name: componentEnd, Value: name})
+}
+
+// WriteComponent writes c and its sub components
+func (w *Writer) WriteComponent(c *Component) error {
+	w.Begin(c.Name)
+	w.WriteProperties(c)
+	for _, sub := range c.Components {
+		w.WriteComponent(sub)
+	}
+	return w.End(c.Name)
+}
+
+// WriteProperties writes the properties of c without the BEGIN and END lines, which is useful when
+// streaming the sub components of a calendar
+func (w *Writer) WriteProperties(c *Component) error {
+	for _, p := range c.Properties {
+		w.WriteProperty(p)
+	}
+	return w.err
+}
+
+func (w *Writer) WriteProperty(p Property) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	line := strings.Builder{}
+	line.WriteString(p.Name)
+	names := make([]string, 0, len(p.Params))
+	for name := range p.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := p.Params[name]
+		if strings.ContainsAny(value, `;:,`) {
+			value = `"` + value + `"`
+		}
+		fmt.Fprintf(&line, ";%s=%s", name, value)
+	}
+	line.WriteString(":")
+	line.WriteString(p.Value)
+
+	_, w.err = io.WriteString(w.w, fold(line.String()))
+	return w.err
+}
+
+// fold splits a content line into lines of at most 75 octets without breaking UTF-8 characters
+func fold(line string) string {
+	folded := strings.Builder{}
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		folded.WriteString(line[:cut])
+		folded.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space which counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	folded.WriteString(line)
+	folded.WriteString("\r\n")
+	return folded.String()
+}
+
+// Parse reads the components of an iCalendar stream, which usually hold a single VCALENDAR
+func Parse(r io.Reader) ([]*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []*Component{}
+	stack := []*Component{}
+	for i, line := range lines {
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		switch p.Name {
+		case componentBegin:
+			c := &Component{Name: strings.ToUpper(p.Value)}
+			if len(stack) == 0 {
+				roots = append(roots, c)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			}
+			stack = append(stack, c)
+		case componentEnd:
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property %s outside of a component", i+1, p.Name)
+			}
+			stack[len(stack)-1].Add(p)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("component %s is not closed", stack[len(stack)-1].Name)
+	}
+	if len(roots) == 0 {
+		return nil, errors.New("no calendar components found")
+	}
+	return roots, nil
+}
+
+// unfold joins folded lines, a line starting with a space or a tab continues the previous one
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxContentOctets)
+
+	lines := []string{}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (Property, error) {
+	p := Property{}
+
+	// the value starts at the first colon that isn't part of a quoted parameter value
+	quoted := false
+	valueStart := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		}
+		if c == ':' && !quoted {
+			valueStart = i
+			break
+		}
+	}
+	if valueStart < 0 {
+		return p, fmt.Errorf("invalid content line %q", line)
+	}
+
+	p.Value = line[valueStart+1:]
+	parts := splitParams(line[:valueStart])
+	p.Name = strings.ToUpper(parts[0])
+	if p.Name == "" {
+		return p, fmt.Errorf("invalid content line %q", line)
+	}
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		if p.Params == nil {
+			p.Params = map[string]string{}
+		}
+		p.Params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return p, nil
+}
+
+func splitParams(s string) []string {
+	parts := []string{}
+	quoted := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ';' && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,120 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestWriteFoldsLongLines(t *testing.T) {
+	summary := strings.Repeat("ab, ", 30) + strings.Repeat("é", 40)
+	c := &Component{Name: "VTODO"}
+	c.Add(TextProperty("SUMMARY", summary))
+
+	out := bytes.Buffer{}
+	if err := NewWriter(&out).WriteComponent(c); err != nil {
+		t.Fatalf("WriteComponent() error = %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %q is %d octets long, want at most %d", line, len(line), maxLineOctets)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %q splits a UTF-8 character", line)
+		}
+	}
+
+	components, err := Parse(&out)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := components[0].Get("SUMMARY").Text(); got != summary {
+		t.Errorf("SUMMARY = %q, want %q", got, summary)
+	}
+}
+
+func TestTextEscaping(t *testing.T) {
+	value := "a; b, c \\ d\nnext line"
+	p := TextProperty("SUMMARY", value)
+	if want := `a\; b\, c \\ d\nnext line`; p.Value != want {
+		t.Errorf("TextProperty() = %q, want %q", p.Value, want)
+	}
+	if got := p.Text(); got != value {
+		t.Errorf("Text() = %q, want %q", got, value)
+	}
+}
+
+func TestParse(t *testing.T) {
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTODO",
+		`X-NOTE;X-PARAM="a;b:c":value: with colons`,
+		"DESCRIPTION:folded ",
+		"\tdescription",
+		"due;tzid=America/New_York:20240701T090000",
+		"END:VTODO",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	components, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(components) != 1 || len(components[0].Components) != 1 {
+		t.Fatalf("Parse() = %+v, want a calendar with one component", components)
+	}
+
+	todo := components[0].Components[0]
+	note := todo.Get("X-NOTE")
+	if note == nil || note.Value != "value: with colons" || note.Params["X-PARAM"] != "a;b:c" {
+		t.Errorf("X-NOTE = %+v", note)
+	}
+	if got := todo.Get("DESCRIPTION").Text(); got != "folded description" {
+		t.Errorf("DESCRIPTION = %q, want %q", got, "folded description")
+	}
+
+	due, err := todo.Get("DUE").Time()
+	if want := time.Date(2024, time.July, 1, 13, 0, 0, 0, time.UTC); err != nil || !due.Equal(want) {
+		t.Errorf("DUE = %v, %v, want %v", due, err, want)
+	}
+}
+
+func TestPropertyTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "20240701T120000Z", want: time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)},
+		{value: "20240701T120000", want: time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)},
+		{value: "20240701", want: time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-07-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		p := Property{Name: "DUE", Value: tt.value}
+		got, err := p.Time()
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("Time(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty":            "",
+		"unclosed":         "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VTODO\r\n",
+		"mismatched end":   "BEGIN:VCALENDAR\r\nEND:VTODO\r\n",
+		"orphan property":  "SUMMARY:todo\r\n",
+		"not content line": "BEGIN:VCALENDAR\r\nnot a content line\r\nEND:VCALENDAR\r\n",
+	}
+	for name, input := range tests {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want an error", name)
+		}
+	}
+}
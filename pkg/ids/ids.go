@@ -0,0 +1,34 @@
+package ids
+
+import "github.com/google/uuid"
+
+// Generator generates identifiers for newly created resources
+type Generator interface {
+	NewId() (string, error)
+}
+
+// UUIDv7 generates UUIDv7 identifiers, using UUIDv7 in order to easily support cursor based
+// pagination without extra fields.
+//
+// From the RFC (https://datatracker.ietf.org/doc/rfc9562/)
+//
+// UUIDv7 features a time-ordered value field derived from the widely
+// implemented and well-known Unix Epoch timestamp source, the number of
+// milliseconds since midnight 1 Jan 1970 UTC, leap seconds excluded.
+// Generally, UUIDv7 has improved entropy characteristics over UUIDv1
+// (Section 5.1) or UUIDv6 (Section 5.6).
+//
+// UUIDv7 values are created by allocating a Unix timestamp in
+// milliseconds in the most significant 48 bits and filling the
+// remaining 74 bits, excluding the required version and variant bits,
+// with random bits for each new UUIDv7 generated to provide uniqueness
+// as per Section 6.9.
+type UUIDv7 struct{}
+
+func (UUIDv7) NewId() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
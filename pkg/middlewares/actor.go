@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"todo-service/pkg/actor"
+)
+
+// Actor sets the actor of requests to the value of their header, which an authenticating proxy in
+// front of the service is expected to set (e.g. X-Forwarded-User). Requests without the header are
+// made by actor.Anonymous.
+func Actor(header string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if name := strings.TrimSpace(r.Header.Get(header)); name != "" {
+				r = r.WithContext(actor.NewContext(r.Context(), name))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-service/pkg/actor"
+)
+
+func TestActor(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "named by the header", header: "alice", want: "alice"},
+		{name: "without the header", header: "", want: actor.Anonymous},
+		{name: "blank header", header: "  ", want: actor.Anonymous},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := Actor("X-Forwarded-User")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = actor.FromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Forwarded-User", tt.header)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("actor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/middlewares"
+	"github.com/tink3rlabs/magic/types"
+)
+
+// StatusClientClosedRequest is the (non standard) status code used when the client went away before
+// the request completed
+const StatusClientClosedRequest = 499
+
+// ErrorHandler extends the magic ErrorHandler with handling of request context errors. Requests
+// that exceeded their deadline are answered with 503 and requests cancelled by the client with 499.
+// It also answers UnsupportedMediaType errors with 415, ContentTooLarge errors with 413, Conflict
+// errors with 409 and Gone errors with 410.
+type ErrorHandler struct {
+	middlewares.ErrorHandler
+}
+
+// UnsupportedMediaType is returned by handlers that can't read request bodies of the type they
+// were sent with
+type UnsupportedMediaType struct {
+	Message string
+}
+
+func (e *UnsupportedMediaType) Error() string {
+	return e.Message
+}
+
+// ContentTooLarge is returned by handlers sent request bodies larger than they accept
+type ContentTooLarge struct {
+	Message string
+}
+
+func (e *ContentTooLarge) Error() string {
+	return e.Message
+}
+
+// Conflict is returned by handlers that can't apply a change to the current state of a resource
+type Conflict struct {
+	Message string
+}
+
+func (e *Conflict) Error() string {
+	return e.Message
+}
+
+// Gone is returned by handlers asked for a resource that existed but is no longer available
+type Gone struct {
+	Message string
+}
+
+func (e *Gone) Error() string {
+	return e.Message
+}
+
+func (e *ErrorHandler) Wrap(handler func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return e.ErrorHandler.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+		err := handler(w, r)
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			return &serviceErrors.ServiceUnavailable{Message: "the request timed out"}
+		}
+
+		var unsupportedMediaType *UnsupportedMediaType
+		var contentTooLarge *ContentTooLarge
+		var conflict *Conflict
+		var gone *Gone
+		switch {
+		case errors.As(err, &unsupportedMediaType):
+			return renderError(w, r, http.StatusUnsupportedMediaType, err)
+		case errors.As(err, &contentTooLarge):
+			return renderError(w, r, http.StatusRequestEntityTooLarge, err)
+		case errors.As(err, &conflict):
+			return renderError(w, r, http.StatusConflict, err)
+		case errors.As(err, &gone):
+			return renderError(w, r, http.StatusGone, err)
+		}
+
+		if errors.Is(err, context.Canceled) {
+			render.Status(r, StatusClientClosedRequest)
+			render.JSON(w, r, types.ErrorResponse{
+				Status: "Client Closed Request",
+				Error:  "the request was cancelled by the client",
+			})
+			return nil
+		}
+
+		return err
+	})
+}
+
+func renderError(w http.ResponseWriter, r *http.Request, status int, err error) error {
+	render.Status(r, status)
+	render.JSON(w, r, types.ErrorResponse{
+		Status: http.StatusText(status),
+		Error:  err.Error(),
+	})
+	return nil
+}
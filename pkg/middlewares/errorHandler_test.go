@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tink3rlabs/magic/storage"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "no error", err: nil, wantStatus: http.StatusOK},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, wantStatus: http.StatusServiceUnavailable},
+		{name: "wrapped deadline exceeded", err: fmt.Errorf("query failed: %w", context.DeadlineExceeded), wantStatus: http.StatusServiceUnavailable},
+		{name: "client cancelled", err: context.Canceled, wantStatus: StatusClientClosedRequest},
+		{name: "not found", err: storage.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "unsupported media type", err: &UnsupportedMediaType{Message: "unsupported"}, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "content too large", err: &ContentTooLarge{Message: "too large"}, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "conflict", err: &Conflict{Message: "changed"}, wantStatus: http.StatusConflict},
+		{name: "gone", err: fmt.Errorf("undo failed: %w", &Gone{Message: "expired"}), wantStatus: http.StatusGone},
+		{name: "unexpected error", err: errors.New("boom"), wantStatus: http.StatusInternalServerError},
+	}
+
+	h := ErrorHandler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := h.Wrap(func(w http.ResponseWriter, r *http.Request) error { return tt.err })
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
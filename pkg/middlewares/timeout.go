@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Timeout sets a deadline on the request context so that handlers, and the storage operations they
+// perform, give up once a request takes longer than timeout. A timeout <= 0 disables the deadline.
+// Requests for server-sent events to one of the streams paths may be long lived by design, they
+// aren't timed out here and the handler of the path must time out the ones that aren't streams.
+func Timeout(timeout time.Duration, streams ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(streams, r.URL.Path) && strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
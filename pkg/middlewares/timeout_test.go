@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		target       string
+		accept       string
+		wantDeadline bool
+	}{
+		{name: "sets a deadline", timeout: time.Minute, target: "/todos", wantDeadline: true},
+		{name: "zero disables the deadline", timeout: 0, target: "/todos", wantDeadline: false},
+		{name: "event streams have no deadline", timeout: time.Minute, target: "/graphql", accept: "text/event-stream", wantDeadline: false},
+		{name: "other requests to streams have a deadline", timeout: time.Minute, target: "/graphql", accept: "application/json", wantDeadline: true},
+		{name: "event streams elsewhere have a deadline", timeout: time.Minute, target: "/todos", accept: "text/event-stream", wantDeadline: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hasDeadline bool
+			handler := Timeout(tt.timeout, "/graphql")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, hasDeadline = r.Context().Deadline()
+			}))
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set("Accept", tt.accept)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if hasDeadline != tt.wantDeadline {
+				t.Errorf("request has a deadline = %v, want %v", hasDeadline, tt.wantDeadline)
+			}
+		})
+	}
+}
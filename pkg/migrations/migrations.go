@@ -0,0 +1,333 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tink3rlabs/magic/storage"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"todo-service/pkg/store"
+)
+
+// Dir is the directory holding a sub directory of migration files for every SQL provider
+const Dir = "config/migrations"
+
+// Providers are the SQL providers that have their own set of migration files
+var Providers = []storage.StorageProviders{storage.MYSQL, storage.POSTGRESQL, storage.SQLITE}
+
+var ErrNotSupported = errors.New("migrations are only supported for the sql and memory storage adapters")
+
+// Migration describes a migration file and whether it was applied
+type Migration struct {
+	Id          int
+	Name        string
+	Description string
+	AppliedAt   time.Time
+	Applied     bool
+	file        migrationFile
+}
+
+// migrationFile is a magic migration file that can list the SQLite compile options (e.g.
+// ENABLE_FTS5) its statements need. Such a migration is skipped while the driver lacks one of them
+// and applied by the first run that has them, so it must not be required by later migrations.
+type migrationFile struct {
+	storage.MigrationFile `yaml:",inline"`
+	Requires              []string `yaml:"requires"`
+}
+
+// Migrator applies and rolls back the migration files of a storage provider.
+//
+// Applied migrations are tracked in the same migrations table used by the magic DatabaseMigration
+// so databases migrated by either of them stay compatible.
+type Migrator struct {
+	storage  storage.StorageAdapter
+	db       *gorm.DB
+	provider storage.StorageProviders
+	files    fs.FS
+}
+
+// NewMigrator returns a Migrator for the given storage adapter, files must contain the Dir
+// directory (e.g. the embedded ConfigFS)
+func NewMigrator(storageAdapter storage.StorageAdapter, files fs.FS) (*Migrator, error) {
+	db, ok := store.GormDB(storageAdapter)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	return &Migrator{storage: storageAdapter, db: db, provider: store.Provider(storageAdapter), files: files}, nil
+}
+
+// Status returns every migration of the provider in the order they are applied
+func (m *Migrator) Status() ([]Migration, error) {
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+
+	migrations, err := m.readFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	type appliedMigration struct {
+		Id        int
+		Timestamp int64
+	}
+	applied := []appliedMigration{}
+	result := m.db.Raw("SELECT id, timestamp FROM migrations").Scan(&applied)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %v", result.Error)
+	}
+
+	for _, a := range applied {
+		for i := range migrations {
+			if migrations[i].Id == a.Id {
+				migrations[i].Applied = true
+				migrations[i].AppliedAt = time.UnixMilli(a.Timestamp).UTC()
+			}
+		}
+	}
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns the ones that were applied. When a statement fails
+// the statements of its migration are rolled back and no further migrations are applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	migrations, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	latest := latestApplied(migrations)
+	for _, migration := range migrations {
+		required := len(migration.file.Requires) > 0
+		if migration.Applied || (migration.Id < latest && !required) {
+			continue
+		}
+		if required {
+			missing, err := m.missingOptions(migration.file.Requires)
+			if err != nil {
+				return applied, err
+			}
+			if len(missing) > 0 {
+				slog.Warn("skipping migration, the SQLite driver was built without the options it requires",
+					slog.String("name", migration.Name), slog.Any("missing", missing))
+				continue
+			}
+		}
+
+		slog.Info("applying migration", slog.String("name", migration.Name))
+		for i, stmt := range migration.file.Migrations {
+			if err := m.storage.Execute(stmt.Migrate); err != nil {
+				slog.Error("failed to execute migration statement", slog.String("name", migration.Name), slog.Any("error", err))
+				if rollbackErr := m.rollback(migration.file.Migrations[:i]); rollbackErr != nil {
+					return applied, fmt.Errorf("failed to apply migration %s: %v, and failed to roll it back: %v", migration.Name, err, rollbackErr)
+				}
+				return applied, fmt.Errorf("failed to apply migration %s: %v", migration.Name, err)
+			}
+		}
+
+		statement := fmt.Sprintf(`INSERT INTO migrations VALUES(%v, '%v', '%v', %v)`, migration.Id, migration.Name, escape(migration.Description), time.Now().UnixMilli())
+		if err := m.storage.Execute(statement); err != nil {
+			return applied, fmt.Errorf("failed to record migration %s: %v", migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Down rolls back the last n applied migrations and returns the ones that were rolled back
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	migrations, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	rolledBack := []Migration{}
+	slices.Reverse(migrations)
+	for _, migration := range migrations {
+		if len(rolledBack) == n {
+			break
+		}
+		if !migration.Applied {
+			continue
+		}
+
+		slog.Info("rolling back migration", slog.String("name", migration.Name))
+		if err := m.rollback(migration.file.Migrations); err != nil {
+			return rolledBack, fmt.Errorf("failed to roll back migration %s: %v", migration.Name, err)
+		}
+		if err := m.storage.Execute(fmt.Sprintf("DELETE FROM migrations WHERE id = %v", migration.Id)); err != nil {
+			return rolledBack, fmt.Errorf("failed to remove migration %s from the migrations table: %v", migration.Name, err)
+		}
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+func (m *Migrator) rollback(statements []storage.Migration) error {
+	for i := len(statements) - 1; i >= 0; i-- {
+		if err := m.storage.Execute(statements[i].Rollback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// missingOptions returns the SQLite compile options the driver was built without
+func (m *Migrator) missingOptions(options []string) ([]string, error) {
+	if m.provider != storage.SQLITE {
+		return nil, fmt.Errorf("migrations can only require compile options of sqlite, not %s", m.provider)
+	}
+	missing := []string{}
+	for _, option := range options {
+		used := 0
+		if err := m.db.Raw("SELECT sqlite_compileoption_used(?)", option).Scan(&used).Error; err != nil {
+			return nil, fmt.Errorf("failed to check the %s compile option: %v", option, err)
+		}
+		if used == 0 {
+			missing = append(missing, option)
+		}
+	}
+	return missing, nil
+}
+
+func (m *Migrator) prepare() error {
+	if m.provider != storage.SQLITE {
+		if err := m.storage.Execute(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", m.storage.GetSchemaName())); err != nil {
+			return fmt.Errorf("failed to create schema: %v", err)
+		}
+	}
+
+	var statement string
+	switch m.provider {
+	case storage.POSTGRESQL:
+		statement = "CREATE TABLE IF NOT EXISTS migrations (id NUMERIC PRIMARY KEY, name TEXT, description TEXT, timestamp NUMERIC)"
+	case storage.MYSQL:
+		statement = "CREATE TABLE IF NOT EXISTS migrations (id INT PRIMARY KEY, name TEXT, description TEXT, timestamp BIGINT)"
+	case storage.SQLITE:
+		statement = "CREATE TABLE IF NOT EXISTS migrations (id INTEGER PRIMARY KEY, name TEXT, description TEXT, timestamp INTEGER)"
+	default:
+		return fmt.Errorf("migrations are not supported for the %s provider", m.provider)
+	}
+	if err := m.storage.Execute(statement); err != nil {
+		return fmt.Errorf("failed to create migration table: %v", err)
+	}
+	return nil
+}
+
+func (m *Migrator) readFiles() ([]Migration, error) {
+	dir := path.Join(Dir, string(m.provider))
+	entries, err := fs.ReadDir(m.files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration files: %v", err)
+	}
+
+	migrations := []Migration{}
+	for _, entry := range entries {
+		id, err := migrationId(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		contents, err := fs.ReadFile(m.files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %v", entry.Name(), err)
+		}
+		mf := migrationFile{}
+		if err := yaml.Unmarshal(contents, &mf); err != nil {
+			return nil, fmt.Errorf("failed to parse migration file %s: %v", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Id: id, Name: entry.Name(), Description: mf.Description, file: mf})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Id < migrations[j].Id })
+	return migrations, nil
+}
+
+// latestApplied returns the id of the latest applied migration. Like the magic DatabaseMigration,
+// migrations older than the latest applied one are considered to be part of the schema already.
+func latestApplied(migrations []Migration) int {
+	latest := 0
+	for _, m := range migrations {
+		if m.Applied && m.Id > latest {
+			latest = m.Id
+		}
+	}
+	return latest
+}
+
+func migrationId(fileName string) (int, error) {
+	id, err := strconv.Atoi(strings.Split(fileName, "__")[0])
+	if err != nil {
+		return 0, fmt.Errorf("failed to determine the id of migration %s: %v", fileName, err)
+	}
+	return id, nil
+}
+
+func escape(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+var nonWordCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// template is the content of a new migration, the description is quoted since names may hold
+// characters YAML would read as syntax (a JSON quoted string is valid YAML)
+const template = `---
+description: %q
+migrations:
+  - migrate: >
+      -- TODO: add the %s statement
+    rollback: >
+      -- TODO: add the %s rollback statement
+`
+
+// Create scaffolds an empty migration file named after name for every provider under dir (usually
+// Dir in the source tree) and returns the paths of the created files. The new migration gets the
+// id following the highest id used by any provider.
+func Create(dir string, name string) ([]string, error) {
+	slug := strings.Trim(nonWordCharacters.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return nil, errors.New("a migration name must contain at least one letter or digit")
+	}
+
+	latest := 0
+	for _, provider := range Providers {
+		entries, err := os.ReadDir(filepath.Join(dir, string(provider)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if id, err := migrationId(entry.Name()); err == nil && id > latest {
+				latest = id
+			}
+		}
+	}
+
+	fileName := fmt.Sprintf("%02d__%s.yaml", latest+1, slug)
+	created := []string{}
+	for _, provider := range Providers {
+		if err := os.MkdirAll(filepath.Join(dir, string(provider)), 0755); err != nil {
+			return created, err
+		}
+		p := filepath.Join(dir, string(provider), fileName)
+		contents := fmt.Sprintf(template, name, provider, provider)
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			return created, fmt.Errorf("failed to write migration file %s: %v", p, err)
+		}
+		created = append(created, p)
+	}
+	return created, nil
+}
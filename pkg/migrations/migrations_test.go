@@ -0,0 +1,220 @@
+package migrations_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tink3rlabs/magic/storage"
+	"gopkg.in/yaml.v3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/migrations"
+)
+
+var files = fstest.MapFS{
+	"config/migrations/sqlite/01__first.yaml": {Data: []byte(`
+description: Create the first table
+migrations:
+  - migrate: CREATE TABLE first (id TEXT PRIMARY KEY)
+    rollback: DROP TABLE first
+`)},
+	"config/migrations/sqlite/02__second.yaml": {Data: []byte(`
+description: Create the second table
+migrations:
+  - migrate: CREATE TABLE second (id TEXT PRIMARY KEY)
+    rollback: DROP TABLE second
+  - migrate: CREATE INDEX second_id ON second (id)
+    rollback: DROP INDEX second_id
+`)},
+}
+
+func newSQLite(t *testing.T) (storage.StorageAdapter, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.sqlite")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	return &storage.SQLAdapter{DB: db}, db
+}
+
+func newMigrator(t *testing.T, adapter storage.StorageAdapter, files fstest.MapFS) *migrations.Migrator {
+	t.Helper()
+	migrator, err := migrations.NewMigrator(adapter, files)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	return migrator
+}
+
+func names(migrations []migrations.Migration) string {
+	n := []string{}
+	for _, m := range migrations {
+		n = append(n, m.Name)
+	}
+	return strings.Join(n, ",")
+}
+
+func TestNewMigratorRequiresSQLStorage(t *testing.T) {
+	if _, err := migrations.NewMigrator(fakes.NewStorage(), files); err == nil {
+		t.Error("NewMigrator() with a non SQL adapter succeeded, want an error")
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	adapter, db := newSQLite(t)
+	migrator := newMigrator(t, adapter, files)
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if got := names(applied); got != "01__first.yaml,02__second.yaml" {
+		t.Errorf("Up() applied %s, want both migrations", got)
+	}
+	if !db.Migrator().HasTable("second") {
+		t.Error("Up() did not create the second table")
+	}
+
+	applied, err = migrator.Up()
+	if err != nil || len(applied) != 0 {
+		t.Errorf("second Up() = %s, %v, want nothing applied", names(applied), err)
+	}
+
+	rolledBack, err := migrator.Down(1)
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if got := names(rolledBack); got != "02__second.yaml" {
+		t.Errorf("Down(1) rolled back %s, want the second migration", got)
+	}
+	if db.Migrator().HasTable("second") || !db.Migrator().HasTable("first") {
+		t.Error("Down(1) did not roll back only the second migration")
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if len(status) != 2 || !status[0].Applied || status[1].Applied {
+		t.Errorf("Status() = %+v, want the first migration applied and the second pending", status)
+	}
+	if status[0].Description != "Create the first table" || status[0].AppliedAt.IsZero() {
+		t.Errorf("Status() = %+v, want the description and time of the applied migration", status[0])
+	}
+
+	rolledBack, err = migrator.Down(5)
+	if err != nil || names(rolledBack) != "01__first.yaml" {
+		t.Errorf("Down(5) = %s, %v, want only the remaining migration rolled back", names(rolledBack), err)
+	}
+}
+
+func TestUpRollsBackFailedMigration(t *testing.T) {
+	broken := fstest.MapFS{
+		"config/migrations/sqlite/01__broken.yaml": {Data: []byte(`
+description: Broken migration
+migrations:
+  - migrate: CREATE TABLE broken (id TEXT PRIMARY KEY)
+    rollback: DROP TABLE broken
+  - migrate: CREATE INDEX broken_id ON missing (id)
+    rollback: DROP INDEX broken_id
+`)},
+	}
+	adapter, db := newSQLite(t)
+	migrator := newMigrator(t, adapter, broken)
+
+	if _, err := migrator.Up(); err == nil {
+		t.Fatal("Up() of a broken migration succeeded, want an error")
+	}
+	if db.Migrator().HasTable("broken") {
+		t.Error("Up() did not roll back the statements of the failed migration")
+	}
+	status, err := migrator.Status()
+	if err != nil || status[0].Applied {
+		t.Errorf("Status() = %+v, %v, want the broken migration pending", status, err)
+	}
+}
+
+func TestUpSkipsMigrationsMissingCompileOptions(t *testing.T) {
+	optional := fstest.MapFS{
+		"config/migrations/sqlite/01__available.yaml": {Data: []byte(`
+description: Needs an option the driver is built with
+requires: [ENABLE_FTS3]
+migrations:
+  - migrate: CREATE VIRTUAL TABLE available USING fts4(summary)
+    rollback: DROP TABLE available
+`)},
+		"config/migrations/sqlite/02__missing.yaml": {Data: []byte(`
+description: Needs an option the driver is built without
+requires: [ENABLE_NOT_AN_OPTION]
+migrations:
+  - migrate: CREATE TABLE missing (id TEXT PRIMARY KEY)
+    rollback: DROP TABLE missing
+`)},
+		"config/migrations/sqlite/03__later.yaml": {Data: []byte(`
+description: A later migration
+migrations:
+  - migrate: CREATE TABLE later (id TEXT PRIMARY KEY)
+    rollback: DROP TABLE later
+`)},
+	}
+	adapter, db := newSQLite(t)
+	migrator := newMigrator(t, adapter, optional)
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if got := names(applied); got != "01__available.yaml,03__later.yaml" {
+		t.Errorf("Up() applied %s, want the migration missing an option skipped", got)
+	}
+	if db.Migrator().HasTable("missing") {
+		t.Error("Up() created the table of the skipped migration")
+	}
+	status, err := migrator.Status()
+	if err != nil || status[1].Applied {
+		t.Errorf("Status() = %+v, %v, want the skipped migration pending", status, err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sqlite"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sqlite", "03__existing.yaml"), []byte("---"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Names may hold characters that have a meaning in YAML
+	name := `fix: Add Due Dates # "soon"`
+	created, err := migrations.Create(dir, name)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(created) != len(migrations.Providers) {
+		t.Fatalf("Create() created %v, want a file per provider", created)
+	}
+	for _, p := range created {
+		if filepath.Base(p) != "04__fix_add_due_dates_soon.yaml" {
+			t.Errorf("Create() created %s, want 04__fix_add_due_dates_soon.yaml", p)
+		}
+		contents, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		file := storage.MigrationFile{}
+		if err := yaml.Unmarshal(contents, &file); err != nil || file.Description != name {
+			t.Errorf("created file %s = %q with the description %q, %v, want %q", p, contents, file.Description, err, name)
+		}
+	}
+
+	if _, err := migrations.Create(dir, "!!!"); err == nil {
+		t.Error("Create() with an invalid name succeeded, want an error")
+	}
+}
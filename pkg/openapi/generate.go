@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/tink3rlabs/magic/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Generate builds the OpenAPI definition from the @openapi comments of the Go files under root,
+// merged into base which holds the parts that aren't read from comments (info, servers, tags and
+// security schemes), and adds the common definitions of magic (such as the Error schema). go
+// generate writes it to config/openapi.json for the server, tests build it on the fly.
+func Generate(root string, base map[string]any) ([]byte, error) {
+	definition := map[string]any{}
+	merge(definition, base)
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		for _, group := range file.Comments {
+			block, ok := openapiBlock(group.List)
+			if !ok {
+				continue
+			}
+			fragment := map[string]any{}
+			if err := yaml.Unmarshal([]byte(block), &fragment); err != nil {
+				return fmt.Errorf("invalid @openapi comment in %s: %v", path, err)
+			}
+			merge(definition, fragment)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+	return types.MergeOpenAPIDefinitions(encoded)
+}
+
+// Validate checks spec is a valid OpenAPI definition
+func Validate(spec []byte) error {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return fmt.Errorf("invalid OpenAPI definition: %v", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return fmt.Errorf("invalid OpenAPI definition: %v", err)
+	}
+	return nil
+}
+
+// openapiBlock returns the YAML following the @openapi line of a comment group. Gofmt indents the
+// YAML with a tab, which is turned back into the two spaces it stands for.
+func openapiBlock(comments []*ast.Comment) (string, bool) {
+	lines := []string{}
+	found := false
+	for _, comment := range comments {
+		line, ok := strings.CutPrefix(comment.Text, "//")
+		if !ok {
+			continue
+		}
+		if !found {
+			found = strings.TrimSpace(line) == "@openapi"
+			continue
+		}
+		if indented, ok := strings.CutPrefix(line, "\t"); ok {
+			line = "  " + indented
+		} else {
+			line = strings.TrimPrefix(line, " ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), found
+}
+
+// merge deep merges src into dst, the comments of an operation only hold the part of the
+// definition leading to it
+func merge(dst map[string]any, src map[string]any) {
+	for key, value := range src {
+		existing, ok := dst[key].(map[string]any)
+		fragment, isMap := value.(map[string]any)
+		if ok && isMap {
+			merge(existing, fragment)
+			continue
+		}
+		dst[key] = value
+	}
+}
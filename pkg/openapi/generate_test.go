@@ -0,0 +1,51 @@
+package openapi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const commented = `package routes
+
+// @openapi
+// paths:
+//
+//	/todos:
+//	  get:
+//	    operationId: listTodos
+//	    responses:
+//	      '200':
+//	        description: successful operation
+func ListTodos() {}
+`
+
+func TestGenerate(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "routes.go"), []byte(commented), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	// Tests aren't part of the definition
+	if err := os.WriteFile(filepath.Join(root, "routes_test.go"), []byte("package routes\n\n// @openapi\n// invalid: [\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	spec, err := Generate(root, map[string]any{"openapi": "3.0.3", "info": map[string]any{"title": "Todo API", "version": "1.0.0"}})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if err := Validate(spec); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	definition := struct {
+		Info  map[string]string                    `json:"info"`
+		Paths map[string]map[string]map[string]any `json:"paths"`
+	}{}
+	if err := json.Unmarshal(spec, &definition); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if definition.Info["title"] != "Todo API" || definition.Paths["/todos"]["get"]["operationId"] != "listTodos" {
+		t.Errorf("Generate() = %s, want the base with the commented operation", spec)
+	}
+}
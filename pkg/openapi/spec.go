@@ -0,0 +1,66 @@
+// Package openapi serves the OpenAPI definition generated from the @openapi comments and validates
+// requests and responses against it
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WithServers returns spec with its servers list replaced by urls, spec is returned unchanged
+// when no urls are given
+func WithServers(spec []byte, urls ...string) ([]byte, error) {
+	if len(urls) == 0 {
+		return spec, nil
+	}
+
+	definition := map[string]json.RawMessage{}
+	if err := json.Unmarshal(spec, &definition); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI definition: %v", err)
+	}
+	servers := []map[string]string{}
+	for _, url := range urls {
+		servers = append(servers, map[string]string{"url": url})
+	}
+	encoded, err := json.Marshal(servers)
+	if err != nil {
+		return nil, err
+	}
+	definition["servers"] = encoded
+
+	// Marshalling a map sorts its keys, which moves openapi, info and paths around but keeps the
+	// definition as valid as it was
+	return json.Marshal(definition)
+}
+
+// ToYAML converts a JSON definition to YAML, keeping the order of its keys
+func ToYAML(spec []byte) ([]byte, error) {
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(spec, &node); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI definition: %v", err)
+	}
+	blockStyle(&node)
+
+	b := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// blockStyle drops the flow style and quotes of JSON, the encoder quotes the strings that would
+// otherwise be read as another type
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,64 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const spec = `{"openapi": "3.0.3", "info": {"title": "Todo API", "version": "1.0.0"}, "servers": [{"url": "http://localhost:8080"}], "paths": {}}`
+
+func TestWithServers(t *testing.T) {
+	got, err := WithServers([]byte(spec), "https://todo.example.com")
+	if err != nil {
+		t.Fatalf("WithServers() error = %v", err)
+	}
+
+	definition := struct {
+		OpenAPI string              `json:"openapi"`
+		Servers []map[string]string `json:"servers"`
+	}{}
+	if err := json.Unmarshal(got, &definition); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if definition.OpenAPI != "3.0.3" || len(definition.Servers) != 1 || definition.Servers[0]["url"] != "https://todo.example.com" {
+		t.Errorf("WithServers() = %s, want the servers replaced", got)
+	}
+
+	if got, err := WithServers([]byte(spec)); err != nil || string(got) != spec {
+		t.Errorf("WithServers() without urls = %s, %v, want the definition unchanged", got, err)
+	}
+	if _, err := WithServers([]byte("not json"), "https://todo.example.com"); err == nil {
+		t.Error("WithServers() of an invalid definition succeeded, want an error")
+	}
+}
+
+func TestToYAML(t *testing.T) {
+	got, err := ToYAML([]byte(spec))
+	if err != nil {
+		t.Fatalf("ToYAML() error = %v", err)
+	}
+	want := `openapi: 3.0.3
+info:
+  title: Todo API
+  version: 1.0.0
+servers:
+  - url: http://localhost:8080
+paths: {}
+`
+	if string(got) != want {
+		t.Errorf("ToYAML() = %q, want %q", got, want)
+	}
+}
+
+func TestToYAMLQuotesAmbiguousStrings(t *testing.T) {
+	got, err := ToYAML([]byte(`{"version": "1.0", "example": "true", "empty": ""}`))
+	if err != nil {
+		t.Fatalf("ToYAML() error = %v", err)
+	}
+	for _, want := range []string{`version: "1.0"`, `example: "true"`, `empty: ""`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("ToYAML() = %s, want it to contain %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/render"
+	"github.com/tink3rlabs/magic/types"
+)
+
+func init() {
+	openapi3filter.RegisterBodyDecoder("application/merge-patch+json", openapi3filter.JSONBodyDecoder)
+}
+
+// Validator checks requests, and optionally responses, against an OpenAPI definition
+type Validator struct {
+	router            routers.Router
+	validateResponses bool
+}
+
+// NewValidator returns a Validator for spec, which must be a valid definition. Responses are only
+// validated when validateResponses is set, this buffers every response and is meant for
+// development and tests.
+func NewValidator(spec []byte, validateResponses bool) (*Validator, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI definition: %v", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI definition: %v", err)
+	}
+	// The servers tell clients where the service is reachable, requests are matched on their path
+	// alone so the service validates them whatever host name it's called with
+	doc.Servers = nil
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{router: router, validateResponses: validateResponses}, nil
+}
+
+// Middleware answers requests that don't match their operation in the definition with 400.
+// Requests for paths the definition doesn't describe (such as CalDAV) are passed through, as are
+// request bodies that aren't JSON which are streamed to the handlers.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if unsupportedMediaType(route.Operation, r) {
+			if r.Method == http.MethodPatch {
+				w.Header().Set("Accept-Patch", strings.Join(mediaTypes(route.Operation), ", "))
+			}
+			render.Status(r, http.StatusUnsupportedMediaType)
+			render.JSON(w, r, types.ErrorResponse{
+				Status: http.StatusText(http.StatusUnsupportedMediaType),
+				Error:  fmt.Sprintf("unsupported Content-Type %q, send one of %s", r.Header.Get("Content-Type"), strings.Join(mediaTypes(route.Operation), ", ")),
+			})
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				ExcludeRequestBody: !isJSON(r.Header.Get("Content-Type")),
+			},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, types.ErrorResponse{
+				Status:  http.StatusText(http.StatusBadRequest),
+				Error:   "request validation failed",
+				Details: details(err),
+			})
+			return
+		}
+
+		if !v.validateResponses || streams(route.Operation) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		recorder := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		v.writeResponse(w, r, input, recorder)
+	})
+}
+
+// writeResponse sends the recorded response when it matches the definition and 500 when it doesn't
+func (v *Validator) writeResponse(w http.ResponseWriter, r *http.Request, input *openapi3filter.RequestValidationInput, recorder *responseRecorder) {
+	err := openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 recorder.status,
+		Header:                 recorder.header,
+		Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+		Options:                &openapi3filter.Options{MultiError: true},
+	})
+	if err != nil {
+		problems := details(err)
+		slog.Error("response doesn't match the OpenAPI definition",
+			slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.Int("status", recorder.status), slog.Any("details", problems))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, types.ErrorResponse{
+			Status:  http.StatusText(http.StatusInternalServerError),
+			Error:   "encountered an unexpected server error: the response doesn't match the API definition",
+			Details: problems,
+		})
+		return
+	}
+
+	for key, values := range recorder.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(recorder.status)
+	if recorder.body.Len() == 0 {
+		return
+	}
+	if _, err := w.Write(recorder.body.Bytes()); err != nil {
+		slog.Error("failed to write response", slog.Any("error", err))
+	}
+}
+
+// details lists the validation errors held by err, schema errors are reported with the field they
+// apply to like the errors of the magic validator ("summary: property is missing")
+func details(err error) []string {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		messages := []string{}
+		for _, e := range multi {
+			messages = append(messages, details(e)...)
+		}
+		return messages
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		field := strings.Join(schemaErr.JSONPointer(), ".")
+		if field == "" {
+			field = "(root)"
+		}
+		return []string{fmt.Sprintf("%s: %s", field, schemaErr.Reason)}
+	}
+	return []string{err.Error()}
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// unsupportedMediaType reports whether r has a JSON body the operation doesn't accept. Bodies that
+// aren't JSON are left to the handlers, which may read them whatever their Content-Type (such as
+// imports with a format parameter).
+func unsupportedMediaType(operation *openapi3.Operation, r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil || !isJSON(contentType) {
+		return false
+	}
+	return operation.RequestBody.Value.Content.Get(contentType) == nil
+}
+
+// mediaTypes lists the media types of the request bodies operation accepts
+func mediaTypes(operation *openapi3.Operation) []string {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return nil
+	}
+	accepted := []string{}
+	for mediaType := range operation.RequestBody.Value.Content {
+		accepted = append(accepted, mediaType)
+	}
+	sort.Strings(accepted)
+	return accepted
+}
+
+// streams reports whether operation responds with content that isn't JSON, such responses are
+// streamed (exports, calendar feeds and server-sent events) and can't be buffered for validation
+func streams(operation *openapi3.Operation) bool {
+	for _, response := range operation.Responses.Map() {
+		if response.Value == nil {
+			continue
+		}
+		for contentType := range response.Value.Content {
+			if !isJSON(contentType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// responseRecorder buffers a response so it can be validated before it's sent
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
@@ -0,0 +1,154 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tink3rlabs/magic/types"
+)
+
+const validatedSpec = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Todo API", "version": "1.0.0"},
+	"servers": [{"url": "https://todo.example.com"}],
+	"paths": {
+		"/todos/{id}": {
+			"put": {
+				"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+				"requestBody": {
+					"required": true,
+					"content": {"application/json": {"schema": {
+						"type": "object",
+						"required": ["summary"],
+						"additionalProperties": false,
+						"properties": {"summary": {"type": "string"}}
+					}}}
+				},
+				"responses": {
+					"200": {"description": "ok", "content": {"application/json": {"schema": {
+						"type": "object",
+						"required": ["id"],
+						"properties": {"id": {"type": "string"}}
+					}}}}
+				}
+			}
+		}
+	}
+}`
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name              string
+		validateResponses bool
+		method            string
+		target            string
+		body              string
+		response          string
+		wantStatus        int
+	}{
+		{name: "valid request", method: http.MethodPut, target: "/todos/1", body: `{"summary": "todo"}`, response: `{"id": "1"}`, wantStatus: http.StatusOK},
+		{name: "missing property", method: http.MethodPut, target: "/todos/1", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "additional property", method: http.MethodPut, target: "/todos/1", body: `{"summary": "todo", "id": "1"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid json", method: http.MethodPut, target: "/todos/1", body: `{"summary": `, wantStatus: http.StatusBadRequest},
+		{name: "missing body", method: http.MethodPut, target: "/todos/1", wantStatus: http.StatusBadRequest},
+		{name: "undocumented path", method: http.MethodPut, target: "/caldav/todos/1", body: `anything`, wantStatus: http.StatusOK},
+		{name: "undocumented method", method: http.MethodGet, target: "/todos/1", wantStatus: http.StatusOK},
+		{name: "invalid response", validateResponses: true, method: http.MethodPut, target: "/todos/1", body: `{"summary": "todo"}`, response: `{}`, wantStatus: http.StatusInternalServerError},
+		{name: "valid response", validateResponses: true, method: http.MethodPut, target: "/todos/1", body: `{"summary": "todo"}`, response: `{"id": "1"}`, wantStatus: http.StatusOK},
+		{name: "responses not validated", method: http.MethodPut, target: "/todos/1", body: `{"summary": "todo"}`, response: `{}`, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := NewValidator([]byte(validatedSpec), tt.validateResponses)
+			if err != nil {
+				t.Fatalf("NewValidator() error = %v", err)
+			}
+			var received string
+			handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body := new(strings.Builder)
+				if r.Body != nil {
+					_, _ = io.Copy(body, r.Body)
+				}
+				received = body.String()
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.response))
+			}))
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && (received != tt.body || w.Body.String() != tt.response) {
+				t.Errorf("handler received %q and answered %q, want %q and %q", received, w.Body.String(), tt.body, tt.response)
+			}
+		})
+	}
+}
+
+func TestNewValidatorRejectsInvalidDefinitions(t *testing.T) {
+	for _, spec := range []string{`not json`, `{"openapi": "3.0.3", "paths": {}}`} {
+		if _, err := NewValidator([]byte(spec), false); err == nil {
+			t.Errorf("NewValidator(%s) error = nil, want an error", spec)
+		}
+	}
+}
+
+func TestValidatorErrorDetails(t *testing.T) {
+	validator, err := NewValidator([]byte(validatedSpec), false)
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+	handler := validator.Middleware(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodPut, "/todos/1", strings.NewReader(`{"summary": 1, "done": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	response := types.ErrorResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response %q: %v", w.Body.String(), err)
+	}
+	want := []string{`(root): property "done" is unsupported`, `summary: value must be a string`}
+	slices.Sort(response.Details)
+	if w.Code != http.StatusBadRequest || !slices.Equal(response.Details, want) {
+		t.Errorf("response = %d %+v, want %d with details %q", w.Code, response, http.StatusBadRequest, want)
+	}
+}
+
+func TestValidatorUnsupportedMediaType(t *testing.T) {
+	validator, err := NewValidator([]byte(validatedSpec), false)
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+	handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := map[string]int{
+		"application/json":                http.StatusOK,
+		"application/json; charset=utf-8": http.StatusOK,
+		"application/merge-patch+json":    http.StatusUnsupportedMediaType,
+		"application/vnd.something+json":  http.StatusUnsupportedMediaType,
+		"text/plain":                      http.StatusOK, // left to the handler
+	}
+	for contentType, wantStatus := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/todos/1", strings.NewReader(`{"summary": "todo"}`))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != wantStatus {
+			t.Errorf("Content-Type %s status = %d, want %d: %s", contentType, w.Code, wantStatus, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package todov1 holds the code generated from proto/todo/v1/todo.proto, regenerate it with
+// go generate ./pkg/proto/... (requires protoc, protoc-gen-go and protoc-gen-go-grpc)
+package todov1
+
+//go:generate protoc --proto_path=../../../../proto --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative todo/v1/todo.proto
@@ -0,0 +1,269 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/features/todo"
+	serviceMiddlewares "todo-service/pkg/middlewares"
+	"todo-service/pkg/types"
+)
+
+// attachmentField is the multipart form field holding uploaded files
+const attachmentField = "file"
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/attachments:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Get the Attachments of a Todo
+//	    description: Returns a page of the files attached to the Todo with the identifier {id}, the oldest first
+//	    operationId: listAttachments
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: limit
+//	        in: query
+//	        description: The number of attachments to return (defaults to 10), limits above service.maxLimit (100 by default) are lowered to it
+//	        required: false
+//	        schema:
+//	          type: integer
+//	          minimum: 1
+//	      - name: next
+//	        in: query
+//	        description: The next page identifier
+//	        required: false
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/AttachmentList'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) ListAttachments(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if (err != nil) || limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, t.maxLimit)
+
+	attachments, next, err := t.service.ListAttachments(r.Context(), chi.URLParam(r, "id"), limit, query.Get("next"))
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, types.AttachmentList{Attachments: attachments, Next: next})
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/attachments:
+//	  post:
+//	    tags:
+//	      - todos
+//	    summary: Attach a file to a Todo
+//	    description: Uploads a file and attaches it to the Todo with the identifier {id}. The type of the file is detected from its content, the allowed types and the largest size are configured by attachments.contentTypes and attachments.maxSize.
+//	    operationId: addAttachment
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	    requestBody:
+//	      description: The file to attach
+//	      required: true
+//	      content:
+//	        multipart/form-data:
+//	          schema:
+//	            type: object
+//	            required:
+//	              - file
+//	            properties:
+//	              file:
+//	                type: string
+//	                format: binary
+//	    responses:
+//	      '201':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Attachment'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '413':
+//	        description: The file is larger than attachments.maxSize
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Error'
+//	      '415':
+//	        description: The type of the file isn't one of attachments.contentTypes
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Error'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) AddAttachment(w http.ResponseWriter, r *http.Request) error {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return &serviceErrors.BadRequest{Message: fmt.Sprintf("send the file in the %s field of a multipart/form-data body", attachmentField)}
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return &serviceErrors.BadRequest{Message: fmt.Sprintf("the %s field is missing", attachmentField)}
+		}
+		if err != nil {
+			return &serviceErrors.BadRequest{Message: fmt.Sprintf("invalid multipart body: %v", err)}
+		}
+		if part.FormName() != attachmentField {
+			continue
+		}
+
+		// The file is streamed to the service rather than parsed into memory or temporary files
+		attachment, err := t.service.AddAttachment(r.Context(), chi.URLParam(r, "id"), part.FileName(), part.Header.Get("Content-Type"), part)
+		switch {
+		case errors.Is(err, todo.ErrAttachmentTooLarge):
+			return &serviceMiddlewares.ContentTooLarge{Message: err.Error()}
+		case errors.Is(err, todo.ErrAttachmentType):
+			return &serviceMiddlewares.UnsupportedMediaType{Message: err.Error()}
+		case err != nil:
+			return err
+		}
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, attachment)
+		return nil
+	}
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/attachments/{attachment}:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Download an Attachment
+//	    description: Returns the content of the file {attachment} attached to the Todo with the identifier {id}
+//	    operationId: getAttachment
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: attachment
+//	        in: path
+//	        description: The identifier of the Attachment
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        headers:
+//	          Content-Disposition:
+//	            description: The name of the uploaded file
+//	            schema:
+//	              type: string
+//	              example: attachment; filename=receipt.pdf
+//	          ETag:
+//	            description: The SHA-256 checksum of the file
+//	            schema:
+//	              type: string
+//	        content:
+//	          '*/*':
+//	            schema:
+//	              type: string
+//	              format: binary
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) GetAttachment(w http.ResponseWriter, r *http.Request) error {
+	attachment, content, err := t.service.GetAttachment(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "attachment"))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("ETag", strconv.Quote(attachment.Checksum))
+	// Browsers must not guess another type, an uploaded file could then be run as a page
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, content); err != nil {
+		// The response is already on its way, all we can do is stop sending it
+		slog.Error("failed to send attachment", slog.String("attachment", attachment.Id), slog.Any("error", err))
+	}
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/attachments/{attachment}:
+//	  delete:
+//	    tags:
+//	      - todos
+//	    summary: Delete an Attachment
+//	    description: Deletes the file {attachment} attached to the Todo with the identifier {id} if exists
+//	    operationId: deleteAttachment
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: attachment
+//	        in: path
+//	        description: The identifier of the Attachment
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '204':
+//	        description: successful operation
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) DeleteAttachment(w http.ResponseWriter, r *http.Request) error {
+	if err := t.service.DeleteAttachment(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "attachment")); err != nil {
+		return err
+	}
+	render.NoContent(w, r)
+	return nil
+}
@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"todo-service/pkg/blob"
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/types"
+)
+
+func newAttachmentRouter(t *testing.T) *TodoRouter {
+	t.Helper()
+	blobs, err := blob.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+	service, err := todo.NewTodoService(todo.TodoServiceProps{
+		Storage:          fakes.NewStorage(),
+		Clock:            fakes.NewClock(now),
+		IdGenerator:      &fakes.IdGenerator{},
+		Blobs:            blobs,
+		AttachmentLimits: todo.AttachmentLimits{MaxSize: 64, ContentTypes: []string{"text/plain"}},
+	})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+	if _, err := service.CreateTodo(context.Background(), types.TodoUpdate{Summary: "Pick up the groceries"}); err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+	return NewTodoRouter(service, 0, 0)
+}
+
+// upload returns a multipart/form-data body holding content in field and its Content-Type
+func upload(t *testing.T, field string, name string, content string) (string, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, name)
+	if err != nil {
+		t.Fatalf("CreateFormFile() error = %v", err)
+	}
+	_, _ = part.Write([]byte(content))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return body.String(), writer.FormDataContentType()
+}
+
+func TestAttachments(t *testing.T) {
+	router := newAttachmentRouter(t)
+
+	body, contentType := upload(t, "file", "list.txt", "Milk, eggs and bread")
+	w := serve(t, router, http.MethodPost, "/"+firstId+"/attachments", body, map[string]string{"Content-Type": contentType})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	attachment := decode[types.Attachment](t, w)
+
+	w = serve(t, router, http.MethodGet, "/"+firstId+"/attachments/"+attachment.Id, "", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "Milk, eggs and bread" {
+		t.Fatalf("GET = %d %q, want the file", w.Code, w.Body.String())
+	}
+	wantHeaders := map[string]string{
+		"Content-Type":        "text/plain; charset=utf-8",
+		"Content-Disposition": "attachment; filename=list.txt",
+		"ETag":                `"` + attachment.Checksum + `"`,
+	}
+	for header, want := range wantHeaders {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	w = serve(t, router, http.MethodGet, "/"+firstId+"/attachments", "", nil)
+	if list := decode[types.AttachmentList](t, w); w.Code != http.StatusOK || len(list.Attachments) != 1 {
+		t.Errorf("GET list = %d %+v, want the attachment", w.Code, list)
+	}
+
+	tooLarge, tooLargeType := upload(t, "file", "large.txt", string(bytes.Repeat([]byte("a"), 65)))
+	image, imageType := upload(t, "file", "image.gif", "GIF89a")
+	wrongField, wrongFieldType := upload(t, "document", "list.txt", "Milk")
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		contentType string
+		wantStatus  int
+	}{
+		{name: "too large", method: http.MethodPost, target: "/" + firstId + "/attachments", body: tooLarge, contentType: tooLargeType, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "type not allowed", method: http.MethodPost, target: "/" + firstId + "/attachments", body: image, contentType: imageType, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "missing file field", method: http.MethodPost, target: "/" + firstId + "/attachments", body: wrongField, contentType: wrongFieldType, wantStatus: http.StatusBadRequest},
+		{name: "not multipart", method: http.MethodPost, target: "/" + firstId + "/attachments", body: "Milk", contentType: "text/plain", wantStatus: http.StatusBadRequest},
+		{name: "missing todo", method: http.MethodPost, target: "/" + missing + "/attachments", body: image, contentType: imageType, wantStatus: http.StatusNotFound},
+		{name: "delete", method: http.MethodDelete, target: "/" + firstId + "/attachments/" + attachment.Id, wantStatus: http.StatusNoContent},
+		{name: "deleted attachment", method: http.MethodGet, target: "/" + firstId + "/attachments/" + attachment.Id, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers map[string]string
+			if tt.contentType != "" {
+				headers = map[string]string{"Content-Type": tt.contentType}
+			}
+			w := serve(t, router, tt.method, tt.target, tt.body, headers)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+// @openapi
+// paths:
+//
+//	/todos/board:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Get the Todos by status
+//	    description: Returns a column for every status of the workflow holding the first Todos with the status, the next page of a column is listed with GET /todos?status={status}
+//	    operationId: todoBoard
+//	    parameters:
+//	      - name: limit
+//	        in: query
+//	        description: The number of todo items to return per column (defaults to 10), limits above service.maxLimit (100 by default) are lowered to it
+//	        required: false
+//	        schema:
+//	          type: integer
+//	          minimum: 1
+//	      - name: assignee
+//	        in: query
+//	        description: Only return the Todos assigned to this user, me is the user making the request
+//	        required: false
+//	        schema:
+//	          type: string
+//	          minLength: 1
+//	        example: me
+//	      - name: ready
+//	        in: query
+//	        description: Only return the open Todos that aren't blocked by open Todos
+//	        required: false
+//	        schema:
+//	          type: boolean
+//	      - name: count
+//	        in: query
+//	        description: Return the number of Todos of every column, which is expensive on storage that can't count
+//	        required: false
+//	        schema:
+//	          type: boolean
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TodoBoard'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) TodoBoard(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if (err != nil) || limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, t.maxLimit)
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	board, err := t.service.TodoBoard(r.Context(), filter, limit)
+	if err != nil {
+		return err
+	}
+	for _, column := range board.Columns {
+		if err := t.withTrackedTime(r.Context(), column.Todos); err != nil {
+			return err
+		}
+	}
+
+	if query.Get("count") == "true" {
+		for i, column := range board.Columns {
+			filter.Status = column.Status
+			count, err := t.service.CountTodos(r.Context(), filter)
+			if err != nil {
+				return err
+			}
+			board.Columns[i].TotalCount = &count
+		}
+	}
+
+	render.JSON(w, r, board)
+	return nil
+}
@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"todo-service/pkg/types"
+)
+
+func TestTodoBoard(t *testing.T) {
+	router, service := newTestRouter(t, 3)
+	if _, err := service.CreateTodo(context.Background(), types.TodoUpdate{Summary: "started", Status: "in_progress"}); err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+
+	w := serve(t, router, http.MethodGet, "/board?limit=2&count=true", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	board := decode[types.TodoBoard](t, w)
+	want := map[string]int{"todo": 3, "in_progress": 1, "blocked": 0, "done": 0}
+	if len(board.Columns) != len(want) {
+		t.Fatalf("got %d columns, want %d", len(board.Columns), len(want))
+	}
+	for _, column := range board.Columns {
+		if column.TotalCount == nil || *column.TotalCount != want[column.Status] || len(column.Todos) != min(want[column.Status], 2) {
+			t.Errorf("column %s has %d todos counting %v, want %d", column.Status, len(column.Todos), column.TotalCount, want[column.Status])
+		}
+	}
+
+	// The next page of a column is listed with the status filter
+	todo := board.Columns[0]
+	w = serve(t, router, http.MethodGet, "/?status=todo&limit=2&next="+todo.Next, "", nil)
+	if list := decode[types.TodoList](t, w); w.Code != http.StatusOK || len(list.Todos) != 1 || list.Todos[0].Status != "todo" {
+		t.Errorf("next page of the todo column = %d %s, want the third todo", w.Code, w.Body.String())
+	}
+}
+
+func TestStatusTransitionsOverHTTP(t *testing.T) {
+	router, _ := newTestRouter(t, 1)
+	headers := map[string]string{"Content-Type": "application/merge-patch+json"}
+
+	if w := serve(t, router, http.MethodPatch, "/"+firstId, `{"status": "blocked"}`, headers); w.Code != http.StatusNoContent {
+		t.Fatalf("blocking status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+	if w := serve(t, router, http.MethodPatch, "/"+firstId, `{"status": "done"}`, headers); w.Code != http.StatusBadRequest {
+		t.Errorf("finishing a blocked todo status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	w := serve(t, router, http.MethodPut, "/"+firstId, `{"summary": "unblocked", "done": false, "status": "in_progress"}`, map[string]string{"Content-Type": "application/json"})
+	if w.Code != http.StatusNoContent {
+		t.Errorf("replacing status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+	if w := serve(t, router, http.MethodGet, "/?status=unknown", "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("listing an unknown status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,530 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/caldav"
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/features/transfer"
+	"todo-service/pkg/ical"
+	serviceMiddlewares "todo-service/pkg/middlewares"
+	"todo-service/pkg/types"
+)
+
+const (
+	calendarCollection = "todos"
+	// maxCalendarObjectSize is the maximum size of a calendar object uploaded with PUT
+	maxCalendarObjectSize = 1024 * 1024
+	calendarObjectType    = "text/calendar; charset=utf-8; component=VTODO"
+	// listPageSize is the number of todos read from storage at a time when listing the collection
+	listPageSize = 100
+)
+
+// CalDAVRouter serves todos as a CalDAV (RFC 4791) calendar collection so calendar and reminder
+// apps can sync them both ways. The server root is also the principal and its calendar home, it
+// holds a single calendar collection with a VTODO calendar object per todo:
+//
+//	{basePath}                 principal and calendar home
+//	{basePath}/todos           calendar collection
+//	{basePath}/todos/{id}.ics  calendar object
+//
+// Collections are addressed without a trailing slash since the server redirects paths ending with
+// one.
+type CalDAVRouter struct {
+	Router   *chi.Mux
+	service  todo.TodoService
+	basePath string
+}
+
+// davResource is a resource properties are read from
+type davResource struct {
+	href string
+	// todo is set for calendar objects
+	todo *types.Todo
+	// ctag is set for the calendar collection
+	ctag string
+}
+
+func NewCalDAVRouter(service todo.TodoService, basePath string) *CalDAVRouter {
+	chi.RegisterMethod("PROPFIND")
+	chi.RegisterMethod("REPORT")
+
+	c := CalDAVRouter{service: service, basePath: strings.TrimSuffix(basePath, "/")}
+	h := serviceMiddlewares.ErrorHandler{}
+
+	router := chi.NewRouter()
+	router.Options("/*", c.Options)
+	router.MethodFunc("PROPFIND", "/", h.Wrap(c.PropFindHome))
+	router.MethodFunc("PROPFIND", "/"+calendarCollection, h.Wrap(c.PropFindCalendar))
+	router.MethodFunc("PROPFIND", "/"+calendarCollection+"/{name}", h.Wrap(c.PropFindObject))
+	router.MethodFunc("REPORT", "/"+calendarCollection, h.Wrap(c.Report))
+	router.Get("/"+calendarCollection+"/{name}", h.Wrap(c.GetObject))
+	router.Head("/"+calendarCollection+"/{name}", h.Wrap(c.GetObject))
+	router.Put("/"+calendarCollection+"/{name}", h.Wrap(c.PutObject))
+	router.Delete("/"+calendarCollection+"/{name}", h.Wrap(c.DeleteObject))
+
+	c.Router = router
+
+	return &c
+}
+
+func (c *CalDAVRouter) Options(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	w.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PropFindHome answers PROPFIND requests for the principal, which is also the calendar home
+func (c *CalDAVRouter) PropFindHome(w http.ResponseWriter, r *http.Request) error {
+	resources := []davResource{{href: c.basePath}}
+	// Depth infinity is treated as 1, which covers every resource anyway
+	if r.Header.Get("Depth") != "0" {
+		todos, err := c.allTodos(r.Context())
+		if err != nil {
+			return err
+		}
+		resources = append(resources, c.calendar(todos))
+	}
+	return c.propFind(w, r, resources)
+}
+
+// PropFindCalendar answers PROPFIND requests for the calendar collection
+func (c *CalDAVRouter) PropFindCalendar(w http.ResponseWriter, r *http.Request) error {
+	todos, err := c.allTodos(r.Context())
+	if err != nil {
+		return err
+	}
+	resources := []davResource{c.calendar(todos)}
+	if r.Header.Get("Depth") != "0" {
+		for _, t := range todos {
+			resources = append(resources, c.object(t))
+		}
+	}
+	return c.propFind(w, r, resources)
+}
+
+// PropFindObject answers PROPFIND requests for a calendar object
+func (c *CalDAVRouter) PropFindObject(w http.ResponseWriter, r *http.Request) error {
+	t, err := c.getObject(r)
+	if err != nil {
+		return err
+	}
+	return c.propFind(w, r, []davResource{c.object(t)})
+}
+
+func (c *CalDAVRouter) propFind(w http.ResponseWriter, r *http.Request, resources []davResource) error {
+	propFind, err := caldav.ReadPropFind(r.Body)
+	if err != nil {
+		return &serviceErrors.BadRequest{Message: err.Error()}
+	}
+
+	multistatus := caldav.Multistatus{}
+	for _, resource := range resources {
+		multistatus.Responses = append(multistatus.Responses, caldav.Response{
+			Href:      resource.href,
+			Propstats: c.propstats(resource, propFind.Prop, propFind.AllProp != nil, propFind.PropName != nil),
+		})
+	}
+	return writeMultistatus(w, multistatus)
+}
+
+// Report answers calendar-query and calendar-multiget reports on the calendar collection
+func (c *CalDAVRouter) Report(w http.ResponseWriter, r *http.Request) error {
+	report, err := caldav.ReadReport(r.Body)
+	if err != nil {
+		return &serviceErrors.BadRequest{Message: err.Error()}
+	}
+
+	multistatus := caldav.Multistatus{}
+	respond := func(t types.Todo) {
+		multistatus.Responses = append(multistatus.Responses, caldav.Response{
+			Href:      c.objectHref(t.Id),
+			Propstats: c.propstats(c.object(t), report.Prop, report.AllProp != nil || report.Prop == nil, false),
+		})
+	}
+
+	switch report.XMLName {
+	case caldav.CalendarQuery:
+		todos, err := c.allTodos(r.Context())
+		if err != nil {
+			return err
+		}
+		for _, t := range todos {
+			if report.Filter != nil {
+				matched, err := report.Filter.Match(calendarObject(t))
+				if err != nil {
+					return &serviceErrors.BadRequest{Message: err.Error()}
+				}
+				if !matched {
+					continue
+				}
+			}
+			respond(t)
+		}
+	case caldav.CalendarMultiget:
+		for _, href := range report.Hrefs {
+			id, ok := c.hrefId(href)
+			if !ok {
+				multistatus.Responses = append(multistatus.Responses, caldav.Response{Href: href, Status: caldav.Status(http.StatusNotFound)})
+				continue
+			}
+			t, err := c.service.GetTodo(r.Context(), id)
+			if errors.Is(err, storage.ErrNotFound) {
+				multistatus.Responses = append(multistatus.Responses, caldav.Response{Href: href, Status: caldav.Status(http.StatusNotFound)})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			respond(t)
+		}
+	default:
+		return writeDAVError(w, http.StatusForbidden, xml.Name{Space: caldav.NamespaceDAV, Local: "supported-report"})
+	}
+
+	return writeMultistatus(w, multistatus)
+}
+
+func (c *CalDAVRouter) GetObject(w http.ResponseWriter, r *http.Request) error {
+	t, err := c.getObject(r)
+	if err != nil {
+		return err
+	}
+
+	data := bytes.Buffer{}
+	if err := ical.NewWriter(&data).WriteComponent(calendarObject(t)); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", calendarObjectType)
+	w.Header().Set("ETag", caldav.ETag(t))
+	w.Header().Set("Last-Modified", t.UpdatedAt.UTC().Format(http.TimeFormat))
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	_, err = w.Write(data.Bytes())
+	return err
+}
+
+// PutObject creates or updates the todo of a calendar object. Clients pick the names of the objects
+// they create, which must be todo ids (UUIDs) so the object can be found again under the same name.
+// Uppercase UUIDs are lowercased, the name the todo is served under is returned in the Location
+// header of created objects.
+func (c *CalDAVRouter) PutObject(w http.ResponseWriter, r *http.Request) error {
+	mediaType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";")
+	if strings.TrimSpace(mediaType) != transfer.ICS.ContentType() {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return nil
+	}
+
+	calendars, err := ical.Parse(http.MaxBytesReader(w, r.Body, maxCalendarObjectSize))
+	if err != nil {
+		return writeDAVError(w, http.StatusBadRequest, xml.Name{Space: caldav.NamespaceCalDAV, Local: "valid-calendar-data"})
+	}
+	var component *ical.Component
+	for _, calendar := range calendars {
+		for _, sub := range calendar.Components {
+			if sub.Name == "VTODO" {
+				component = sub
+			}
+		}
+	}
+	if component == nil {
+		return writeDAVError(w, http.StatusForbidden, xml.Name{Space: caldav.NamespaceCalDAV, Local: "supported-calendar-component"})
+	}
+	update, err := transfer.FromComponent(component)
+	if err != nil || strings.TrimSpace(update.Summary) == "" {
+		return writeDAVError(w, http.StatusForbidden, xml.Name{Space: caldav.NamespaceCalDAV, Local: "valid-calendar-object-resource"})
+	}
+
+	name := chi.URLParam(r, "name")
+	id, ok := objectId(name)
+	if !ok {
+		return &serviceErrors.BadRequest{Message: "calendar objects must be named after a todo id, a UUID followed by .ics"}
+	}
+
+	current, err := c.service.GetTodo(r.Context(), id)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	exists := err == nil
+
+	ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	if (ifMatch != "" && (!exists || !caldav.MatchETag(ifMatch, caldav.ETag(current)))) ||
+		(ifNoneMatch != "" && exists && caldav.MatchETag(ifNoneMatch, caldav.ETag(current))) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return nil
+	}
+
+	if exists {
+		current.Summary = update.Summary
+		current.Done = update.Done
+		current.Due = update.Due
+		current.Priority = update.Priority
+		if err := c.service.UpdateTodo(r.Context(), current); err != nil {
+			return err
+		}
+		updated, err := c.service.GetTodo(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("ETag", caldav.ETag(updated))
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	// Timestamps are set by the service, not the client
+	update.Id, update.CreatedAt, update.UpdatedAt = id, time.Time{}, time.Time{}
+	created, action, err := c.service.ImportTodo(r.Context(), update, todo.ImportOptions{Conflict: todo.ConflictSkip})
+	if err != nil {
+		return err
+	}
+	if action != todo.ImportCreated {
+		// Another request created the todo in the meantime
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return nil
+	}
+	// The stored todo is read back since databases keep timestamps at a lower precision than the
+	// returned one, which the ETag is computed from
+	stored, err := c.service.GetTodo(r.Context(), created.Id)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", c.objectHref(stored.Id))
+	w.Header().Set("ETag", caldav.ETag(stored))
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+func (c *CalDAVRouter) DeleteObject(w http.ResponseWriter, r *http.Request) error {
+	t, err := c.getObject(r)
+	if err != nil {
+		return err
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && !caldav.MatchETag(ifMatch, caldav.ETag(t)) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return nil
+	}
+	if err := c.service.DeleteTodo(r.Context(), t.Id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// propstats returns the requested properties of resource grouped by whether they were found
+func (c *CalDAVRouter) propstats(resource davResource, requested *caldav.PropNames, all bool, namesOnly bool) []caldav.Propstat {
+	names := []xml.Name{}
+	if all || namesOnly || requested == nil {
+		names = c.allProps(resource)
+	} else {
+		for _, name := range requested.Names {
+			names = append(names, name.XMLName)
+		}
+	}
+
+	found, missing := caldav.Prop{}, caldav.Prop{}
+	for _, name := range names {
+		value, ok := c.property(resource, name)
+		if namesOnly {
+			value = ""
+		}
+		if ok {
+			found.Properties = append(found.Properties, caldav.Property{XMLName: name, Inner: value})
+		} else {
+			missing.Properties = append(missing.Properties, caldav.Property{XMLName: name})
+		}
+	}
+
+	propstats := []caldav.Propstat{}
+	if len(found.Properties) > 0 {
+		propstats = append(propstats, caldav.Propstat{Prop: found, Status: caldav.Status(http.StatusOK)})
+	}
+	if len(missing.Properties) > 0 {
+		propstats = append(propstats, caldav.Propstat{Prop: missing, Status: caldav.Status(http.StatusNotFound)})
+	}
+	return propstats
+}
+
+// allProps returns the properties returned for allprop requests, calendar-data is left out as
+// RFC 4791 requires
+func (c *CalDAVRouter) allProps(resource davResource) []xml.Name {
+	switch {
+	case resource.todo != nil:
+		return []xml.Name{caldav.ResourceType, caldav.GetETag, caldav.GetContentType, caldav.GetLastModified}
+	case resource.ctag != "":
+		return []xml.Name{caldav.ResourceType, caldav.DisplayName, caldav.GetCTag, caldav.GetETag, caldav.SupportedCalendarComponentSet, caldav.SupportedReportSet, caldav.CurrentUserPrincipal, caldav.CurrentUserPrivilegeSet}
+	default:
+		return []xml.Name{caldav.ResourceType, caldav.DisplayName, caldav.CurrentUserPrincipal, caldav.PrincipalURL, caldav.CalendarHomeSet}
+	}
+}
+
+func (c *CalDAVRouter) property(resource davResource, name xml.Name) (string, bool) {
+	collection := caldav.Element(xml.Name{Space: caldav.NamespaceDAV, Local: "collection"})
+
+	switch name {
+	case caldav.CurrentUserPrincipal, caldav.PrincipalURL, caldav.CalendarHomeSet:
+		return caldav.Href(c.basePath), resource.todo == nil
+	}
+
+	if t := resource.todo; t != nil {
+		switch name {
+		case caldav.ResourceType:
+			return "", true
+		case caldav.GetETag:
+			return caldav.Text(caldav.ETag(*t)), true
+		case caldav.GetContentType:
+			return caldav.Text(calendarObjectType), true
+		case caldav.GetLastModified:
+			return caldav.Text(t.UpdatedAt.UTC().Format(http.TimeFormat)), true
+		case caldav.CalendarData:
+			data := bytes.Buffer{}
+			if err := ical.NewWriter(&data).WriteComponent(calendarObject(*t)); err != nil {
+				slog.Error("failed to write calendar data", slog.String("id", t.Id), slog.Any("error", err))
+				return "", false
+			}
+			return caldav.Text(data.String()), true
+		}
+		return "", false
+	}
+
+	if resource.ctag != "" {
+		switch name {
+		case caldav.ResourceType:
+			return collection + caldav.Element(xml.Name{Space: caldav.NamespaceCalDAV, Local: "calendar"}), true
+		case caldav.DisplayName:
+			return caldav.Text(transfer.CalendarName), true
+		case caldav.GetCTag, caldav.GetETag:
+			return caldav.Text(resource.ctag), true
+		case caldav.SupportedCalendarComponentSet:
+			return caldav.Element(xml.Name{Space: caldav.NamespaceCalDAV, Local: "comp"}, xml.Attr{Name: xml.Name{Local: "name"}, Value: "VTODO"}), true
+		case caldav.SupportedReportSet:
+			reports := ""
+			for _, report := range []xml.Name{caldav.CalendarQuery, caldav.CalendarMultiget} {
+				reports += `<supported-report xmlns="DAV:"><report xmlns="DAV:">` + caldav.Element(report) + `</report></supported-report>`
+			}
+			return reports, true
+		case caldav.CurrentUserPrivilegeSet:
+			privileges := ""
+			for _, privilege := range []string{"read", "write", "write-content", "write-properties", "bind", "unbind"} {
+				privileges += `<privilege xmlns="DAV:">` + caldav.Element(xml.Name{Space: caldav.NamespaceDAV, Local: privilege}) + `</privilege>`
+			}
+			return privileges, true
+		}
+		return "", false
+	}
+
+	switch name {
+	case caldav.ResourceType:
+		return collection, true
+	case caldav.DisplayName:
+		return caldav.Text(transfer.CalendarName), true
+	}
+	return "", false
+}
+
+func (c *CalDAVRouter) calendar(todos []types.Todo) davResource {
+	return davResource{href: c.basePath + "/" + calendarCollection, ctag: caldav.CTag(todos)}
+}
+
+func (c *CalDAVRouter) object(t types.Todo) davResource {
+	return davResource{href: c.objectHref(t.Id), todo: &t}
+}
+
+func (c *CalDAVRouter) objectHref(id string) string {
+	return c.basePath + "/" + calendarCollection + "/" + url.PathEscape(id) + ".ics"
+}
+
+// hrefId returns the id of the todo a calendar object href refers to
+func (c *CalDAVRouter) hrefId(href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(u.Path, c.basePath+"/"+calendarCollection+"/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return objectId(name)
+}
+
+// allTodos returns every todo, the calendar collection needs all of them to compute its ctag
+func (c *CalDAVRouter) allTodos(ctx context.Context) ([]types.Todo, error) {
+	todos := []types.Todo{}
+	cursor := ""
+	for {
+		page, next, err := c.service.ListTodos(ctx, listPageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, page...)
+		if next == "" {
+			return todos, nil
+		}
+		cursor = next
+	}
+}
+
+// getObject returns the todo of the calendar object a request is for
+func (c *CalDAVRouter) getObject(r *http.Request) (types.Todo, error) {
+	id, ok := objectId(chi.URLParam(r, "name"))
+	if !ok {
+		return types.Todo{}, &serviceErrors.NotFound{Message: "calendar object not found"}
+	}
+	return c.service.GetTodo(r.Context(), id)
+}
+
+// objectId returns the todo id of a calendar object name, ok is false when the name isn't a todo
+// id. Ids are UUIDs, which are matched whatever their case.
+func objectId(name string) (id string, ok bool) {
+	parsed, err := uuid.Parse(strings.TrimSuffix(name, ".ics"))
+	if err != nil {
+		return "", false
+	}
+	return parsed.String(), true
+}
+
+// calendarObject returns the calendar object resource of a todo, a VCALENDAR holding its VTODO
+func calendarObject(t types.Todo) *ical.Component {
+	calendar := ical.NewCalendar("")
+	calendar.Components = append(calendar.Components, transfer.ToVTodo(t))
+	return calendar
+}
+
+func writeMultistatus(w http.ResponseWriter, multistatus caldav.Multistatus) error {
+	body, err := xml.Marshal(multistatus)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	_, err = io.WriteString(w, xml.Header+string(body))
+	return err
+}
+
+// writeDAVError answers a request that failed a WebDAV or CalDAV precondition
+func writeDAVError(w http.ResponseWriter, status int, condition xml.Name) error {
+	body, err := xml.Marshal(caldav.Error{Condition: caldav.Property{XMLName: condition}})
+	if err != nil {
+		return fmt.Errorf("failed to write DAV error: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	_, err = io.WriteString(w, xml.Header+string(body))
+	return err
+}
@@ -0,0 +1,262 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo-service/pkg/caldav"
+)
+
+func serveDAV(router *CalDAVRouter, method string, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	router.Router.ServeHTTP(w, req)
+	return w
+}
+
+func newTestCalDAVRouter(t *testing.T, count int) *CalDAVRouter {
+	t.Helper()
+	_, service := newTestRouter(t, count)
+	return NewCalDAVRouter(service, "/caldav")
+}
+
+func TestCalDAVPropFind(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		depth        string
+		body         string
+		wantStatus   int
+		wantHrefs    int
+		wantContains []string
+	}{
+		{
+			name:         "principal",
+			target:       "/",
+			depth:        "0",
+			body:         `<propfind xmlns="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><prop><current-user-principal/><C:calendar-home-set/></prop></propfind>`,
+			wantStatus:   http.StatusMultiStatus,
+			wantHrefs:    1,
+			wantContains: []string{"<current-user-principal xmlns=\"DAV:\"><href xmlns=\"DAV:\">/caldav</href>"},
+		},
+		{
+			name:         "calendar home",
+			target:       "/",
+			depth:        "1",
+			wantStatus:   http.StatusMultiStatus,
+			wantHrefs:    2,
+			wantContains: []string{"<href>/caldav/todos</href>", `<calendar xmlns="urn:ietf:params:xml:ns:caldav"/>`},
+		},
+		{
+			name:         "calendar collection",
+			target:       "/todos",
+			depth:        "1",
+			body:         `<propfind xmlns="DAV:" xmlns:CS="http://calendarserver.org/ns/"><prop><getetag/><CS:getctag/><displayname/><quota-used-bytes/></prop></propfind>`,
+			wantStatus:   http.StatusMultiStatus,
+			wantHrefs:    3,
+			wantContains: []string{"<href>/caldav/todos/" + firstId + ".ics</href>", "<getctag xmlns=\"http://calendarserver.org/ns/\">", "HTTP/1.1 404 Not Found"},
+		},
+		{
+			name:         "calendar object",
+			target:       "/todos/" + firstId + ".ics",
+			body:         `<propfind xmlns="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><prop><getetag/><C:calendar-data/></prop></propfind>`,
+			wantStatus:   http.StatusMultiStatus,
+			wantHrefs:    1,
+			wantContains: []string{"BEGIN:VTODO", "UID:" + firstId},
+		},
+		{name: "missing object", target: "/todos/" + missing + ".ics", wantStatus: http.StatusNotFound},
+		{name: "invalid body", target: "/", body: "<propfind", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestCalDAVRouter(t, 2)
+			w := serveDAV(router, "PROPFIND", tt.target, tt.body, map[string]string{"Depth": tt.depth})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusMultiStatus {
+				return
+			}
+			body := w.Body.String()
+			if got := strings.Count(body, "<response>"); got != tt.wantHrefs {
+				t.Errorf("got %d responses, want %d: %s", got, tt.wantHrefs, body)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(body, want) {
+					t.Errorf("response %s doesn't contain %q", body, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalDAVReport(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantHrefs  int
+	}{
+		{
+			name:       "calendar-query",
+			body:       `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/><C:calendar-data/></D:prop><C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"/></C:comp-filter></C:filter></C:calendar-query>`,
+			wantStatus: http.StatusMultiStatus,
+			wantHrefs:  2,
+		},
+		{
+			name:       "calendar-query for events",
+			body:       `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/></D:prop><C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT"/></C:comp-filter></C:filter></C:calendar-query>`,
+			wantStatus: http.StatusMultiStatus,
+		},
+		{
+			name:       "calendar-multiget",
+			body:       `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/></D:prop><D:href>/caldav/todos/` + firstId + `.ics</D:href><D:href>http://localhost/caldav/todos/` + missing + `.ics</D:href></C:calendar-multiget>`,
+			wantStatus: http.StatusMultiStatus,
+			wantHrefs:  2,
+		},
+		{
+			name:       "unsupported report",
+			body:       `<D:sync-collection xmlns:D="DAV:"><D:sync-token/></D:sync-collection>`,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestCalDAVRouter(t, 2)
+			w := serveDAV(router, "REPORT", "/todos", tt.body, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := strings.Count(w.Body.String(), "<response>"); tt.wantStatus == http.StatusMultiStatus && got != tt.wantHrefs {
+				t.Errorf("got %d responses, want %d: %s", got, tt.wantHrefs, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCalDAVSync(t *testing.T) {
+	_, service := newTestRouter(t, 1)
+	router := NewCalDAVRouter(service, "/caldav")
+	ctx := context.Background()
+	calendarHeaders := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+	object := func(uid string, summary string, status string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:" + uid + "\r\nSUMMARY:" + summary + "\r\nSTATUS:" + status + "\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	}
+
+	current, err := service.GetTodo(ctx, firstId)
+	if err != nil {
+		t.Fatalf("GetTodo() error = %v", err)
+	}
+	etag := caldav.ETag(current)
+
+	w := serveDAV(router, http.MethodGet, "/todos/"+firstId+".ics", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != etag || !strings.Contains(w.Body.String(), "SUMMARY:Pick up the groceries") {
+		t.Fatalf("GET = %d %q %s, want the todo with ETag %s", w.Code, w.Header().Get("ETag"), w.Body.String(), etag)
+	}
+
+	// Updates must match the current ETag
+	w = serveDAV(router, http.MethodPut, "/todos/"+firstId+".ics", object(firstId, "changed", "COMPLETED"), map[string]string{"Content-Type": "text/calendar", "If-Match": `"stale"`})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("PUT with a stale ETag = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	w = serveDAV(router, http.MethodPut, "/todos/"+firstId+".ics", object(firstId, "changed", "COMPLETED"), map[string]string{"Content-Type": "text/calendar", "If-Match": etag})
+	if w.Code != http.StatusNoContent || w.Header().Get("ETag") == "" {
+		t.Fatalf("PUT = %d %s, want %d with an ETag", w.Code, w.Body.String(), http.StatusNoContent)
+	}
+	if got, err := service.GetTodo(ctx, firstId); err != nil || got.Summary != "changed" || !got.Done || got.CompletedAt == nil {
+		t.Errorf("GetTodo() after PUT = %+v, %v, want a completed todo named changed", got, err)
+	}
+
+	// Creating a todo under a client chosen name
+	created := "0190a0e4-0000-7000-8000-000000000042"
+	w = serveDAV(router, http.MethodPut, "/todos/"+created+".ics", object(created, "new", "NEEDS-ACTION"), map[string]string{"Content-Type": "text/calendar", "If-None-Match": "*"})
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/caldav/todos/"+created+".ics" {
+		t.Fatalf("PUT of a new object = %d %q %s, want %d with its Location", w.Code, w.Header().Get("Location"), w.Body.String(), http.StatusCreated)
+	}
+	createdETag := w.Header().Get("ETag")
+	if w = serveDAV(router, http.MethodGet, "/todos/"+created+".ics", "", nil); w.Header().Get("ETag") != createdETag {
+		t.Errorf("GET of a new object has ETag %q, want the %q returned when it was created", w.Header().Get("ETag"), createdETag)
+	}
+	w = serveDAV(router, http.MethodPut, "/todos/"+created+".ics", object(created, "new", "NEEDS-ACTION"), map[string]string{"Content-Type": "text/calendar", "If-None-Match": "*"})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT of an existing object with If-None-Match = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+
+	// Names that aren't todo ids are refused, uppercase ids are the same todo as lowercase ones
+	w = serveDAV(router, http.MethodPut, "/todos/reminder-1.ics", object("reminder-1", "other", "NEEDS-ACTION"), calendarHeaders)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("PUT of an object that isn't named after an id = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	uppercase := "0190A0E4-0000-7000-8000-0000000000AB"
+	w = serveDAV(router, http.MethodPut, "/todos/"+uppercase+".ics", object(uppercase, "other", "NEEDS-ACTION"), calendarHeaders)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/caldav/todos/"+strings.ToLower(uppercase)+".ics" {
+		t.Errorf("PUT of an object named after an uppercase id = %d %q, want %d with the lowercase Location", w.Code, w.Header().Get("Location"), http.StatusCreated)
+	}
+	w = serveDAV(router, http.MethodPut, "/todos/"+uppercase+".ics", object(uppercase, "changed", "NEEDS-ACTION"), calendarHeaders)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("PUT of an existing object named after an uppercase id = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	w = serveDAV(router, http.MethodGet, "/todos/"+uppercase+".ics", "", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "SUMMARY:changed") {
+		t.Errorf("GET of an object named after an uppercase id = %d %s, want the changed todo", w.Code, w.Body.String())
+	}
+	if w = serveDAV(router, http.MethodGet, "/todos/reminder-1.ics", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET of an object that isn't named after an id = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	w = serveDAV(router, http.MethodPut, "/todos/"+created+".ics", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:event\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n", calendarHeaders)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("PUT of an event = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	w = serveDAV(router, http.MethodPut, "/todos/"+created+".ics", object(created, "new", "NEEDS-ACTION"), map[string]string{"Content-Type": "application/json"})
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("PUT of JSON = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+
+	w = serveDAV(router, http.MethodDelete, "/todos/"+created+".ics", "", map[string]string{"If-Match": `"stale"`})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with a stale ETag = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	w = serveDAV(router, http.MethodDelete, "/todos/"+created+".ics", "", nil)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("DELETE = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	w = serveDAV(router, http.MethodGet, "/todos/"+created+".ics", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET of a deleted object = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestCalDAVCTagChanges(t *testing.T) {
+	_, service := newTestRouter(t, 2)
+	router := NewCalDAVRouter(service, "/caldav")
+	ctag := func() string {
+		w := serveDAV(router, "PROPFIND", "/todos", `<propfind xmlns="DAV:" xmlns:CS="http://calendarserver.org/ns/"><prop><CS:getctag/></prop></propfind>`, map[string]string{"Depth": "0"})
+		_, after, _ := strings.Cut(w.Body.String(), `<getctag xmlns="http://calendarserver.org/ns/">`)
+		value, _, _ := strings.Cut(after, "<")
+		return value
+	}
+
+	before := ctag()
+	if err := service.DeleteTodo(context.Background(), secondId); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+	if after := ctag(); after == before || after == "" {
+		t.Errorf("ctag = %q after a delete, want it to change from %q", after, before)
+	}
+}
+
+func TestCalDAVOptions(t *testing.T) {
+	w := serveDAV(newTestCalDAVRouter(t, 0), http.MethodOptions, "/todos", "", nil)
+	if !strings.Contains(w.Header().Get("DAV"), "calendar-access") {
+		t.Errorf("DAV header = %q, want calendar-access", w.Header().Get("DAV"))
+	}
+}
@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/features/transfer"
+	serviceMiddlewares "todo-service/pkg/middlewares"
+)
+
+// CalendarRouter serves todos as an iCalendar feed calendar apps can subscribe to. Calendar apps
+// can't send credentials so feeds are protected by a secret token in the feed URL. The token only
+// keeps the feed URL secret, the other routes serving todos rely on the authenticating proxy in
+// front of the service.
+type CalendarRouter struct {
+	Router  *chi.Mux
+	service todo.TodoService
+	tokens  []string
+}
+
+// NewCalendarRouter creates a router serving the feed to requests carrying one of tokens, when no
+// tokens are configured the feed is disabled
+func NewCalendarRouter(service todo.TodoService, tokens []string) *CalendarRouter {
+	c := CalendarRouter{service: service, tokens: tokens}
+	h := serviceMiddlewares.ErrorHandler{}
+
+	router := chi.NewRouter()
+	router.Get("/", h.Wrap(c.Feed))
+
+	c.Router = router
+
+	return &c
+}
+
+// @openapi
+// paths:
+//
+//	/todos.ics:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Subscribe to Todos
+//	    description: Returns all Todos as an iCalendar (RFC 5545) feed of VTODO components
+//	    operationId: todosFeed
+//	    parameters:
+//	      - name: token
+//	        in: query
+//	        description: One of the feed tokens configured in calendar.tokens, which only keep the feed URL secret and don't protect the rest of the API
+//	        required: true
+//	        allowEmptyValue: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          text/calendar:
+//	            schema:
+//	              type: string
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (c *CalendarRouter) Feed(w http.ResponseWriter, r *http.Request) error {
+	if !c.authorized(r.URL.Query().Get("token")) {
+		// Not found rather than unauthorized so feed URLs can't be probed
+		return &errors.NotFound{Message: "calendar feed not found"}
+	}
+
+	w.Header().Set("Content-Type", transfer.ICS.ContentType()+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="todos.ics"`)
+
+	tracker := &writeTracker{ResponseWriter: w}
+	err := transfer.Export(r.Context(), c.service, transfer.ICS, tracker)
+	if err != nil && tracker.written {
+		slog.Error("failed to write calendar feed", slog.Any("error", err))
+		return nil
+	}
+	return err
+}
+
+func (c *CalendarRouter) authorized(token string) bool {
+	if token == "" {
+		return false
+	}
+	authorized := false
+	for _, t := range c.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo-service/pkg/openapi/openapitest"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokens     []string
+		target     string
+		wantStatus int
+	}{
+		{name: "valid token", tokens: []string{"other", "secret"}, target: "/?token=secret", wantStatus: http.StatusOK},
+		{name: "invalid token", tokens: []string{"secret"}, target: "/?token=guess", wantStatus: http.StatusNotFound},
+		{name: "no tokens configured", target: "/?token=secret", wantStatus: http.StatusNotFound},
+		{name: "empty token", tokens: []string{"secret"}, target: "/?token=", wantStatus: http.StatusNotFound},
+		{name: "missing token", tokens: []string{"secret"}, target: "/", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, service := newTestRouter(t, 2)
+			router := NewCalendarRouter(service, tt.tokens)
+
+			w := httptest.NewRecorder()
+			openapitest.Mount(t, "/todos.ics", router.Router).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos.ics"+strings.TrimPrefix(tt.target, "/"), nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/calendar") {
+				t.Errorf("Content-Type = %q, want text/calendar", contentType)
+			}
+			body := w.Body.String()
+			for _, want := range []string{"BEGIN:VCALENDAR\r\n", "UID:" + firstId + "\r\n", "UID:" + secondId + "\r\n", "STATUS:NEEDS-ACTION\r\n", "END:VCALENDAR\r\n"} {
+				if !strings.Contains(body, want) {
+					t.Errorf("feed %q doesn't contain %q", body, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"todo-service/pkg/types"
+)
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/comments:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Get the Comments of a Todo
+//	    description: Returns a page of the Comments on the Todo with the identifier {id}, the oldest first
+//	    operationId: listComments
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: limit
+//	        in: query
+//	        description: The number of comments to return (defaults to 10), limits above service.maxLimit (100 by default) are lowered to it
+//	        required: false
+//	        schema:
+//	          type: integer
+//	          minimum: 1
+//	      - name: next
+//	        in: query
+//	        description: The next page identifier
+//	        required: false
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/CommentList'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) ListComments(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if (err != nil) || limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, t.maxLimit)
+
+	comments, next, err := t.service.ListComments(r.Context(), chi.URLParam(r, "id"), limit, query.Get("next"))
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, types.CommentList{Comments: comments, Next: next})
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/comments:
+//	  post:
+//	    tags:
+//	      - todos
+//	    summary: Comment on a Todo
+//	    description: Adds a Comment to the Todo with the identifier {id}, written by the user named by the service.actorHeader header
+//	    operationId: createComment
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	    requestBody:
+//	      description: The new Comment
+//	      required: true
+//	      content:
+//	        application/json:
+//	          schema:
+//	            $ref: '#/components/schemas/CommentUpdate'
+//	    responses:
+//	      '201':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Comment'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) CreateComment(w http.ResponseWriter, r *http.Request) error {
+	var commentToCreate types.CommentUpdate
+	if err := json.NewDecoder(r.Body).Decode(&commentToCreate); err != nil {
+		return err
+	}
+
+	comment, err := t.service.CreateComment(r.Context(), chi.URLParam(r, "id"), commentToCreate)
+	if err != nil {
+		return err
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, comment)
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/comments/{comment}:
+//	  put:
+//	    tags:
+//	      - todos
+//	    summary: Edit a Comment
+//	    description: Replaces the body of the Comment {comment} on the Todo with the identifier {id}, the Comment keeps its author
+//	    operationId: updateComment
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: comment
+//	        in: path
+//	        description: The identifier of the Comment
+//	        required: true
+//	        schema:
+//	          type: string
+//	    requestBody:
+//	      description: The edited Comment
+//	      required: true
+//	      content:
+//	        application/json:
+//	          schema:
+//	            $ref: '#/components/schemas/CommentUpdate'
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Comment'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) UpdateComment(w http.ResponseWriter, r *http.Request) error {
+	var commentToUpdate types.CommentUpdate
+	if err := json.NewDecoder(r.Body).Decode(&commentToUpdate); err != nil {
+		return err
+	}
+
+	comment, err := t.service.UpdateComment(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "comment"), commentToUpdate)
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, comment)
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/comments/{comment}:
+//	  delete:
+//	    tags:
+//	      - todos
+//	    summary: Delete a Comment
+//	    description: Deletes the Comment {comment} on the Todo with the identifier {id} if exists
+//	    operationId: deleteComment
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: comment
+//	        in: path
+//	        description: The identifier of the Comment
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '204':
+//	        description: successful operation
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) DeleteComment(w http.ResponseWriter, r *http.Request) error {
+	if err := t.service.DeleteComment(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "comment")); err != nil {
+		return err
+	}
+	render.NoContent(w, r)
+	return nil
+}
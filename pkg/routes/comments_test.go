@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"todo-service/pkg/types"
+)
+
+func TestComments(t *testing.T) {
+	router, _ := newTestRouter(t, 2)
+	jsonContent := map[string]string{"Content-Type": "application/json"}
+
+	w := serve(t, router, http.MethodPost, "/"+firstId+"/comments", `{"body": "Check the **opening hours** first"}`, jsonContent)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	comment := decode[types.Comment](t, w)
+
+	w = serve(t, router, http.MethodPut, "/"+firstId+"/comments/"+comment.Id, `{"body": "Check the opening hours"}`, jsonContent)
+	if edited := decode[types.Comment](t, w); w.Code != http.StatusOK || edited.Body != "Check the opening hours" {
+		t.Errorf("PUT = %d %+v, want the edited comment", w.Code, edited)
+	}
+
+	w = serve(t, router, http.MethodGet, "/"+firstId+"/comments", "", nil)
+	if list := decode[types.CommentList](t, w); w.Code != http.StatusOK || len(list.Comments) != 1 || list.Comments[0].Id != comment.Id {
+		t.Errorf("GET = %d %+v, want the comment", w.Code, list)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "empty body", method: http.MethodPost, target: "/" + firstId + "/comments", body: `{"body": ""}`, wantStatus: http.StatusBadRequest},
+		{name: "missing todo", method: http.MethodPost, target: "/" + missing + "/comments", body: `{"body": "Hello"}`, wantStatus: http.StatusNotFound},
+		{name: "comments of a missing todo", method: http.MethodGet, target: "/" + missing + "/comments", wantStatus: http.StatusNotFound},
+		{name: "comment of another todo", method: http.MethodPut, target: "/" + secondId + "/comments/" + comment.Id, body: `{"body": "Moved"}`, wantStatus: http.StatusNotFound},
+		{name: "delete", method: http.MethodDelete, target: "/" + firstId + "/comments/" + comment.Id, wantStatus: http.StatusNoContent},
+		{name: "deleted comment", method: http.MethodPut, target: "/" + firstId + "/comments/" + comment.Id, body: `{"body": "Hello"}`, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers map[string]string
+			if tt.body != "" {
+				headers = jsonContent
+			}
+			w := serve(t, router, tt.method, tt.target, tt.body, headers)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/dependencies:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Get the dependencies of a Todo
+//	    description: Returns the Todos the Todo with the identifier {id} is blocked by and the Todos it blocks
+//	    operationId: todoDependencies
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TodoDependencies'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) TodoDependencies(w http.ResponseWriter, r *http.Request) error {
+	dependencies, err := t.service.TodoDependencies(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, dependencies)
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/dependencies/{blocker}:
+//	  put:
+//	    tags:
+//	      - todos
+//	    summary: Block a Todo by another one
+//	    description: Makes the Todo with the identifier {id} wait for the Todo {blocker} to be done. Dependencies that would create a cycle are refused. Adding a dependency again keeps it.
+//	    operationId: addDependency
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the blocked Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: blocker
+//	        in: path
+//	        description: The identifier of the Todo that must be done first
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '204':
+//	        description: successful operation
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) AddDependency(w http.ResponseWriter, r *http.Request) error {
+	if err := t.service.AddDependency(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "blocker")); err != nil {
+		return err
+	}
+	render.NoContent(w, r)
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/dependencies/{blocker}:
+//	  delete:
+//	    tags:
+//	      - todos
+//	    summary: Unblock a Todo
+//	    description: Removes the dependency of the Todo with the identifier {id} on the Todo {blocker}, removing a missing dependency succeeds
+//	    operationId: removeDependency
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the blocked Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: blocker
+//	        in: path
+//	        description: The identifier of the Todo it was blocked by
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '204':
+//	        description: successful operation
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) RemoveDependency(w http.ResponseWriter, r *http.Request) error {
+	if err := t.service.RemoveDependency(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "blocker")); err != nil {
+		return err
+	}
+	render.NoContent(w, r)
+	return nil
+}
@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"todo-service/pkg/types"
+)
+
+func TestDependencies(t *testing.T) {
+	router, _ := newTestRouter(t, 2)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{name: "add", method: http.MethodPut, target: "/" + secondId + "/dependencies/" + firstId, wantStatus: http.StatusNoContent},
+		{name: "add again", method: http.MethodPut, target: "/" + secondId + "/dependencies/" + firstId, wantStatus: http.StatusNoContent},
+		{name: "cycle", method: http.MethodPut, target: "/" + firstId + "/dependencies/" + secondId, wantStatus: http.StatusBadRequest},
+		{name: "missing blocker", method: http.MethodPut, target: "/" + firstId + "/dependencies/" + missing, wantStatus: http.StatusNotFound},
+		{name: "missing todo", method: http.MethodGet, target: "/" + missing + "/dependencies", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(t, router, tt.method, tt.target, "", nil); w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	w := serve(t, router, http.MethodGet, "/"+secondId+"/dependencies", "", nil)
+	if got := decode[types.TodoDependencies](t, w); w.Code != http.StatusOK || len(got.BlockedBy) != 1 || got.BlockedBy[0] != firstId || got.Ready {
+		t.Errorf("GET dependencies = %d %s, want the second todo blocked by the first", w.Code, w.Body.String())
+	}
+	w = serve(t, router, http.MethodGet, "/?ready=true", "", nil)
+	if list := decode[types.TodoList](t, w); w.Code != http.StatusOK || len(list.Todos) != 1 || list.Todos[0].Id != firstId {
+		t.Errorf("GET /todos?ready=true = %d %s, want the first todo", w.Code, w.Body.String())
+	}
+	done := map[string]string{"Content-Type": "application/merge-patch+json"}
+	if w := serve(t, router, http.MethodPatch, "/"+secondId, `{"done": true}`, done); w.Code != http.StatusBadRequest {
+		t.Errorf("finishing a blocked todo status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	if w := serve(t, router, http.MethodDelete, "/"+secondId+"/dependencies/"+firstId, "", nil); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := serve(t, router, http.MethodPatch, "/"+secondId, `{"done": true}`, done); w.Code != http.StatusNoContent {
+		t.Errorf("finishing an unblocked todo status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// DocsRouter serves the interactive API documentation, a Swagger UI page rendering the definition
+// served at /api-docs. The Swagger UI assets are embedded in the binary so no CDN is needed.
+type DocsRouter struct {
+	Router *chi.Mux
+	index  []byte
+}
+
+// NewDocsRouter creates a router serving index, the page loading Swagger UI, and the Swagger UI
+// assets
+func NewDocsRouter(index []byte) *DocsRouter {
+	d := DocsRouter{index: index}
+
+	router := chi.NewRouter()
+	router.Get("/", d.Index)
+	router.Handle("/*", http.StripPrefix("/docs", http.FileServer(http.FS(swaggerFiles.FS))))
+
+	d.Router = router
+
+	return &d
+}
+
+func (d *DocsRouter) Index(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write(d.index); err != nil {
+		slog.Error("failed responding to /docs", slog.Any("error", err))
+	}
+}
+
+// SpecHandler serves an OpenAPI definition with the given content type
+func SpecHandler(spec []byte, contentType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		if _, err := w.Write(spec); err != nil {
+			slog.Error("failed responding to "+r.URL.Path, slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDocs(t *testing.T) {
+	router := chi.NewRouter()
+	router.Mount("/docs", NewDocsRouter([]byte("<html>docs</html>")).Router)
+
+	tests := []struct {
+		target          string
+		wantStatus      int
+		wantContentType string
+	}{
+		{target: "/docs", wantStatus: http.StatusOK, wantContentType: "text/html"},
+		{target: "/docs/swagger-ui-bundle.js", wantStatus: http.StatusOK, wantContentType: "text/javascript"},
+		{target: "/docs/swagger-ui.css", wantStatus: http.StatusOK, wantContentType: "text/css"},
+		{target: "/docs/missing.js", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.wantContentType)
+			}
+		})
+	}
+}
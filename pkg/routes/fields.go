@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/types"
+)
+
+// todoFields are the fields of a Todo clients can select with the fields query parameter
+var todoFields = []string{"id", "summary", "done", "status", "due", "priority", "assignees", "estimateSeconds", "trackedSeconds", "completedAt", "createdAt", "updatedAt"}
+
+// parseFields returns the fields listed by the fields query parameter (?fields=id,summary), nil
+// means every field
+func parseFields(r *http.Request) ([]string, error) {
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil, nil
+	}
+	fields := strings.Split(value, ",")
+	for _, field := range fields {
+		if !slices.Contains(todoFields, field) {
+			return nil, &errors.BadRequest{Message: fmt.Sprintf("unknown field %q, fields must be one of %s", field, strings.Join(todoFields, ", "))}
+		}
+	}
+	return fields, nil
+}
+
+// parseEmbed rejects the embed query parameter, which is declared so that clients asking to inline
+// related resources get a 400 rather than a response without them. Todos have no related
+// resources yet (no lists, subtasks or tags), so no value is supported.
+func parseEmbed(r *http.Request) error {
+	if value := r.URL.Query().Get("embed"); value != "" {
+		return &errors.BadRequest{Message: fmt.Sprintf("unsupported embed %q, todos have no related resources to embed yet", value)}
+	}
+	return nil
+}
+
+// selectFields returns the representation of todo restricted to fields, all of them when fields is
+// nil. Fields that are left out of a full representation (an unset due date) stay left out.
+func selectFields(todo types.Todo, fields []string) (any, error) {
+	if fields == nil {
+		return todo, nil
+	}
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return nil, err
+	}
+	all := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	selected := map[string]json.RawMessage{}
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/features/todo"
+)
+
+// assigneeMe is the assignee query parameter selecting the todos of the user making the request
+const assigneeMe = "me"
+
+// parseFilter returns the filter set by the query parameters of a request listing todos
+func parseFilter(r *http.Request) (todo.TodoFilter, error) {
+	query := r.URL.Query()
+	filter := todo.TodoFilter{Assignee: query.Get("assignee"), Status: query.Get("status"), Ready: query.Get("ready") == "true"}
+	if filter.Assignee == assigneeMe {
+		filter.Assignee = actor.FromContext(r.Context())
+		if filter.Assignee == actor.Anonymous {
+			return filter, &errors.BadRequest{Message: "assignee=me requires the request to name its user (see service.actorHeader)"}
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo-service/pkg/middlewares"
+	"todo-service/pkg/openapi/openapitest"
+	"todo-service/pkg/types"
+)
+
+func TestListTodosByAssignee(t *testing.T) {
+	router, service := newTestRouter(t, 2)
+	for _, assignees := range []types.Assignees{{"alice"}, {"bob"}, {"alice", "bob"}} {
+		if _, err := service.CreateTodo(context.Background(), types.TodoUpdate{Summary: "assigned", Assignees: assignees}); err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+	}
+	// The actor middleware names the user assignee=me stands for
+	handler := openapitest.Mount(t, "/todos", middlewares.Actor("X-Forwarded-User")(router.Router))
+	get := func(target string, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if user != "" {
+			req.Header.Set("X-Forwarded-User", user)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name      string
+		target    string
+		user      string
+		wantCount int
+	}{
+		{name: "assignee", target: "/todos?assignee=bob&count=true", wantCount: 2},
+		{name: "me", target: "/todos?assignee=me&count=true", user: "alice", wantCount: 2},
+		{name: "nobody", target: "/todos?assignee=carol&count=true", wantCount: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(tt.target, tt.user)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			list := decode[types.TodoList](t, w)
+			if len(list.Todos) != tt.wantCount || list.TotalCount == nil || *list.TotalCount != tt.wantCount {
+				t.Errorf("got %d todos counting %v, want %d", len(list.Todos), list.TotalCount, tt.wantCount)
+			}
+			if list.Prev != nil {
+				t.Errorf("prev = %q, filtered pages have no previous page", *list.Prev)
+			}
+		})
+	}
+
+	t.Run("links keep the filter", func(t *testing.T) {
+		w := get("/todos?assignee=alice&limit=1", "")
+		if link := w.Header().Get("Link"); !strings.Contains(link, "assignee=alice") || !strings.Contains(link, `rel="next"`) {
+			t.Errorf("Link = %q, want a next link filtering on alice", link)
+		}
+	})
+
+	t.Run("me without a user", func(t *testing.T) {
+		if w := get("/todos?assignee=me", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestCreateTodoWithAssignees(t *testing.T) {
+	router, _ := newTestRouter(t, 0)
+	w := serve(t, router, http.MethodPost, "/", `{"summary": "todo", "assignees": ["alice", "alice", "bob"]}`, map[string]string{"Content-Type": "application/json"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if created := decode[types.Todo](t, w); strings.Join(created.Assignees, ",") != "alice,bob" {
+		t.Errorf("assignees = %v, want [alice bob]", created.Assignees)
+	}
+
+	w = serve(t, router, http.MethodPost, "/", `{"summary": "todo", "assignees": [""]}`, map[string]string{"Content-Type": "application/json"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status with an empty assignee = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,227 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/gql"
+	serviceMiddlewares "todo-service/pkg/middlewares"
+)
+
+// GraphQLRouter serves the GraphQL API. Queries and mutations are answered with JSON, subscriptions
+// are streamed as server-sent events to requests that accept text/event-stream.
+type GraphQLRouter struct {
+	Router  *chi.Mux
+	server  *gql.Server
+	timeout time.Duration
+}
+
+// NewGraphQLRouter creates a router timing out queries and mutations after timeout, a timeout <= 0
+// disables it. The Timeout middleware leaves event stream requests to the router, which only lets
+// subscriptions run for as long as the client stays connected.
+func NewGraphQLRouter(service todo.TodoService, limits gql.Limits, timeout time.Duration) *GraphQLRouter {
+	g := GraphQLRouter{server: gql.NewServer(service, limits), timeout: timeout}
+	h := serviceMiddlewares.ErrorHandler{}
+
+	router := chi.NewRouter()
+	router.Post("/", h.Wrap(g.Post))
+	router.Get("/", h.Wrap(g.Get))
+
+	g.Router = router
+
+	return &g
+}
+
+// @openapi
+// paths:
+//
+//	/graphql:
+//	  post:
+//	    tags:
+//	      - graphql
+//	    summary: Run a GraphQL operation
+//	    description: Runs a GraphQL query, mutation or subscription. Subscriptions require `Accept text/event-stream` and stream a `next` event per result followed by a `complete` event.
+//	    operationId: graphql
+//	    requestBody:
+//	      content:
+//	        application/json:
+//	          schema:
+//	            $ref: '#/components/schemas/GraphQLRequest'
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/GraphQLResponse'
+//	          text/event-stream:
+//	            schema:
+//	              type: string
+//	      '400':
+//	        description: the request isn't a valid GraphQL operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/GraphQLResponse'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (g *GraphQLRouter) Post(w http.ResponseWriter, r *http.Request) error {
+	req := gql.Request{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return &errors.BadRequest{Message: fmt.Sprintf("invalid GraphQL request: %v", err)}
+	}
+	g.serve(w, r, req)
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/graphql:
+//	  get:
+//	    tags:
+//	      - graphql
+//	    summary: Run a GraphQL query
+//	    description: Runs a GraphQL query or subscription passed as query parameters, mutations must be sent with POST
+//	    operationId: graphqlQuery
+//	    parameters:
+//	      - name: query
+//	        in: query
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: operationName
+//	        in: query
+//	        required: false
+//	        schema:
+//	          type: string
+//	      - name: variables
+//	        in: query
+//	        description: The variables as a JSON object
+//	        required: false
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/GraphQLResponse'
+//	          text/event-stream:
+//	            schema:
+//	              type: string
+//	      '400':
+//	        description: the request isn't a valid GraphQL operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/GraphQLResponse'
+//	      '405':
+//	        description: mutations can't be sent with GET
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (g *GraphQLRouter) Get(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	req := gql.Request{Query: query.Get("query"), OperationName: query.Get("operationName")}
+	if variables := query.Get("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			return &errors.BadRequest{Message: fmt.Sprintf("invalid GraphQL variables: %v", err)}
+		}
+	}
+	g.serve(w, r, req)
+	return nil
+}
+
+func (g *GraphQLRouter) serve(w http.ResponseWriter, r *http.Request, req gql.Request) {
+	operation, errs := g.server.Parse(req)
+	if errs != nil {
+		writeGraphQLErrors(w, r, http.StatusBadRequest, errs)
+		return
+	}
+
+	// GET requests must be safe so they can't change anything (GraphQL over HTTP)
+	if r.Method == http.MethodGet && operation.Type == "mutation" {
+		w.Header().Set("Allow", http.MethodPost)
+		writeGraphQLErrors(w, r, http.StatusMethodNotAllowed, gqlerrors.FormatErrors(fmt.Errorf("mutations must be sent with POST")))
+		return
+	}
+
+	if operation.Type == "subscription" {
+		if !acceptsEventStream(r) {
+			writeGraphQLErrors(w, r, http.StatusNotAcceptable, gqlerrors.FormatErrors(fmt.Errorf("subscriptions are streamed as server-sent events, send them with Accept: text/event-stream")))
+			return
+		}
+		g.stream(w, r, operation)
+		return
+	}
+
+	ctx := r.Context()
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+	render.JSON(w, r, g.server.Execute(ctx, operation))
+}
+
+// stream writes a next event per subscription result until the client goes away or the
+// subscription ends, which is followed by a complete event
+func (g *GraphQLRouter) stream(w http.ResponseWriter, r *http.Request, operation *gql.Operation) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeGraphQLErrors(w, r, http.StatusInternalServerError, gqlerrors.FormatErrors(fmt.Errorf("streaming isn't supported")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	results := g.server.Subscribe(ctx, operation)
+	defer func() {
+		// Drain the results so the subscription doesn't block on a result nobody reads
+		for range results {
+		}
+	}()
+	defer cancel()
+
+	for result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			slog.Error("failed to encode GraphQL result", slog.Any("error", err))
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: next\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+	if r.Context().Err() == nil {
+		fmt.Fprint(w, "event: complete\ndata:\n\n")
+		flusher.Flush()
+	}
+}
+
+func writeGraphQLErrors(w http.ResponseWriter, r *http.Request, status int, errs []gqlerrors.FormattedError) {
+	render.Status(r, status)
+	render.JSON(w, r, graphql.Result{Errors: errs})
+}
+
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/gql"
+	"todo-service/pkg/types"
+)
+
+func TestGraphQL(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "query",
+			method:     http.MethodPost,
+			body:       `{"query": "query($id: ID!) { todo(id: $id) { id summary } }", "variables": {"id": "` + firstId + `"}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"todo":{"id":"` + firstId + `","summary":"Pick up the groceries"}}}`,
+		},
+		{
+			name:       "query with GET",
+			method:     http.MethodGet,
+			target:     "/?" + url.Values{"query": {"{ todos(first: 1) { nodes { id } pageInfo { hasNextPage } } }"}}.Encode(),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"todos":{"nodes":[{"id":"` + firstId + `"}],"pageInfo":{"hasNextPage":true}}}}`,
+		},
+		{
+			name:       "mutation",
+			method:     http.MethodPost,
+			body:       `{"query": "mutation { deleteTodo(id: \"` + secondId + `\") }"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"deleteTodo":"` + secondId + `"}}`,
+		},
+		{
+			name:       "mutation with GET",
+			method:     http.MethodGet,
+			target:     "/?" + url.Values{"query": {`mutation { deleteTodo(id: "` + secondId + `") }`}}.Encode(),
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "invalid query",
+			method:     http.MethodPost,
+			body:       `{"query": "{ todo { unknown } }"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid body",
+			method:     http.MethodPost,
+			body:       `{"query": `,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "subscription without event stream",
+			method:     http.MethodPost,
+			body:       `{"query": "subscription { todoChanged { type } }"}`,
+			wantStatus: http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, service := newTestRouter(t, 2)
+			router := NewGraphQLRouter(service, gql.DefaultLimits, time.Minute)
+
+			target := tt.target
+			if target == "" {
+				target = "/"
+			}
+			w := httptest.NewRecorder()
+			router.Router.ServeHTTP(w, httptest.NewRequest(tt.method, target, strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && strings.TrimSpace(w.Body.String()) != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+// deadlineService records whether GetTodos is called with a deadline
+type deadlineService struct {
+	todo.TodoService
+	hasDeadline bool
+}
+
+func (d *deadlineService) GetTodos(ctx context.Context, ids []string) ([]types.Todo, error) {
+	_, d.hasDeadline = ctx.Deadline()
+	return d.TodoService.GetTodos(ctx, ids)
+}
+
+func TestGraphQLTimesOutQueriesSentAsEventStreams(t *testing.T) {
+	_, service := newTestRouter(t, 1)
+	recorder := &deadlineService{TodoService: service}
+	router := NewGraphQLRouter(recorder, gql.DefaultLimits, time.Minute)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"query": "{ todo(id: \"`+firstId+`\") { id } }"}`))
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	router.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if !recorder.hasDeadline {
+		t.Errorf("query sent with Accept: text/event-stream ran without a deadline")
+	}
+}
+
+func TestGraphQLSubscription(t *testing.T) {
+	_, service := newTestRouter(t, 0)
+	server := httptest.NewServer(NewGraphQLRouter(service, gql.DefaultLimits, time.Minute).Router)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(`{"query": "subscription { todoChanged { type todo { summary } } }"}`))
+	req.Header.Set("Accept", "text/event-stream")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer res.Body.Close()
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", contentType)
+	}
+
+	// The headers are sent once the subscription is set up, changes made from now on are streamed,
+	// yet the watch itself starts asynchronously so keep making changes until an event arrives
+	go func() {
+		for ctx.Err() == nil {
+			_, _ = service.CreateTodo(ctx, types.TodoUpdate{Summary: "streamed"})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var result struct {
+			Data struct {
+				TodoChanged struct {
+					Type string     `json:"type"`
+					Todo types.Todo `json:"todo"`
+				} `json:"todoChanged"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &result); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", line, err)
+		}
+		if result.Data.TodoChanged.Type != "CREATED" || result.Data.TodoChanged.Todo.Summary != "streamed" {
+			t.Errorf("event = %s, want a CREATED event", line)
+		}
+		return
+	}
+	t.Fatalf("the stream ended without an event: %v", scanner.Err())
+}
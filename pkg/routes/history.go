@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/types"
+)
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/history:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Get the history of a Todo
+//	    description: Returns the revisions recorded for every change to the Todo with the identifier {id}, the oldest first. The history of a deleted Todo is kept.
+//	    operationId: getTodoHistory
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: limit
+//	        in: query
+//	        description: The number of revisions to return (defaults to 10), limits above service.maxLimit (100 by default) are lowered to it
+//	        required: false
+//	        schema:
+//	          type: integer
+//	          minimum: 1
+//	      - name: next
+//	        in: query
+//	        description: The next page identifier
+//	        required: false
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TodoHistory'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) TodoHistory(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if (err != nil) || limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, t.maxLimit)
+
+	revisions, next, err := t.service.TodoHistory(r.Context(), chi.URLParam(r, "id"), limit, query.Get("next"))
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, types.TodoHistory{Revisions: revisions, Next: next})
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/revert/{revision}:
+//	  post:
+//	    tags:
+//	      - todos
+//	    summary: Revert a Todo to an earlier revision
+//	    description: Restores the Todo with the identifier {id} to the way it was after the revision {revision}, which is recorded as a new revision. Deleted Todos are restored as well, without the comments, attachments, dependencies and time entries that were deleted with them. Reverts the workflow or the dependencies of the Todo forbid are refused with a 400, the way updates are.
+//	    operationId: revertTodo
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: revision
+//	        in: path
+//	        description: The identifier of the revision to revert to
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        headers:
+//	          Undo-Token:
+//	            $ref: '#/components/headers/UndoToken'
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Todo'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) RevertTodo(w http.ResponseWriter, r *http.Request) error {
+	ctx, changes := todo.TrackChanges(r.Context())
+	reverted, err := t.service.RevertTodo(ctx, chi.URLParam(r, "id"), chi.URLParam(r, "revision"))
+	if err != nil {
+		return err
+	}
+	setUndoToken(w, changes)
+	render.JSON(w, r, reverted)
+	return nil
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"todo-service/pkg/types"
+)
+
+func TestTodoHistory(t *testing.T) {
+	router, _ := newTestRouter(t, 1)
+	if w := serve(t, router, http.MethodPatch, "/"+firstId, `{"summary": "Pick up the mail"}`, map[string]string{"Content-Type": "application/merge-patch+json"}); w.Code != http.StatusNoContent {
+		t.Fatalf("PATCH status = %d: %s", w.Code, w.Body.String())
+	}
+
+	w := serve(t, router, http.MethodGet, "/"+firstId+"/history", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	history := decode[types.TodoHistory](t, w)
+	if len(history.Revisions) != 2 || history.Revisions[0].Action != types.RevisionCreated || history.Revisions[1].Action != types.RevisionUpdated {
+		t.Errorf("history = %+v, want the creation and the update", history)
+	}
+
+	w = serve(t, router, http.MethodGet, "/"+firstId+"/history?limit=1", "", nil)
+	if history := decode[types.TodoHistory](t, w); len(history.Revisions) != 1 || history.Next == "" {
+		t.Errorf("history with a limit = %+v, want 1 revision and a cursor", history)
+	}
+
+	if w := serve(t, router, http.MethodGet, "/"+missing+"/history", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("history of a missing todo status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestRevertTodo(t *testing.T) {
+	router, service := newTestRouter(t, 1)
+	if w := serve(t, router, http.MethodDelete, "/"+firstId, "", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d: %s", w.Code, w.Body.String())
+	}
+	history := decode[types.TodoHistory](t, serve(t, router, http.MethodGet, "/"+firstId+"/history", "", nil))
+	if len(history.Revisions) != 2 {
+		t.Fatalf("history = %+v, want the creation and the deletion", history)
+	}
+	created, deleted := history.Revisions[0].Id, history.Revisions[1].Id
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{name: "restores a deleted todo", target: "/" + firstId + "/revert/" + created, wantStatus: http.StatusOK},
+		{name: "revision deleting the todo", target: "/" + firstId + "/revert/" + deleted, wantStatus: http.StatusBadRequest},
+		{name: "missing revision", target: "/" + firstId + "/revert/" + missing, wantStatus: http.StatusNotFound},
+		{name: "revision of another todo", target: "/" + secondId + "/revert/" + created, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, http.MethodPost, tt.target, "", nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	if todo, err := service.GetTodo(context.Background(), firstId); err != nil || todo.Summary != "Pick up the groceries" {
+		t.Errorf("GetTodo() after the revert = %+v, %v, want the restored todo", todo, err)
+	}
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"todo-service/pkg/types"
+)
+
+// pageLinks returns the Link header (RFC 8288) of a page of list, linking to the first, next and
+// previous pages listed with the same limit and query parameters
+func pageLinks(r *http.Request, limit int, list types.TodoList) string {
+	links := []string{pageLink(r, limit, "", "first")}
+	if list.Next != "" {
+		links = append(links, pageLink(r, limit, list.Next, "next"))
+	}
+	if list.Prev != nil {
+		links = append(links, pageLink(r, limit, *list.Prev, "prev"))
+	}
+	return strings.Join(links, ", ")
+}
+
+func pageLink(r *http.Request, limit int, cursor string, rel string) string {
+	query := r.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Del("next")
+	if cursor != "" {
+		query.Set("next", cursor)
+	}
+	return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), rel)
+}
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/tink3rlabs/magic/errors"
+
+	serviceMiddlewares "todo-service/pkg/middlewares"
+	"todo-service/pkg/types"
+)
+
+const (
+	jsonPatchType  = "application/json-patch+json"
+	mergePatchType = "application/merge-patch+json"
+)
+
+// acceptPatch lists the patch formats UpdateTodo understands (RFC 5789)
+const acceptPatch = jsonPatchType + ", " + mergePatchType
+
+// patcher returns a function applying the patch document body, a JSON Patch or a JSON Merge Patch
+// depending on contentType, to a todo
+func patcher(contentType string, body []byte) (func(document []byte) ([]byte, error), error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case jsonPatchType:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			return nil, &errors.BadRequest{Message: err.Error()}
+		}
+		return patch.Apply, nil
+	case mergePatchType:
+		if !json.Valid(body) {
+			return nil, &errors.BadRequest{Message: "the merge patch isn't valid JSON"}
+		}
+		return func(document []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(document, body)
+		}, nil
+	default:
+		return nil, &serviceMiddlewares.UnsupportedMediaType{
+			Message: fmt.Sprintf("unsupported patch format %q, send one of %s", contentType, acceptPatch),
+		}
+	}
+}
+
+// patchedTodo decodes the patched document of current, which must be a valid todo that keeps the
+// read-only fields of current
+func patchedTodo(current types.Todo, document []byte) (types.Todo, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+	modified := types.Todo{}
+	if err := decoder.Decode(&modified); err != nil {
+		return modified, &errors.BadRequest{Message: fmt.Sprintf("the patched Todo is invalid: %v", err)}
+	}
+
+	switch {
+	case modified.Id != current.Id:
+		return modified, &errors.BadRequest{Message: "Id field can't be changed"}
+	case !modified.CreatedAt.Equal(current.CreatedAt):
+		return modified, &errors.BadRequest{Message: "createdAt is read-only and can't be changed"}
+	case !modified.UpdatedAt.Equal(current.UpdatedAt):
+		return modified, &errors.BadRequest{Message: "updatedAt is read-only and can't be changed"}
+	case !sameTime(modified.CompletedAt, current.CompletedAt):
+		return modified, &errors.BadRequest{Message: "completedAt is read-only and can't be changed"}
+	case modified.TrackedSeconds != current.TrackedSeconds:
+		return modified, &errors.BadRequest{Message: "trackedSeconds is read-only and can't be changed"}
+	case modified.Summary == "":
+		return modified, &errors.BadRequest{Message: "summary is required"}
+	case modified.Priority < 0 || modified.Priority > 9:
+		return modified, &errors.BadRequest{Message: "priority must be between 0 and 9"}
+	case modified.EstimateSeconds < 0:
+		return modified, &errors.BadRequest{Message: "estimateSeconds can't be negative"}
+	}
+	return modified, nil
+}
+
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// prefersRepresentation reports whether the request asks for the changed resource in the response
+// with Prefer: return=representation (RFC 7240)
+func prefersRepresentation(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(header, ",") {
+			name, _, _ := strings.Cut(preference, ";")
+			if strings.EqualFold(strings.ReplaceAll(name, " ", ""), "return=representation") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/features/todo"
+	serviceMiddlewares "todo-service/pkg/middlewares"
+)
+
+// StatsRouter reports how many todos are created, completed and overdue
+type StatsRouter struct {
+	Router  *chi.Mux
+	service todo.TodoService
+}
+
+func NewStatsRouter(service todo.TodoService) *StatsRouter {
+	s := StatsRouter{service: service}
+	h := serviceMiddlewares.ErrorHandler{}
+
+	router := chi.NewRouter()
+	router.Get("/", h.Wrap(s.TodoStats))
+
+	s.Router = router
+
+	return &s
+}
+
+// @openapi
+// paths:
+//
+//	/stats:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Get Todo statistics
+//	    description: Returns the number of Todos created and completed in each day or week of a range, the average time Todos completed in the range took, and the number of open, done and overdue Todos by status and by assignee
+//	    operationId: todoStats
+//	    parameters:
+//	      - name: from
+//	        in: query
+//	        description: The start of the range, included (defaults to 30 days before its end)
+//	        required: false
+//	        schema:
+//	          type: string
+//	          format: date-time
+//	        example: 2024-07-01T00:00:00Z
+//	      - name: to
+//	        in: query
+//	        description: The end of the range, excluded (defaults to now)
+//	        required: false
+//	        schema:
+//	          type: string
+//	          format: date-time
+//	        example: 2024-08-01T00:00:00Z
+//	      - name: interval
+//	        in: query
+//	        description: The length of the periods the range is split in, a range holds at most 366 periods
+//	        required: false
+//	        schema:
+//	          type: string
+//	          enum: [day, week]
+//	          default: day
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TodoStats'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (s *StatsRouter) TodoStats(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	options := todo.StatsOptions{Interval: todo.StatsInterval(query.Get("interval"))}
+	for name, bound := range map[string]*time.Time{"from": &options.From, "to": &options.To} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return &errors.BadRequest{Message: fmt.Sprintf("%s must be an RFC 3339 date-time", name)}
+			}
+			*bound = parsed
+		}
+	}
+
+	stats, err := s.service.TodoStats(r.Context(), options)
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, stats)
+	return nil
+}
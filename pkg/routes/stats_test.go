@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-service/pkg/openapi/openapitest"
+	"todo-service/pkg/types"
+)
+
+func TestTodoStats(t *testing.T) {
+	_, service := newTestRouter(t, 2)
+	stats := openapitest.Mount(t, "/stats", NewStatsRouter(service).Router)
+
+	tests := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantCreated int
+	}{
+		// The range ends now by default, which leaves out the todos created now
+		{name: "default range", target: "/stats", wantStatus: http.StatusOK, wantCreated: 0},
+		{name: "range", target: "/stats?from=2024-07-01T00:00:00Z&to=2024-07-08T00:00:00Z&interval=week", wantStatus: http.StatusOK, wantCreated: 2},
+		{name: "unknown interval", target: "/stats?interval=month", wantStatus: http.StatusBadRequest},
+		{name: "invalid from", target: "/stats?from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "empty range", target: "/stats?from=2024-07-08T00:00:00Z&to=2024-07-01T00:00:00Z", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			stats.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			if got := decode[types.TodoStats](t, w); got.Total != 2 || got.Open != 2 || got.Created != tt.wantCreated {
+				t.Errorf("GET %s = %s, want 2 open todos and %d created", tt.target, w.Body.String(), tt.wantCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/features/todo"
+	serviceMiddlewares "todo-service/pkg/middlewares"
+	"todo-service/pkg/types"
+)
+
+// withTrackedTime sets the tracked time of todos, which is summed from their time entries
+func (t *TodoRouter) withTrackedTime(ctx context.Context, todos []types.Todo) error {
+	ids := []string{}
+	for _, todo := range todos {
+		ids = append(ids, todo.Id)
+	}
+	tracked, err := t.service.TrackedTime(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range todos {
+		todos[i].TrackedSeconds = tracked[todos[i].Id]
+	}
+	return nil
+}
+
+// timerError maps the timer errors of the service to responses
+func timerError(err error) error {
+	if errors.Is(err, todo.ErrTimerRunning) || errors.Is(err, todo.ErrNoTimer) {
+		return &serviceMiddlewares.Conflict{Message: err.Error()}
+	}
+	return err
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/time-entries:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Get the TimeEntries of a Todo
+//	    description: Returns a page of the time logged on the Todo with the identifier {id}, the oldest first. Running timers are listed without an end.
+//	    operationId: listTimeEntries
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: limit
+//	        in: query
+//	        description: The number of time entries to return (defaults to 10), limits above service.maxLimit (100 by default) are lowered to it
+//	        required: false
+//	        schema:
+//	          type: integer
+//	          minimum: 1
+//	      - name: next
+//	        in: query
+//	        description: The next page identifier
+//	        required: false
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TimeEntryList'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) ListTimeEntries(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if (err != nil) || limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, t.maxLimit)
+
+	entries, next, err := t.service.ListTimeEntries(r.Context(), chi.URLParam(r, "id"), limit, query.Get("next"))
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, types.TimeEntryList{TimeEntries: entries, Next: next})
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/time-entries:
+//	  post:
+//	    tags:
+//	      - todos
+//	    summary: Log time spent on a Todo
+//	    description: Adds a TimeEntry to the Todo with the identifier {id}, logged by the user named by the service.actorHeader header
+//	    operationId: addTimeEntry
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	    requestBody:
+//	      description: The time spent
+//	      required: true
+//	      content:
+//	        application/json:
+//	          schema:
+//	            $ref: '#/components/schemas/TimeEntryCreate'
+//	    responses:
+//	      '201':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TimeEntry'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) AddTimeEntry(w http.ResponseWriter, r *http.Request) error {
+	var entryToAdd types.TimeEntryCreate
+	if err := json.NewDecoder(r.Body).Decode(&entryToAdd); err != nil {
+		return err
+	}
+
+	entry, err := t.service.AddTimeEntry(r.Context(), chi.URLParam(r, "id"), entryToAdd)
+	if err != nil {
+		return err
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, entry)
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/time-entries/{entry}:
+//	  delete:
+//	    tags:
+//	      - todos
+//	    summary: Delete a TimeEntry
+//	    description: Deletes the TimeEntry {entry} of the Todo with the identifier {id} if exists
+//	    operationId: deleteTimeEntry
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	      - name: entry
+//	        in: path
+//	        description: The identifier of the TimeEntry
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '204':
+//	        description: successful operation
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) DeleteTimeEntry(w http.ResponseWriter, r *http.Request) error {
+	err := t.service.DeleteTimeEntry(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "entry"))
+	if err != nil {
+		return err
+	}
+	render.NoContent(w, r)
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/timer/start:
+//	  post:
+//	    tags:
+//	      - todos
+//	    summary: Start a timer on a Todo
+//	    description: Starts a timer of the user named by the service.actorHeader header on the Todo with the identifier {id}, the time is logged when the timer is stopped
+//	    operationId: startTimer
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '201':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TimeEntry'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '409':
+//	        description: The user already runs a timer on the Todo
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Error'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) StartTimer(w http.ResponseWriter, r *http.Request) error {
+	entry, err := t.service.StartTimer(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		return timerError(err)
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, entry)
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/{id}/timer/stop:
+//	  post:
+//	    tags:
+//	      - todos
+//	    summary: Stop a timer on a Todo
+//	    description: Stops the timer the user named by the service.actorHeader header runs on the Todo with the identifier {id}, logging the time since it was started. Timers log at most a day like time entries logged by hand, a timer left running for longer ends a day after it started.
+//	    operationId: stopTimer
+//	    parameters:
+//	      - name: id
+//	        in: path
+//	        description: The identifier of the Todo
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TimeEntry'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '409':
+//	        description: The user runs no timer on the Todo
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Error'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) StopTimer(w http.ResponseWriter, r *http.Request) error {
+	entry, err := t.service.StopTimer(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		return timerError(err)
+	}
+	render.JSON(w, r, entry)
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/time-report:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Report the time logged on Todos
+//	    description: Sums the time logged by user and by Todo for the TimeEntries started within a range, running timers are left out
+//	    operationId: timeReport
+//	    parameters:
+//	      - name: from
+//	        in: query
+//	        description: The start of the range, included
+//	        required: true
+//	        schema:
+//	          type: string
+//	          format: date-time
+//	        example: 2024-07-01T00:00:00Z
+//	      - name: to
+//	        in: query
+//	        description: The end of the range, excluded
+//	        required: true
+//	        schema:
+//	          type: string
+//	          format: date-time
+//	        example: 2024-08-01T00:00:00Z
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TimeReport'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) TimeReport(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	bounds := [2]time.Time{}
+	for i, name := range []string{"from", "to"} {
+		bound, err := time.Parse(time.RFC3339, query.Get(name))
+		if err != nil {
+			return &serviceErrors.BadRequest{Message: fmt.Sprintf("%s must be an RFC 3339 date-time", name)}
+		}
+		bounds[i] = bound
+	}
+
+	report, err := t.service.TimeReport(r.Context(), bounds[0], bounds[1])
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, report)
+	return nil
+}
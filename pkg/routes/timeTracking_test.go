@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"todo-service/pkg/types"
+)
+
+func TestTimeTracking(t *testing.T) {
+	router, _ := newTestRouter(t, 2)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "start", method: http.MethodPost, target: "/" + firstId + "/timer/start", wantStatus: http.StatusCreated},
+		{name: "start again", method: http.MethodPost, target: "/" + firstId + "/timer/start", wantStatus: http.StatusConflict},
+		{name: "stop", method: http.MethodPost, target: "/" + firstId + "/timer/stop", wantStatus: http.StatusOK},
+		{name: "stop again", method: http.MethodPost, target: "/" + firstId + "/timer/stop", wantStatus: http.StatusConflict},
+		{name: "log", method: http.MethodPost, target: "/" + firstId + "/time-entries", body: `{"seconds": 1800, "note": "review"}`, wantStatus: http.StatusCreated},
+		{name: "log too much", method: http.MethodPost, target: "/" + firstId + "/time-entries", body: `{"seconds": 86401}`, wantStatus: http.StatusBadRequest},
+		{name: "log on a missing todo", method: http.MethodPost, target: "/" + missing + "/time-entries", body: `{"seconds": 60}`, wantStatus: http.StatusNotFound},
+		{name: "report without range", method: http.MethodGet, target: "/time-report", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{"Content-Type": "application/json"}
+			if w := serve(t, router, tt.method, tt.target, tt.body, headers); w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	// The timer stopped right away logs a second
+	w := serve(t, router, http.MethodGet, "/"+firstId, "", nil)
+	if got := decode[types.Todo](t, w); w.Code != http.StatusOK || got.TrackedSeconds != 1801 {
+		t.Errorf("GET todo = %d %s, want 1801 tracked seconds", w.Code, w.Body.String())
+	}
+	w = serve(t, router, http.MethodGet, "/"+firstId+"/time-entries", "", nil)
+	if list := decode[types.TimeEntryList](t, w); w.Code != http.StatusOK || len(list.TimeEntries) != 2 {
+		t.Errorf("GET time entries = %d %s, want the timer and the logged entry", w.Code, w.Body.String())
+	}
+	w = serve(t, router, http.MethodGet, "/time-report?from=2000-01-01T00:00:00Z&to=2100-01-01T00:00:00Z", "", nil)
+	if report := decode[types.TimeReport](t, w); w.Code != http.StatusOK || report.TotalSeconds != 1801 || len(report.ByTodo) != 1 {
+		t.Errorf("GET time report = %d %s, want 1801 seconds on the first todo", w.Code, w.Body.String())
+	}
+
+	patch := map[string]string{"Content-Type": "application/merge-patch+json"}
+	if w := serve(t, router, http.MethodPatch, "/"+firstId, `{"trackedSeconds": 60}`, patch); w.Code != http.StatusBadRequest {
+		t.Errorf("changing the tracked time status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := serve(t, router, http.MethodPatch, "/"+firstId, `{"estimateSeconds": 3600}`, patch); w.Code != http.StatusNoContent {
+		t.Errorf("setting the estimate status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+}
@@ -6,104 +6,77 @@ import (
 	"net/http"
 	"strconv"
 
-	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 
 	"todo-service/pkg/features/todo"
+	serviceMiddlewares "todo-service/pkg/middlewares"
 	"todo-service/pkg/types"
 
 	"github.com/tink3rlabs/magic/errors"
-	"github.com/tink3rlabs/magic/middlewares"
 )
 
-type TodoRouter struct {
-	Router  *chi.Mux
-	service *todo.TodoService
-}
-
-// Define the JSON schemas as a map where the ctx(body, params and query) is the key and schema is the value
-// Example: If you gave a request where you need to validate body, params and query
-// var schema = map[string]string{
-// 	"body": `{
-// 		"type": "object",
-// 		"properties": {
-// 			"summary": { "type": "string" }
-// 		},
-// 		"required": ["summary"]
-// 	}`,
-// 	"params": `{
-// 		"type": "object",
-// 		"properties": {
-// 			"id": { "type": "string" }
-// 		},
-// 		"required": ["id"]
-// 	}`,
-// 	"query": `{
-// 		"type": "object",
-// 		"properties": {
-// 			"app": { "type": "string" }
-// 		},
-// 		"required": ["app"]
-// 	}`,
-// }
-
-var createSchema = map[string]string{
-	"body": `{
-		"type": "object",
-		"properties": {
-			"summary": { "type": "string" },
-			"done": { "type": "boolean" }
-		},
-		"required": ["summary"],
-		"additionalProperties": false
-	}`,
-}
-
-var replaceSchema = map[string]string{
-	"body": `{
-		"type": "object",
-		"properties": {
-			"summary": { "type": "string" },
-			"done": { "type": "boolean" }
-		},
-		"required": ["summary", "done"],
-		"additionalProperties": false
-	}`,
-	"params": `{
-		"type": "object",
-		"properties": {
-			"id": { "type": "string" }
-		},
-		"required": ["id"]
-	}`,
-}
+const (
+	// DefaultLimit is the number of todos listed when the request doesn't set a limit
+	DefaultLimit = 10
+	// DefaultMaxLimit is the largest number of todos listed at once unless configured otherwise
+	DefaultMaxLimit = 100
+	// DefaultMaxImportSize is the largest import body in bytes unless configured otherwise
+	DefaultMaxImportSize = 10 * 1024 * 1024
+)
 
-var idSchema = map[string]string{
-	"params": `{
-		"type": "object",
-		"properties": {
-			"id": { "type": "string" }
-		},
-		"required": ["id"]
-	}`,
+type TodoRouter struct {
+	Router        *chi.Mux
+	service       todo.TodoService
+	maxLimit      int
+	maxImportSize int64
 }
 
-func NewTodoRouter() *TodoRouter {
-	t := TodoRouter{}
-	h := middlewares.ErrorHandler{}
-	v := middlewares.Validator{}
+// NewTodoRouter creates a router listing at most maxLimit todos at once, larger limits are lowered
+// to it, and importing bodies of at most maxImportSize bytes. A maxLimit <= 0 uses DefaultMaxLimit
+// and a maxImportSize <= 0 DefaultMaxImportSize.
+func NewTodoRouter(service todo.TodoService, maxLimit int, maxImportSize int64) *TodoRouter {
+	if maxLimit <= 0 {
+		maxLimit = DefaultMaxLimit
+	}
+	if maxImportSize <= 0 {
+		maxImportSize = DefaultMaxImportSize
+	}
+	t := TodoRouter{service: service, maxLimit: maxLimit, maxImportSize: maxImportSize}
+	h := serviceMiddlewares.ErrorHandler{}
 
 	router := chi.NewRouter()
-	router.Get("/{id}", v.ValidateRequest(idSchema, h.Wrap(t.GetTodo)))
-	router.Delete("/{id}", v.ValidateRequest(idSchema, h.Wrap(t.DeleteTodo)))
-	router.Put("/{id}", v.ValidateRequest(replaceSchema, h.Wrap(t.ReplaceTodo)))
-	router.Patch("/{id}", v.ValidateRequest(idSchema, h.Wrap(t.UpdateTodo)))
-	router.Post("/", v.ValidateRequest(createSchema, h.Wrap(t.CreateTodo)))
+	router.Get("/{id}", h.Wrap(t.GetTodo))
+	router.Delete("/{id}", h.Wrap(t.DeleteTodo))
+	router.Put("/{id}", h.Wrap(t.ReplaceTodo))
+	router.Patch("/{id}", h.Wrap(t.UpdateTodo))
+	router.Get("/{id}/history", h.Wrap(t.TodoHistory))
+	router.Post("/{id}/revert/{revision}", h.Wrap(t.RevertTodo))
+	router.Get("/{id}/comments", h.Wrap(t.ListComments))
+	router.Post("/{id}/comments", h.Wrap(t.CreateComment))
+	router.Put("/{id}/comments/{comment}", h.Wrap(t.UpdateComment))
+	router.Delete("/{id}/comments/{comment}", h.Wrap(t.DeleteComment))
+	router.Get("/{id}/dependencies", h.Wrap(t.TodoDependencies))
+	router.Put("/{id}/dependencies/{blocker}", h.Wrap(t.AddDependency))
+	router.Delete("/{id}/dependencies/{blocker}", h.Wrap(t.RemoveDependency))
+	router.Get("/{id}/time-entries", h.Wrap(t.ListTimeEntries))
+	router.Post("/{id}/time-entries", h.Wrap(t.AddTimeEntry))
+	router.Delete("/{id}/time-entries/{entry}", h.Wrap(t.DeleteTimeEntry))
+	router.Post("/{id}/timer/start", h.Wrap(t.StartTimer))
+	router.Post("/{id}/timer/stop", h.Wrap(t.StopTimer))
+	router.Get("/{id}/attachments", h.Wrap(t.ListAttachments))
+	router.Post("/{id}/attachments", h.Wrap(t.AddAttachment))
+	router.Get("/{id}/attachments/{attachment}", h.Wrap(t.GetAttachment))
+	router.Delete("/{id}/attachments/{attachment}", h.Wrap(t.DeleteAttachment))
+	router.Post("/", h.Wrap(t.CreateTodo))
 	router.Get("/", h.Wrap(t.ListTodos))
+	router.Get("/search", h.Wrap(t.SearchTodos))
+	router.Get("/board", h.Wrap(t.TodoBoard))
+	router.Get("/time-report", h.Wrap(t.TimeReport))
+	router.Get("/export", h.Wrap(t.ExportTodos))
+	router.Post("/import", h.Wrap(t.ImportTodos))
 
 	t.Router = router
-	t.service = todo.NewTodoService()
 
 	return &t
 }
@@ -116,43 +89,209 @@ func NewTodoRouter() *TodoRouter {
 //	    tags:
 //	      - todos
 //	    summary: Get all Todos
-//	    description: Returns all Todos
+//	    description: Returns a page of Todos, the Link header links to the first, next and previous pages (RFC 8288). Pages of filtered Todos have no previous page.
 //	    operationId: listTodos
 //	    parameters:
+//	      - name: assignee
+//	        in: query
+//	        description: Only return the Todos assigned to this user, me is the user making the request
+//	        required: false
+//	        schema:
+//	          type: string
+//	          minLength: 1
+//	        example: me
+//	      - name: status
+//	        in: query
+//	        description: Only return the Todos with this status of the workflow
+//	        required: false
+//	        schema:
+//	          type: string
+//	          minLength: 1
+//	        example: in_progress
+//	      - name: ready
+//	        in: query
+//	        description: Only return the open Todos that aren't blocked by open Todos
+//	        required: false
+//	        schema:
+//	          type: boolean
 //	      - name: limit
 //	        in: query
-//	        description: The number of todo items to return (defaults to 10)
+//	        description: The number of todo items to return (defaults to 10), limits above service.maxLimit (100 by default) are lowered to it
 //	        required: false
 //	        schema:
-//	          type: number
+//	          type: integer
+//	          minimum: 1
 //	      - name: next
 //	        in: query
-//	        description: The next page identifier
+//	        description: The identifier of the page to return, the next or prev of another page (empty for the first page)
+//	        required: false
+//	        allowEmptyValue: true
+//	        schema:
+//	          type: string
+//	      - name: count
+//	        in: query
+//	        description: Return the total number of Todos, which is expensive on storage that can't count
+//	        required: false
+//	        schema:
+//	          type: boolean
+//	      - name: fields
+//	        in: query
+//	        description: The fields of the Todos to return, separated by commas (defaults to all of them)
+//	        required: false
+//	        style: form
+//	        explode: false
+//	        schema:
+//	          type: array
+//	          items:
+//	            type: string
+//	            enum: [id, summary, done, status, due, priority, assignees, estimateSeconds, trackedSeconds, completedAt, createdAt, updatedAt]
+//	          example: [id, summary]
+//	      - name: embed
+//	        in: query
+//	        description: Not supported yet, todos have no related resources (lists, subtasks or tags) to embed. Any value is answered with 400.
 //	        required: false
 //	        schema:
 //	          type: string
+//	          maxLength: 0
 //	    responses:
 //	      '200':
 //	        description: successful operation
+//	        headers:
+//	          Link:
+//	            description: Links to the first, next and previous pages
+//	            schema:
+//	              type: string
+//	              example: </todos?limit=10>; rel="first", </todos?limit=10&next=MDE5MDlhOGU>; rel="next"
 //	        content:
 //	          application/json:
 //	            schema:
 //	              $ref: '#/components/schemas/TodoList'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
 //	      '500':
 //	         $ref: '#/components/responses/ServerError'
 func (t *TodoRouter) ListTodos(w http.ResponseWriter, r *http.Request) error {
-	cursor := r.URL.Query().Get("next")
+	query := r.URL.Query()
+	cursor := query.Get("next")
 
-	limit, err := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	limit, err := strconv.Atoi(query.Get("limit"))
 	if (err != nil) || limit <= 0 {
-		limit = 10
+		limit = DefaultLimit
+	}
+	limit = min(limit, t.maxLimit)
+
+	fields, err := parseFields(r)
+	if err != nil {
+		return err
+	}
+	if err := parseEmbed(r); err != nil {
+		return err
+	}
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
 	}
 
-	todos, next, err := t.service.ListTodos(int(limit), cursor)
+	todos, next, err := t.service.FilterTodos(r.Context(), filter, limit, cursor)
 	if err != nil {
 		return err
 	}
-	render.JSON(w, r, types.TodoList{Todos: todos, Next: next})
+	if err := t.withTrackedTime(r.Context(), todos); err != nil {
+		return err
+	}
+	list := types.TodoList{Todos: todos, Next: next}
+
+	// Finding where the previous page of filtered todos starts means filtering the todos before
+	// it, filtered pages only link forward
+	if filter.IsZero() {
+		prev, ok, err := t.service.PreviousCursor(r.Context(), limit, cursor)
+		if err != nil {
+			return err
+		}
+		if ok {
+			list.Prev = &prev
+		}
+	}
+
+	if query.Get("count") == "true" {
+		count, err := t.service.CountTodos(r.Context(), filter)
+		if err != nil {
+			return err
+		}
+		list.TotalCount = &count
+	}
+
+	w.Header().Set("Link", pageLinks(r, limit, list))
+	if fields == nil {
+		render.JSON(w, r, list)
+		return nil
+	}
+
+	selected := []any{}
+	for _, todo := range todos {
+		s, err := selectFields(todo, fields)
+		if err != nil {
+			return err
+		}
+		selected = append(selected, s)
+	}
+	render.JSON(w, r, struct {
+		types.TodoList
+		Todos []any `json:"todos"`
+	}{TodoList: list, Todos: selected})
+	return nil
+}
+
+// @openapi
+// paths:
+//
+//	/todos/search:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Search Todos
+//	    description: Returns the Todos whose summary contains every word of the query, the most relevant first
+//	    operationId: searchTodos
+//	    parameters:
+//	      - name: q
+//	        in: query
+//	        description: The words to search for
+//	        required: true
+//	        schema:
+//	          type: string
+//	          minLength: 1
+//	        example: groceries
+//	      - name: limit
+//	        in: query
+//	        description: The number of todo items to return (defaults to 10), limits above service.maxLimit (100 by default) are lowered to it
+//	        required: false
+//	        schema:
+//	          type: integer
+//	          minimum: 1
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/TodoSearchResults'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) SearchTodos(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if (err != nil) || limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, t.maxLimit)
+
+	results, err := t.service.SearchTodos(r.Context(), query.Get("q"), limit)
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, types.TodoSearchResults{Results: results})
 	return nil
 }
 
@@ -173,6 +312,25 @@ func (t *TodoRouter) ListTodos(w http.ResponseWriter, r *http.Request) error {
 //	        required: true
 //	        schema:
 //	          type: string
+//	      - name: fields
+//	        in: query
+//	        description: The fields of the Todo to return, separated by commas (defaults to all of them)
+//	        required: false
+//	        style: form
+//	        explode: false
+//	        schema:
+//	          type: array
+//	          items:
+//	            type: string
+//	            enum: [id, summary, done, status, due, priority, assignees, estimateSeconds, trackedSeconds, completedAt, createdAt, updatedAt]
+//	          example: [id, summary]
+//	      - name: embed
+//	        in: query
+//	        description: Not supported yet, todos have no related resources (lists, subtasks or tags) to embed. Any value is answered with 400.
+//	        required: false
+//	        schema:
+//	          type: string
+//	          maxLength: 0
 //	    responses:
 //	      '200':
 //	        description: successful operation
@@ -186,11 +344,26 @@ func (t *TodoRouter) ListTodos(w http.ResponseWriter, r *http.Request) error {
 //	         $ref: '#/components/responses/ServerError'
 func (t *TodoRouter) GetTodo(w http.ResponseWriter, r *http.Request) error {
 	id := chi.URLParam(r, "id")
-	todo, err := t.service.GetTodo(id)
+	fields, err := parseFields(r)
 	if err != nil {
 		return err
 	}
-	render.JSON(w, r, todo)
+	if err := parseEmbed(r); err != nil {
+		return err
+	}
+	todo, err := t.service.GetTodo(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	todos := []types.Todo{todo}
+	if err := t.withTrackedTime(r.Context(), todos); err != nil {
+		return err
+	}
+	selected, err := selectFields(todos[0], fields)
+	if err != nil {
+		return err
+	}
+	render.JSON(w, r, selected)
 	return nil
 }
 
@@ -202,7 +375,7 @@ func (t *TodoRouter) GetTodo(w http.ResponseWriter, r *http.Request) error {
 //	    tags:
 //	      - todos
 //	    summary: Delete a single Todo
-//	    description: Deletes a Todos with the identifier {id} if exists
+//	    description: Deletes a Todos with the identifier {id} if exists, along with its comments, attachments, dependencies and time entries. Undoing or reverting the deletion restores the Todo without them.
 //	    operationId: deleteTodo
 //	    parameters:
 //	      - name: id
@@ -214,14 +387,19 @@ func (t *TodoRouter) GetTodo(w http.ResponseWriter, r *http.Request) error {
 //	    responses:
 //	      '204':
 //	        description: successful operation
+//	        headers:
+//	          Undo-Token:
+//	            $ref: '#/components/headers/UndoToken'
 //	      '500':
 //	         $ref: '#/components/responses/ServerError'
 func (t *TodoRouter) DeleteTodo(w http.ResponseWriter, r *http.Request) error {
 	id := chi.URLParam(r, "id")
-	err := t.service.DeleteTodo(id)
+	ctx, changes := todo.TrackChanges(r.Context())
+	err := t.service.DeleteTodo(ctx, id)
 	if err != nil {
 		return err
 	}
+	setUndoToken(w, changes)
 	render.NoContent(w, r)
 	return nil
 }
@@ -238,6 +416,7 @@ func (t *TodoRouter) DeleteTodo(w http.ResponseWriter, r *http.Request) error {
 //	    operationId: createTodo
 //	    requestBody:
 //	      description: Create a new Todo
+//	      required: true
 //	      content:
 //	        application/json:
 //	          schema:
@@ -245,6 +424,10 @@ func (t *TodoRouter) DeleteTodo(w http.ResponseWriter, r *http.Request) error {
 //	    responses:
 //	      '201':
 //	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Todo'
 //	      '400':
 //	         $ref: '#/components/responses/BadRequest'
 //	      '500':
@@ -257,7 +440,7 @@ func (t *TodoRouter) CreateTodo(w http.ResponseWriter, r *http.Request) error {
 		return decodeErr
 	}
 
-	todo, err := t.service.CreateTodo(todoToCreate)
+	todo, err := t.service.CreateTodo(r.Context(), todoToCreate)
 	if err != nil {
 		return err
 	}
@@ -286,17 +469,24 @@ func (t *TodoRouter) CreateTodo(w http.ResponseWriter, r *http.Request) error {
 //	          type: string
 //	    requestBody:
 //	      description: Updated Todo
+//	      required: true
 //	      content:
 //	        application/json:
 //	          schema:
-//	            $ref: '#/components/schemas/TodoUpdate'
+//	            allOf:
+//	              - $ref: '#/components/schemas/TodoUpdate'
+//	              - required:
+//	                  - done
 //	    responses:
 //	      '204':
 //	        description: successful operation
+//	        headers:
+//	          Undo-Token:
+//	            $ref: '#/components/headers/UndoToken'
 //	      '400':
-//	         $ref: '#/components/responses/NotFound'
-//	      '404':
 //	         $ref: '#/components/responses/BadRequest'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
 //	      '500':
 //	         $ref: '#/components/responses/ServerError'
 func (t *TodoRouter) ReplaceTodo(w http.ResponseWriter, r *http.Request) error {
@@ -309,17 +499,33 @@ func (t *TodoRouter) ReplaceTodo(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	currentRecord, err := t.service.GetTodo(id)
+	currentRecord, err := t.service.GetTodo(r.Context(), id)
 	if err != nil {
+		if ctxErr := r.Context().Err(); ctxErr != nil {
+			return ctxErr
+		}
 		return &errors.NotFound{Message: "Todo not found"}
 	}
 
-	todo := types.Todo{Id: currentRecord.Id, Summary: todoToUpdate.Summary, Done: todoToUpdate.Done}
-	err = t.service.UpdateTodo(todo)
+	// Tracked before todo shadows the package
+	ctx, changes := todo.TrackChanges(r.Context())
+	todo := types.Todo{
+		Id:              currentRecord.Id,
+		Summary:         todoToUpdate.Summary,
+		Done:            todoToUpdate.Done,
+		Status:          todoToUpdate.Status,
+		Due:             todoToUpdate.Due,
+		Priority:        todoToUpdate.Priority,
+		Assignees:       todoToUpdate.Assignees,
+		EstimateSeconds: todoToUpdate.EstimateSeconds,
+		CreatedAt:       currentRecord.CreatedAt,
+	}
+	err = t.service.UpdateTodo(ctx, todo)
 	if err != nil {
 		return err
 	}
 
+	setUndoToken(w, changes)
 	render.NoContent(w, r)
 	return nil
 }
@@ -332,7 +538,7 @@ func (t *TodoRouter) ReplaceTodo(w http.ResponseWriter, r *http.Request) error {
 //	    tags:
 //	      - todos
 //	    summary: Update a Todo
-//	    description: Update a Todo using [JSON Patch](https://jsonpatch.com/)
+//	    description: Update a Todo using [JSON Patch](https://jsonpatch.com/) (RFC 6902) or JSON Merge Patch (RFC 7396), depending on the Content-Type. The patched Todo must be valid and keep its read-only fields (id, trackedSeconds, createdAt, updatedAt and completedAt).
 //	    operationId: updateTodo
 //	    parameters:
 //	      - name: id
@@ -341,8 +547,16 @@ func (t *TodoRouter) ReplaceTodo(w http.ResponseWriter, r *http.Request) error {
 //	        required: true
 //	        schema:
 //	          type: string
+//	      - name: Prefer
+//	        in: header
+//	        description: Send return=representation to get the updated Todo in the response
+//	        required: false
+//	        schema:
+//	          type: string
+//	          example: return=representation
 //	    requestBody:
-//	      description: JSON Patch operations to perform in order to update the Todo item
+//	      description: The changes to make to the Todo item
+//	      required: true
 //	      content:
 //	        application/json-patch+json:
 //	          schema:
@@ -352,15 +566,55 @@ func (t *TodoRouter) ReplaceTodo(w http.ResponseWriter, r *http.Request) error {
 //	            example:
 //	              - {"op": "replace", "path": "/summary", "value": "An updated TODO item summary"}
 //	              - {"op": "replace", "path": "/done", "value": true}
+//	        application/merge-patch+json:
+//	          schema:
+//	            type: object
+//	            description: The fields to change, fields set to null are removed
+//	            example: {"summary": "An updated TODO item summary", "done": true, "due": null}
 //	    responses:
+//	      '200':
+//	        description: successful operation, sent when the request prefers return=representation
+//	        headers:
+//	          Preference-Applied:
+//	            schema:
+//	              type: string
+//	              example: return=representation
+//	          Undo-Token:
+//	            $ref: '#/components/headers/UndoToken'
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Todo'
 //	      '204':
 //	        description: successful operation
+//	        headers:
+//	          Undo-Token:
+//	            $ref: '#/components/headers/UndoToken'
 //	      '400':
-//	         $ref: '#/components/responses/NotFound'
-//	      '404':
 //	         $ref: '#/components/responses/BadRequest'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '415':
+//	        description: The Content-Type isn't a supported patch format, the supported formats are listed in the Accept-Patch header
+//	        headers:
+//	          Accept-Patch:
+//	            schema:
+//	              type: string
+//	              example: application/json-patch+json, application/merge-patch+json
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Error'
 //	      '500':
 //	         $ref: '#/components/responses/ServerError'
+//
+// components:
+//
+//	schemas:
+//	  PatchBody:
+//	    properties:
+//	      value:
+//	        nullable: true
 func (t *TodoRouter) UpdateTodo(w http.ResponseWriter, r *http.Request) error {
 	id := chi.URLParam(r, "id")
 
@@ -369,41 +623,58 @@ func (t *TodoRouter) UpdateTodo(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	patch, err := jsonpatch.DecodePatch(body)
+	apply, err := patcher(r.Header.Get("Content-Type"), body)
 	if err != nil {
-		return &errors.BadRequest{Message: err.Error()}
+		w.Header().Set("Accept-Patch", acceptPatch)
+		return err
 	}
 
-	currentRecord, err := t.service.GetTodo(id)
+	currentRecord, err := t.service.GetTodo(r.Context(), id)
 	if err != nil {
+		if ctxErr := r.Context().Err(); ctxErr != nil {
+			return ctxErr
+		}
 		return &errors.NotFound{Message: "Todo not found"}
 	}
+	// The tracked time is read-only, patches keep the current one
+	current := []types.Todo{currentRecord}
+	if err := t.withTrackedTime(r.Context(), current); err != nil {
+		return err
+	}
+	currentRecord = current[0]
 
 	currentBytes, err := json.Marshal(currentRecord)
 	if err != nil {
 		return err
 	}
 
-	modifiedBytes, err := patch.Apply(currentBytes)
+	modifiedBytes, err := apply(currentBytes)
 	if err != nil {
 		return &errors.BadRequest{Message: err.Error()}
 	}
 
-	var modified types.Todo
-	err = json.Unmarshal(modifiedBytes, &modified)
+	modified, err := patchedTodo(currentRecord, modifiedBytes)
 	if err != nil {
 		return err
 	}
 
-	if modified.Id != currentRecord.Id {
-		return &errors.BadRequest{Message: "Id field can't be changed"}
+	ctx, changes := todo.TrackChanges(r.Context())
+	err = t.service.UpdateTodo(ctx, modified)
+	if err != nil {
+		return err
 	}
+	setUndoToken(w, changes)
 
-	err = t.service.UpdateTodo(modified)
+	if !prefersRepresentation(r) {
+		render.NoContent(w, r)
+		return nil
+	}
+	updated, err := t.service.GetTodo(r.Context(), id)
 	if err != nil {
 		return err
 	}
-
-	render.NoContent(w, r)
+	updated.TrackedSeconds = currentRecord.TrackedSeconds
+	w.Header().Set("Preference-Applied", "return=representation")
+	render.JSON(w, r, updated)
 	return nil
 }
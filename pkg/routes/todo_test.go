@@ -0,0 +1,745 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/openapi/openapitest"
+	"todo-service/pkg/store/storetest"
+	"todo-service/pkg/types"
+)
+
+// now is the time of the fake clock used by the test router, every timestamp is set to it
+var now = time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+var due = time.Date(2024, time.July, 2, 17, 0, 0, 0, time.UTC)
+
+const (
+	firstId  = "00000000-0000-7000-8000-000000000001"
+	secondId = "00000000-0000-7000-8000-000000000002"
+	missing  = "00000000-0000-7000-8000-999999999999"
+)
+
+// newTestRouter returns a router backed by fake storage that holds count todos, the todos get
+// ascending ids starting at firstId
+func newTestRouter(t *testing.T, count int) (*TodoRouter, todo.TodoService) {
+	t.Helper()
+	return newTestRouterOn(t, fakes.NewStorage(), count)
+}
+
+// newTestRouterOn returns a router like newTestRouter backed by adapter
+func newTestRouterOn(t *testing.T, adapter storage.StorageAdapter, count int) (*TodoRouter, todo.TodoService) {
+	t.Helper()
+	service, err := todo.NewTodoService(todo.TodoServiceProps{
+		Storage:     adapter,
+		Clock:       fakes.NewClock(now),
+		IdGenerator: &fakes.IdGenerator{},
+	})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+	for i := 0; i < count; i++ {
+		if _, err := service.CreateTodo(context.Background(), types.TodoUpdate{Summary: "Pick up the groceries"}); err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+	}
+	return NewTodoRouter(service, 0, 0), service
+}
+
+// serve sends a request to router mounted at /todos behind the OpenAPI validator, like the server
+// serves it. Targets are relative to the mount point.
+func serve(t *testing.T, router *TodoRouter, method string, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	path, query, _ := strings.Cut(target, "?")
+	target = strings.TrimSuffix("/todos"+path, "/")
+	if query != "" {
+		target += "?" + query
+	}
+
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, target, nil)
+	} else {
+		req = httptest.NewRequest(method, target, strings.NewReader(body))
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	openapitest.Mount(t, "/todos", router.Router).ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("failed to decode response %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+func TestListTodos(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		wantCount int
+		wantNext  bool
+	}{
+		{name: "defaults to 10 items", target: "/", wantCount: 10, wantNext: true},
+		{name: "respects limit", target: "/?limit=3", wantCount: 3, wantNext: true},
+		{name: "limit larger than the collection", target: "/?limit=50", wantCount: 12, wantNext: false},
+	}
+
+	router, _ := newTestRouter(t, 12)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, tt.target, "", nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			list := decode[types.TodoList](t, w)
+			if len(list.Todos) != tt.wantCount {
+				t.Errorf("got %d todos, want %d", len(list.Todos), tt.wantCount)
+			}
+			if (list.Next != "") != tt.wantNext {
+				t.Errorf("next = %q, want a cursor: %v", list.Next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestListTodosInvalidLimit(t *testing.T) {
+	router, _ := newTestRouter(t, 2)
+	for _, target := range []string{"/?limit=abc", "/?limit=0", "/?limit=-1", "/?limit=2.5"} {
+		if w := serve(t, router, http.MethodGet, target, "", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s status = %d, want %d", target, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestListTodosCursor(t *testing.T) {
+	router, _ := newTestRouter(t, 5)
+
+	seen := []string{}
+	target := "/?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		w := serve(t, router, http.MethodGet, target, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		list := decode[types.TodoList](t, w)
+		for _, todo := range list.Todos {
+			seen = append(seen, todo.Id)
+		}
+		if list.Next == "" {
+			break
+		}
+		target = "/?limit=2&next=" + list.Next
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("paged through %d todos, want 5: %v", len(seen), seen)
+	}
+	for i := 1; i < len(seen); i++ {
+		if seen[i-1] >= seen[i] {
+			t.Errorf("todos are not in ascending order: %v", seen)
+		}
+	}
+}
+
+func TestListTodosPrev(t *testing.T) {
+	router, _ := newTestRouterOn(t, storetest.Memory(t), 5)
+
+	pages := [][]string{}
+	prevs := []*string{}
+	target := "/?limit=2"
+	for {
+		list := decode[types.TodoList](t, serve(t, router, http.MethodGet, target, "", nil))
+		ids := []string{}
+		for _, todo := range list.Todos {
+			ids = append(ids, todo.Id)
+		}
+		pages = append(pages, ids)
+		prevs = append(prevs, list.Prev)
+		if list.Next == "" {
+			break
+		}
+		target = "/?limit=2&next=" + list.Next
+	}
+
+	if len(pages) != 3 || prevs[0] != nil {
+		t.Fatalf("got %d pages with the first prev %v, want 3 pages and no prev on the first", len(pages), prevs[0])
+	}
+	for i := 1; i < len(pages); i++ {
+		if prevs[i] == nil {
+			t.Fatalf("page %d has no prev cursor", i)
+		}
+		list := decode[types.TodoList](t, serve(t, router, http.MethodGet, "/?limit=2&next="+url.QueryEscape(*prevs[i]), "", nil))
+		if len(list.Todos) != len(pages[i-1]) || list.Todos[0].Id != pages[i-1][0] {
+			t.Errorf("prev of page %d returned %+v, want %v", i, list.Todos, pages[i-1])
+		}
+	}
+}
+
+func TestListTodosLinks(t *testing.T) {
+	router, _ := newTestRouterOn(t, storetest.Memory(t), 5)
+
+	w := serve(t, router, http.MethodGet, "/?limit=2&fields=id", "", nil)
+	next := decode[types.TodoList](t, w).Next
+	want := `</todos?fields=id&limit=2>; rel="first", </todos?fields=id&limit=2&next=` + url.QueryEscape(next) + `>; rel="next"`
+	if got := w.Header().Get("Link"); got != want {
+		t.Errorf("Link = %s, want %s", got, want)
+	}
+
+	w = serve(t, router, http.MethodGet, "/?limit=2&next="+url.QueryEscape(next), "", nil)
+	if got := w.Header().Get("Link"); !strings.Contains(got, `</todos?limit=2>; rel="prev"`) {
+		t.Errorf("Link = %s, want the first page as prev", got)
+	}
+
+	// Storage that can only page forward has no prev
+	router, _ = newTestRouter(t, 5)
+	w = serve(t, router, http.MethodGet, "/?limit=2&next="+url.QueryEscape(next), "", nil)
+	if list := decode[types.TodoList](t, w); list.Prev != nil || strings.Contains(w.Header().Get("Link"), `rel="prev"`) {
+		t.Errorf("prev = %v, Link = %s, want no previous page", list.Prev, w.Header().Get("Link"))
+	}
+}
+
+func TestListTodosCount(t *testing.T) {
+	router, _ := newTestRouter(t, 3)
+
+	list := decode[types.TodoList](t, serve(t, router, http.MethodGet, "/?limit=1", "", nil))
+	if list.TotalCount != nil {
+		t.Errorf("totalCount = %d without count=true, want it left out", *list.TotalCount)
+	}
+	list = decode[types.TodoList](t, serve(t, router, http.MethodGet, "/?limit=1&count=true", "", nil))
+	if list.TotalCount == nil || *list.TotalCount != 3 {
+		t.Errorf("totalCount = %v, want 3", list.TotalCount)
+	}
+}
+
+func TestListTodosMaxLimit(t *testing.T) {
+	_, service := newTestRouter(t, 5)
+	router := NewTodoRouter(service, 2, 0)
+
+	w := serve(t, router, http.MethodGet, "/?limit=50", "", nil)
+	if list := decode[types.TodoList](t, w); len(list.Todos) != 2 || list.Next == "" {
+		t.Errorf("got %d todos and next %q, want the limit lowered to 2", len(list.Todos), list.Next)
+	}
+	if got := w.Header().Get("Link"); !strings.Contains(got, "limit=2") {
+		t.Errorf("Link = %s, want links with the lowered limit", got)
+	}
+}
+
+func TestSearchTodos(t *testing.T) {
+	router, service := newTestRouter(t, 0)
+	for _, summary := range []string{"Pick up the groceries", "Pay the rent", "Groceries for the party"} {
+		if _, err := service.CreateTodo(context.Background(), types.TodoUpdate{Summary: summary}); err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantCount  int
+	}{
+		{name: "matches", target: "/search?q=groceries", wantStatus: http.StatusOK, wantCount: 2},
+		{name: "every word must match", target: "/search?q=groceries+party", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "no match", target: "/search?q=taxes", wantStatus: http.StatusOK, wantCount: 0},
+		{name: "respects limit", target: "/search?q=groceries&limit=1", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "missing query", target: "/search", wantStatus: http.StatusBadRequest},
+		{name: "empty query", target: "/search?q=", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, tt.target, "", nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if results := decode[types.TodoSearchResults](t, w).Results; len(results) != tt.wantCount {
+				t.Errorf("got %d results, want %d: %+v", len(results), tt.wantCount, results)
+			}
+		})
+	}
+}
+
+func TestGetTodo(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{name: "existing todo", id: firstId, wantStatus: http.StatusOK},
+		{name: "missing todo", id: missing, wantStatus: http.StatusNotFound},
+	}
+
+	router, _ := newTestRouter(t, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, "/"+tt.id, "", nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				if got := decode[types.Todo](t, w); got.Id != tt.id {
+					t.Errorf("got todo %q, want %q", got.Id, tt.id)
+				}
+			}
+		})
+	}
+}
+
+func TestTodoFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantFields []string
+	}{
+		{name: "get selected fields", target: "/" + firstId + "?fields=id,summary", wantStatus: http.StatusOK, wantFields: []string{"id", "summary"}},
+		{name: "get unset field", target: "/" + firstId + "?fields=id,due", wantStatus: http.StatusOK, wantFields: []string{"id"}},
+		{name: "get unknown field", target: "/" + firstId + "?fields=id,color", wantStatus: http.StatusBadRequest},
+		{name: "list selected fields", target: "/?fields=summary,done", wantStatus: http.StatusOK, wantFields: []string{"done", "summary"}},
+		{name: "list unknown field", target: "/?fields=Summary", wantStatus: http.StatusBadRequest},
+		{name: "get embedded resources", target: "/" + firstId + "?embed=tags", wantStatus: http.StatusBadRequest},
+		{name: "list embedded resources", target: "/?embed=list,subtasks", wantStatus: http.StatusBadRequest},
+	}
+
+	router, _ := newTestRouter(t, 2)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, tt.target, "", nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			todos := []map[string]any{}
+			if strings.HasPrefix(tt.target, "/?") {
+				list := decode[struct {
+					Todos []map[string]any `json:"todos"`
+					Next  string           `json:"next"`
+				}](t, w)
+				if len(list.Todos) != 2 {
+					t.Fatalf("got %d todos, want 2", len(list.Todos))
+				}
+				todos = list.Todos
+			} else {
+				todos = append(todos, decode[map[string]any](t, w))
+			}
+			for _, todo := range todos {
+				fields := []string{}
+				for field := range todo {
+					fields = append(fields, field)
+				}
+				slices.Sort(fields)
+				if !slices.Equal(fields, tt.wantFields) {
+					t.Errorf("got fields %v, want %v", fields, tt.wantFields)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteTodo(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{name: "existing todo", id: firstId, wantStatus: http.StatusNoContent},
+		{name: "missing todo", id: missing, wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newTestRouter(t, 1)
+			w := serve(t, router, http.MethodDelete, "/"+tt.id, "", nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w := serve(t, router, http.MethodGet, "/"+tt.id, "", nil); w.Code != http.StatusNotFound {
+				t.Errorf("GET after DELETE status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestCreateTodo(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       types.Todo
+	}{
+		{
+			name:       "summary only",
+			body:       `{"summary": "Pick up the groceries"}`,
+			wantStatus: http.StatusCreated,
+			want:       types.Todo{Id: firstId, Summary: "Pick up the groceries", Status: "todo"},
+		},
+		{
+			name:       "summary and done",
+			body:       `{"summary": "Pick up the groceries", "done": true}`,
+			wantStatus: http.StatusCreated,
+			want:       types.Todo{Id: firstId, Summary: "Pick up the groceries", Done: true, Status: "done", CompletedAt: &now},
+		},
+		{
+			name:       "due date and priority",
+			body:       `{"summary": "Pick up the groceries", "due": "2024-07-02T17:00:00Z", "priority": 1}`,
+			wantStatus: http.StatusCreated,
+			want:       types.Todo{Id: firstId, Summary: "Pick up the groceries", Status: "todo", Due: &due, Priority: 1},
+		},
+		{name: "priority out of range", body: `{"summary": "todo", "priority": 10}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid due date", body: `{"summary": "todo", "due": "tomorrow"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing summary", body: `{"done": true}`, wantStatus: http.StatusBadRequest},
+		{name: "wrong summary type", body: `{"summary": 1}`, wantStatus: http.StatusBadRequest},
+		{name: "wrong done type", body: `{"summary": "todo", "done": "yes"}`, wantStatus: http.StatusBadRequest},
+		{name: "additional property", body: `{"summary": "todo", "id": "1"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid json", body: `{"summary": `, wantStatus: http.StatusBadRequest},
+		{name: "not an object", body: `["todo"]`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newTestRouter(t, 0)
+			w := serve(t, router, http.MethodPost, "/", tt.body, map[string]string{"Content-Type": "application/json"})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusCreated {
+				tt.want.CreatedAt, tt.want.UpdatedAt = now, now
+				if got := decode[types.Todo](t, w); !got.Equal(tt.want) {
+					t.Errorf("created %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReplaceTodo(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		wantStatus int
+		want       types.Todo
+	}{
+		{
+			name:       "replace all fields",
+			id:         firstId,
+			body:       `{"summary": "replaced", "done": true}`,
+			wantStatus: http.StatusNoContent,
+			want:       types.Todo{Id: firstId, Summary: "replaced", Done: true, Status: "done", CompletedAt: &now},
+		},
+		{name: "missing todo", id: missing, body: `{"summary": "replaced", "done": true}`, wantStatus: http.StatusNotFound},
+		{name: "missing done", id: firstId, body: `{"summary": "replaced"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing summary", id: firstId, body: `{"done": true}`, wantStatus: http.StatusBadRequest},
+		{name: "id in body", id: firstId, body: `{"id": "1", "summary": "replaced", "done": true}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid json", id: firstId, body: `not json`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, service := newTestRouter(t, 1)
+			w := serve(t, router, http.MethodPut, "/"+tt.id, tt.body, map[string]string{"Content-Type": "application/json"})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusNoContent {
+				tt.want.CreatedAt, tt.want.UpdatedAt = now, now
+				got, err := service.GetTodo(context.Background(), tt.id)
+				if err != nil || !got.Equal(tt.want) {
+					t.Errorf("stored %+v, %v, want %+v", got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTodo(t *testing.T) {
+	original := types.Todo{Id: firstId, Summary: "Pick up the groceries", Status: "todo"}
+	tests := []struct {
+		name        string
+		id          string
+		contentType string
+		body        string
+		wantStatus  int
+		want        types.Todo
+	}{
+		{
+			name:       "replace summary and done",
+			id:         firstId,
+			body:       `[{"op": "replace", "path": "/summary", "value": "patched"}, {"op": "replace", "path": "/done", "value": true}]`,
+			wantStatus: http.StatusNoContent,
+			want:       types.Todo{Id: firstId, Summary: "patched", Done: true, Status: "done", CompletedAt: &now},
+		},
+		{
+			name:       "successful test operation",
+			id:         firstId,
+			body:       `[{"op": "test", "path": "/done", "value": false}, {"op": "replace", "path": "/done", "value": true}]`,
+			wantStatus: http.StatusNoContent,
+			want:       types.Todo{Id: firstId, Summary: "Pick up the groceries", Done: true, Status: "done", CompletedAt: &now},
+		},
+		{
+			name:       "copy a field",
+			id:         firstId,
+			body:       `[{"op": "copy", "from": "/id", "path": "/summary"}]`,
+			wantStatus: http.StatusNoContent,
+			want:       types.Todo{Id: firstId, Summary: firstId, Status: "todo"},
+		},
+		{
+			name:       "empty patch",
+			id:         firstId,
+			body:       `[]`,
+			wantStatus: http.StatusNoContent,
+			want:       original,
+		},
+		{
+			name:       "changing the id",
+			id:         firstId,
+			body:       `[{"op": "replace", "path": "/id", "value": "` + secondId + `"}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "removing the id",
+			id:         firstId,
+			body:       `[{"op": "remove", "path": "/id"}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "failed test operation",
+			id:         firstId,
+			body:       `[{"op": "test", "path": "/done", "value": true}, {"op": "replace", "path": "/summary", "value": "patched"}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "replacing a missing path",
+			id:         firstId,
+			body:       `[{"op": "replace", "path": "/missing", "value": "patched"}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "unknown operation",
+			id:         firstId,
+			body:       `[{"op": "rename", "path": "/summary", "value": "patched"}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "not a patch document",
+			id:         firstId,
+			body:       `{"summary": "patched"}`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "changing a read-only field",
+			id:         firstId,
+			body:       `[{"op": "replace", "path": "/createdAt", "value": "2020-01-01T00:00:00Z"}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "setting the completion time",
+			id:         firstId,
+			body:       `[{"op": "add", "path": "/completedAt", "value": "2024-07-01T12:00:00Z"}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "wrong type",
+			id:         firstId,
+			body:       `[{"op": "replace", "path": "/done", "value": "yes"}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "priority out of range",
+			id:         firstId,
+			body:       `[{"op": "add", "path": "/priority", "value": 10}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "removing the summary",
+			id:         firstId,
+			body:       `[{"op": "remove", "path": "/summary"}]`,
+			wantStatus: http.StatusBadRequest,
+			want:       original,
+		},
+		{
+			name:       "missing todo",
+			id:         missing,
+			body:       `[{"op": "replace", "path": "/summary", "value": "patched"}]`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:        "merge patch",
+			id:          firstId,
+			contentType: "application/merge-patch+json",
+			body:        `{"summary": "merged", "done": true, "due": "2024-07-02T17:00:00Z", "priority": 2}`,
+			wantStatus:  http.StatusNoContent,
+			want:        types.Todo{Id: firstId, Summary: "merged", Done: true, Status: "done", Due: &due, Priority: 2, CompletedAt: &now},
+		},
+		{
+			name:        "merge patch with charset",
+			id:          firstId,
+			contentType: "application/merge-patch+json; charset=utf-8",
+			body:        `{"done": true}`,
+			wantStatus:  http.StatusNoContent,
+			want:        types.Todo{Id: firstId, Summary: "Pick up the groceries", Done: true, Status: "done", CompletedAt: &now},
+		},
+		{
+			name:        "merge patch removing a field",
+			id:          firstId,
+			contentType: "application/merge-patch+json",
+			body:        `{"priority": null, "due": null}`,
+			wantStatus:  http.StatusNoContent,
+			want:        original,
+		},
+		{
+			name:        "merge patch changing the id",
+			id:          firstId,
+			contentType: "application/merge-patch+json",
+			body:        `{"id": "` + secondId + `"}`,
+			wantStatus:  http.StatusBadRequest,
+			want:        original,
+		},
+		{
+			name:        "merge patch changing a read-only field",
+			id:          firstId,
+			contentType: "application/merge-patch+json",
+			body:        `{"updatedAt": "2020-01-01T00:00:00Z"}`,
+			wantStatus:  http.StatusBadRequest,
+			want:        original,
+		},
+		{
+			name:        "merge patch with a wrong type",
+			id:          firstId,
+			contentType: "application/merge-patch+json",
+			body:        `{"priority": "high"}`,
+			wantStatus:  http.StatusBadRequest,
+			want:        original,
+		},
+		{
+			name:        "merge patch adding an unknown field",
+			id:          firstId,
+			contentType: "application/merge-patch+json",
+			body:        `{"color": "red"}`,
+			wantStatus:  http.StatusBadRequest,
+			want:        original,
+		},
+		{
+			name:        "merge patch that isn't an object",
+			id:          firstId,
+			contentType: "application/merge-patch+json",
+			body:        `["done"]`,
+			wantStatus:  http.StatusBadRequest,
+			want:        original,
+		},
+		{
+			name:        "plain JSON",
+			id:          firstId,
+			contentType: "application/json",
+			body:        `{"summary": "patched"}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			want:        original,
+		},
+		{
+			name:        "unknown content type",
+			id:          firstId,
+			contentType: "text/plain",
+			body:        `summary=patched`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			want:        original,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, service := newTestRouter(t, 1)
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json-patch+json"
+			}
+			w := serve(t, router, http.MethodPatch, "/"+tt.id, tt.body, map[string]string{"Content-Type": contentType})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if accept := w.Header().Get("Accept-Patch"); tt.wantStatus == http.StatusUnsupportedMediaType && !strings.Contains(accept, "application/merge-patch+json") {
+				t.Errorf("Accept-Patch = %q, want the supported patch formats", accept)
+			}
+			if tt.want.Id != "" {
+				tt.want.CreatedAt, tt.want.UpdatedAt = now, now
+				got, err := service.GetTodo(context.Background(), tt.want.Id)
+				if err != nil || !got.Equal(tt.want) {
+					t.Errorf("stored %+v, %v, want %+v", got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTodoReturnRepresentation(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefer     string
+		wantStatus int
+	}{
+		{name: "no preference", wantStatus: http.StatusNoContent},
+		{name: "return representation", prefer: "return=representation", wantStatus: http.StatusOK},
+		{name: "among other preferences", prefer: "respond-async, return=representation; foo=bar", wantStatus: http.StatusOK},
+		{name: "return minimal", prefer: "return=minimal", wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newTestRouter(t, 1)
+			headers := map[string]string{"Content-Type": "application/merge-patch+json"}
+			if tt.prefer != "" {
+				headers["Prefer"] = tt.prefer
+			}
+			w := serve(t, router, http.MethodPatch, "/"+firstId, `{"summary": "merged", "done": true}`, headers)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			want := types.Todo{Id: firstId, Summary: "merged", Done: true, Status: "done", CompletedAt: &now, CreatedAt: now, UpdatedAt: now}
+			if got := decode[types.Todo](t, w); !got.Equal(want) {
+				t.Errorf("response = %+v, want %+v", got, want)
+			}
+			if applied := w.Header().Get("Preference-Applied"); applied != "return=representation" {
+				t.Errorf("Preference-Applied = %q, want return=representation", applied)
+			}
+		})
+	}
+}
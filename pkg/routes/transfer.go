@@ -0,0 +1,203 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+
+	"todo-service/pkg/features/todo"
+	"todo-service/pkg/features/transfer"
+	serviceMiddlewares "todo-service/pkg/middlewares"
+)
+
+// @openapi
+// paths:
+//
+//	/todos/export:
+//	  get:
+//	    tags:
+//	      - todos
+//	    summary: Export all Todos
+//	    description: Streams every Todo, preserving ids and timestamps, in the requested format
+//	    operationId: exportTodos
+//	    parameters:
+//	      - name: format
+//	        in: query
+//	        description: The export format (defaults to ndjson)
+//	        required: false
+//	        schema:
+//	          type: string
+//	          enum: [ndjson, json, csv, ics]
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/x-ndjson:
+//	            schema:
+//	              $ref: '#/components/schemas/Todo'
+//	          application/json:
+//	            schema:
+//	              type: array
+//	              items:
+//	                $ref: '#/components/schemas/Todo'
+//	          text/csv:
+//	            schema:
+//	              type: string
+//	          text/calendar:
+//	            schema:
+//	              type: string
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) ExportTodos(w http.ResponseWriter, r *http.Request) error {
+	format := transfer.NDJSON
+	if value := r.URL.Query().Get("format"); value != "" {
+		var err error
+		if format, err = transfer.ParseFormat(value); err != nil {
+			return &serviceErrors.BadRequest{Message: err.Error()}
+		}
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="todos.%s"`, format))
+
+	tracker := &writeTracker{ResponseWriter: w}
+	err := transfer.Export(r.Context(), t.service, format, tracker)
+	if err != nil && tracker.written {
+		// The response is already on its way, all we can do is stop streaming it
+		slog.Error("failed to export todos", slog.Any("error", err))
+		return nil
+	}
+	return err
+}
+
+// @openapi
+// paths:
+//
+//	/todos/import:
+//	  post:
+//	    tags:
+//	      - todos
+//	    summary: Import Todos
+//	    description: Imports Todos exported by /todos/export, preserving their ids and timestamps
+//	    operationId: importTodos
+//	    parameters:
+//	      - name: format
+//	        in: query
+//	        description: The format of the request body, defaults to the format matching the Content-Type header or ndjson
+//	        required: false
+//	        schema:
+//	          type: string
+//	          enum: [ndjson, json, csv, ics]
+//	      - name: conflict
+//	        in: query
+//	        description: What to do with Todos whose id already exists, skip them, overwrite the existing Todo or import them with a new id (defaults to skip)
+//	        required: false
+//	        schema:
+//	          type: string
+//	          enum: [skip, overwrite, new-id]
+//	      - name: dryRun
+//	        in: query
+//	        description: Report what would be imported without changing anything
+//	        required: false
+//	        schema:
+//	          type: boolean
+//	    requestBody:
+//	      description: The Todos to import
+//	      required: true
+//	      content:
+//	        application/x-ndjson:
+//	          schema:
+//	            $ref: '#/components/schemas/Todo'
+//	        application/json:
+//	          schema:
+//	            type: array
+//	            items:
+//	              $ref: '#/components/schemas/Todo'
+//	        text/csv:
+//	          schema:
+//	            type: string
+//	        text/calendar:
+//	          schema:
+//	            type: string
+//	            description: An iCalendar file, VTODO and VEVENT components are imported as Todos
+//	    responses:
+//	      '200':
+//	        description: successful operation
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/ImportSummary'
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '413':
+//	        description: The body is larger than service.maxImportSize, the records read before the limit was reached stay imported
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Error'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+func (t *TodoRouter) ImportTodos(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+
+	format, ok := transfer.FormatFromContentType(r.Header.Get("Content-Type"))
+	if !ok {
+		format = transfer.NDJSON
+	}
+	if value := query.Get("format"); value != "" {
+		var err error
+		if format, err = transfer.ParseFormat(value); err != nil {
+			return &serviceErrors.BadRequest{Message: err.Error()}
+		}
+	}
+
+	options := todo.ImportOptions{Conflict: todo.ConflictSkip, DryRun: query.Get("dryRun") == "true"}
+	if value := query.Get("conflict"); value != "" {
+		var err error
+		if options.Conflict, err = todo.ParseConflictMode(value); err != nil {
+			return &serviceErrors.BadRequest{Message: err.Error()}
+		}
+	}
+
+	tooLarge := &serviceMiddlewares.ContentTooLarge{Message: fmt.Sprintf("imports are limited to %d bytes", t.maxImportSize)}
+	if r.ContentLength > t.maxImportSize {
+		return tooLarge
+	}
+	summary, err := transfer.Import(r.Context(), t.service, format, http.MaxBytesReader(w, r.Body, t.maxImportSize), options)
+	if err != nil {
+		if ctxErr := r.Context().Err(); ctxErr != nil {
+			return ctxErr
+		}
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			return tooLarge
+		}
+		return &serviceErrors.BadRequest{Message: err.Error()}
+	}
+
+	render.JSON(w, r, summary)
+	return nil
+}
+
+// writeTracker records whether anything was written to the response
+type writeTracker struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *writeTracker) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writeTracker) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
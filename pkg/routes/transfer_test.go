@@ -0,0 +1,156 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo-service/pkg/types"
+)
+
+func TestExportTodos(t *testing.T) {
+	tests := []struct {
+		name            string
+		target          string
+		wantStatus      int
+		wantContentType string
+		wantLines       int
+	}{
+		{name: "defaults to ndjson", target: "/export", wantStatus: http.StatusOK, wantContentType: "application/x-ndjson", wantLines: 3},
+		{name: "json", target: "/export?format=json", wantStatus: http.StatusOK, wantContentType: "application/json", wantLines: 5},
+		{name: "csv", target: "/export?format=csv", wantStatus: http.StatusOK, wantContentType: "text/csv", wantLines: 4},
+		{name: "unknown format", target: "/export?format=xml", wantStatus: http.StatusBadRequest},
+	}
+
+	router, _ := newTestRouter(t, 3)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, tt.target, "", nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := strings.Count(w.Body.String(), "\n"); got != tt.wantLines {
+				t.Errorf("got %d lines, want %d: %s", got, tt.wantLines, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestImportTodos(t *testing.T) {
+	existing := `{"id": "` + firstId + `", "summary": "imported", "createdAt": "2023-01-01T00:00:00Z"}`
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		wantStatus  int
+		want        types.ImportSummary
+		wantSummary string
+	}{
+		{
+			name:        "skips existing todos by default",
+			target:      "/import",
+			contentType: "application/x-ndjson",
+			body:        existing + "\n" + `{"summary": "new"}`,
+			wantStatus:  http.StatusOK,
+			want:        types.ImportSummary{Created: 1, Skipped: 1},
+			wantSummary: "Pick up the groceries",
+		},
+		{
+			name:        "overwrite",
+			target:      "/import?conflict=overwrite",
+			contentType: "application/x-ndjson",
+			body:        existing,
+			wantStatus:  http.StatusOK,
+			want:        types.ImportSummary{Overwritten: 1},
+			wantSummary: "imported",
+		},
+		{
+			name:        "dry run",
+			target:      "/import?conflict=overwrite&dryRun=true",
+			contentType: "application/x-ndjson",
+			body:        existing,
+			wantStatus:  http.StatusOK,
+			want:        types.ImportSummary{Overwritten: 1, DryRun: true},
+			wantSummary: "Pick up the groceries",
+		},
+		{
+			name:        "format from content type",
+			target:      "/import?conflict=new-id",
+			contentType: "application/json",
+			body:        "[" + existing + "]",
+			wantStatus:  http.StatusOK,
+			want:        types.ImportSummary{Created: 1},
+			wantSummary: "Pick up the groceries",
+		},
+		{
+			name:        "format query parameter",
+			target:      "/import?format=csv",
+			contentType: "text/plain",
+			body:        "summary\nnew\n",
+			wantStatus:  http.StatusOK,
+			want:        types.ImportSummary{Created: 1},
+			wantSummary: "Pick up the groceries",
+		},
+		{
+			name:        "icalendar",
+			target:      "/import?conflict=overwrite",
+			contentType: "text/calendar",
+			body:        "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:" + firstId + "\r\nSUMMARY:from a calendar\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+			wantStatus:  http.StatusOK,
+			want:        types.ImportSummary{Overwritten: 1},
+			wantSummary: "from a calendar",
+		},
+		{name: "unknown conflict mode", target: "/import?conflict=merge", body: existing, wantStatus: http.StatusBadRequest},
+		{name: "unknown format", target: "/import?format=xml", body: existing, wantStatus: http.StatusBadRequest},
+		{name: "unreadable input", target: "/import?format=json", body: existing, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, service := newTestRouter(t, 1)
+			w := serve(t, router, http.MethodPost, tt.target, tt.body, map[string]string{"Content-Type": tt.contentType})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := decode[types.ImportSummary](t, w); got.Created != tt.want.Created || got.Overwritten != tt.want.Overwritten || got.Skipped != tt.want.Skipped || got.DryRun != tt.want.DryRun {
+				t.Errorf("summary = %+v, want %+v", got, tt.want)
+			}
+			if got, err := service.GetTodo(context.Background(), firstId); err != nil || got.Summary != tt.wantSummary {
+				t.Errorf("stored %+v, %v, want summary %q", got, err, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestImportTodosTooLarge(t *testing.T) {
+	_, service := newTestRouter(t, 0)
+	router := NewTodoRouter(service, 0, 64)
+	body := strings.Repeat(`{"summary": "new"}`+"\n", 10)
+
+	w := serve(t, router, http.MethodPost, "/import", body, map[string]string{"Content-Type": "application/x-ndjson"})
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusRequestEntityTooLarge, w.Body.String())
+	}
+
+	// Bodies of unknown length are cut off once they exceed the limit
+	req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	router.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status of a body of unknown length = %d, want %d: %s", w.Code, http.StatusRequestEntityTooLarge, w.Body.String())
+	}
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"todo-service/pkg/features/todo"
+	serviceMiddlewares "todo-service/pkg/middlewares"
+)
+
+// undoTokenHeader is the response header holding the token that undoes the changes of a request
+const undoTokenHeader = "Undo-Token"
+
+// setUndoToken sends the token undoing the tracked changes, if anything changed
+func setUndoToken(w http.ResponseWriter, changes *todo.Changes) {
+	if token := changes.UndoToken(); token != "" {
+		w.Header().Set(undoTokenHeader, token)
+	}
+}
+
+// UndoRouter undoes the changes of earlier requests with the undo tokens they returned
+type UndoRouter struct {
+	Router  *chi.Mux
+	service todo.TodoService
+}
+
+func NewUndoRouter(service todo.TodoService) *UndoRouter {
+	u := UndoRouter{service: service}
+	h := serviceMiddlewares.ErrorHandler{}
+
+	router := chi.NewRouter()
+	router.Post("/{token}", h.Wrap(u.Undo))
+
+	u.Router = router
+
+	return &u
+}
+
+// @openapi
+// paths:
+//
+//	/undo/{token}:
+//	  post:
+//	    tags:
+//	      - todos
+//	    summary: Undo a change
+//	    description: Reverts the changes of the request that returned the Undo-Token {token}. Changes can only be undone for the configured undo window (service.undoWindow) and while the Todos are the way the changes left them. Undoing a deletion restores the Todo only, its comments, attachments, dependencies and time entries were deleted with it and stay deleted.
+//	    operationId: undo
+//	    parameters:
+//	      - name: token
+//	        in: path
+//	        description: The Undo-Token header of the response to the change
+//	        required: true
+//	        schema:
+//	          type: string
+//	    responses:
+//	      '204':
+//	        description: successful operation
+//	      '400':
+//	         $ref: '#/components/responses/BadRequest'
+//	      '404':
+//	         $ref: '#/components/responses/NotFound'
+//	      '409':
+//	        description: A Todo was changed again since, the change can't be undone
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Error'
+//	      '410':
+//	        description: The undo window has passed, the change can't be undone anymore
+//	        content:
+//	          application/json:
+//	            schema:
+//	              $ref: '#/components/schemas/Error'
+//	      '500':
+//	         $ref: '#/components/responses/ServerError'
+//
+// components:
+//
+//	headers:
+//	  UndoToken:
+//	    description: Undoes the changes of the request with POST /undo/{token}
+//	    schema:
+//	      type: string
+func (u *UndoRouter) Undo(w http.ResponseWriter, r *http.Request) error {
+	err := u.service.Undo(r.Context(), chi.URLParam(r, "token"))
+	switch {
+	case errors.Is(err, todo.ErrChangedSince):
+		return &serviceMiddlewares.Conflict{Message: err.Error()}
+	case errors.Is(err, todo.ErrUndoExpired):
+		return &serviceMiddlewares.Gone{Message: err.Error()}
+	case err != nil:
+		return err
+	}
+	render.NoContent(w, r)
+	return nil
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-service/pkg/openapi/openapitest"
+)
+
+func TestUndoTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		headers map[string]string
+	}{
+		{name: "delete", method: http.MethodDelete, target: "/" + firstId},
+		{name: "replace", method: http.MethodPut, target: "/" + firstId, body: `{"summary": "Pick up the mail", "done": false}`, headers: map[string]string{"Content-Type": "application/json"}},
+		{name: "patch", method: http.MethodPatch, target: "/" + firstId, body: `{"done": true}`, headers: map[string]string{"Content-Type": "application/merge-patch+json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, service := newTestRouter(t, 1)
+			w := serve(t, router, tt.method, tt.target, tt.body, tt.headers)
+			token := w.Header().Get("Undo-Token")
+			if w.Code != http.StatusNoContent || token == "" {
+				t.Fatalf("response = %d with Undo-Token %q, want %d and a token: %s", w.Code, token, http.StatusNoContent, w.Body.String())
+			}
+
+			undo := openapitest.Mount(t, "/undo", NewUndoRouter(service).Router)
+			w = httptest.NewRecorder()
+			undo.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/undo/"+token, nil))
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("undo status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+			}
+			todo, err := service.GetTodo(context.Background(), firstId)
+			if err != nil || todo.Summary != "Pick up the groceries" || todo.Done {
+				t.Errorf("GetTodo() after the undo = %+v, %v, want the todo as it was", todo, err)
+			}
+
+			// The undo changed the todo again
+			w = httptest.NewRecorder()
+			undo.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/undo/"+token, nil))
+			if w.Code != http.StatusConflict {
+				t.Errorf("second undo status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUndo(t *testing.T) {
+	_, service := newTestRouter(t, 1)
+	undo := openapitest.Mount(t, "/undo", NewUndoRouter(service).Router)
+
+	tests := map[string]int{
+		"/undo/not!a!token": http.StatusBadRequest,
+		"/undo/bWlzc2luZw":  http.StatusBadRequest, // "missing" without a signature
+	}
+	for target, wantStatus := range tests {
+		w := httptest.NewRecorder()
+		undo.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		if w.Code != wantStatus {
+			t.Errorf("POST %s status = %d, want %d: %s", target, w.Code, wantStatus, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"todo-service/pkg/actor"
+)
+
+// UnaryActor sets the actor of calls to the value of their header metadata, the gRPC counterpart
+// of middlewares.Actor. Calls without the header are made by actor.Anonymous.
+func UnaryActor(header string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withActor(ctx, header), req)
+	}
+}
+
+// StreamActor sets the actor of streams the way UnaryActor does for unary calls
+func StreamActor(header string) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &actorStream{ServerStream: stream, ctx: withActor(stream.Context(), header)})
+	}
+}
+
+// actorStream is a ServerStream whose context carries the actor
+type actorStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *actorStream) Context() context.Context {
+	return s.ctx
+}
+
+// withActor returns ctx carrying the actor named by the header metadata of the incoming call,
+// metadata keys are lowercase so the header matches regardless of its case
+func withActor(ctx context.Context, header string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, name := range md.Get(header) {
+		if name = strings.TrimSpace(name); name != "" {
+			return actor.NewContext(ctx, name)
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"todo-service/pkg/features/todo"
+	todov1 "todo-service/pkg/proto/todo/v1"
+	"todo-service/pkg/types"
+)
+
+func toProto(t types.Todo) *todov1.Todo {
+	return &todov1.Todo{
+		Id:          t.Id,
+		Summary:     t.Summary,
+		Done:        t.Done,
+		Due:         toTimestamp(t.Due),
+		Priority:    int32(t.Priority),
+		CompletedAt: toTimestamp(t.CompletedAt),
+		CreatedAt:   toTimestamp(&t.CreatedAt),
+		UpdatedAt:   toTimestamp(&t.UpdatedAt),
+	}
+}
+
+// fromProto returns the todo fields clients can set
+func fromProto(t *todov1.Todo) types.Todo {
+	todo := types.Todo{
+		Id:       t.GetId(),
+		Summary:  t.GetSummary(),
+		Done:     t.GetDone(),
+		Priority: int(t.GetPriority()),
+	}
+	if t.GetDue() != nil {
+		due := t.GetDue().AsTime()
+		todo.Due = &due
+	}
+	return todo
+}
+
+func toProtoEvent(event todo.TodoEvent) *todov1.TodoEvent {
+	eventTypes := map[todo.EventType]todov1.TodoEvent_Type{
+		todo.EventCreated: todov1.TodoEvent_TYPE_CREATED,
+		todo.EventUpdated: todov1.TodoEvent_TYPE_UPDATED,
+		todo.EventDeleted: todov1.TodoEvent_TYPE_DELETED,
+	}
+	if event.Type == todo.EventDeleted {
+		return &todov1.TodoEvent{Type: eventTypes[event.Type], Todo: &todov1.Todo{Id: event.Todo.Id}}
+	}
+	return &todov1.TodoEvent{Type: eventTypes[event.Type], Todo: toProto(event.Todo)}
+}
+
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
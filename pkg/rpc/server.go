@@ -0,0 +1,259 @@
+// Package rpc implements the gRPC API, a transport over the same TodoService as the REST routes
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"todo-service/pkg/features/todo"
+	todov1 "todo-service/pkg/proto/todo/v1"
+	"todo-service/pkg/types"
+)
+
+const (
+	// defaultPageSize matches the default limit of the REST API
+	defaultPageSize = 10
+	// defaultMaxPageSize matches the default max limit of the REST API
+	defaultMaxPageSize = 100
+)
+
+// updatableFields are the fields UpdateTodo can change
+var updatableFields = []string{"summary", "done", "due", "priority"}
+
+// TodoServer implements todov1.TodoServiceServer
+type TodoServer struct {
+	todov1.UnimplementedTodoServiceServer
+	service     todo.TodoService
+	maxPageSize int
+}
+
+// NewTodoServer creates a TodoServer listing at most maxPageSize todos at once, larger page sizes
+// are lowered to it. A maxPageSize <= 0 uses the default max limit of the REST API.
+func NewTodoServer(service todo.TodoService, maxPageSize int) *TodoServer {
+	if maxPageSize <= 0 {
+		maxPageSize = defaultMaxPageSize
+	}
+	return &TodoServer{service: service, maxPageSize: maxPageSize}
+}
+
+// NewServer returns a gRPC server serving the todo API along with the health and reflection
+// services, pages hold at most maxPageSize todos
+func NewServer(service todo.TodoService, maxPageSize int, options ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(options...)
+	todov1.RegisterTodoServiceServer(server, NewTodoServer(service, maxPageSize))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(todov1.TodoService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server
+}
+
+func (s *TodoServer) ListTodos(ctx context.Context, req *todov1.ListTodosRequest) (*todov1.ListTodosResponse, error) {
+	if req.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	pageSize := int(req.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, s.maxPageSize)
+
+	todos, next, err := s.service.ListTodos(ctx, pageSize, req.PageToken)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	res := &todov1.ListTodosResponse{NextPageToken: next}
+	for _, t := range todos {
+		res.Todos = append(res.Todos, toProto(t))
+	}
+	return res, nil
+}
+
+func (s *TodoServer) GetTodo(ctx context.Context, req *todov1.GetTodoRequest) (*todov1.Todo, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	t, err := s.service.GetTodo(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toProto(t), nil
+}
+
+func (s *TodoServer) CreateTodo(ctx context.Context, req *todov1.CreateTodoRequest) (*todov1.Todo, error) {
+	if err := validate(req.Todo); err != nil {
+		return nil, err
+	}
+
+	update := fromProto(req.Todo)
+	t, err := s.service.CreateTodo(ctx, types.TodoUpdate{Summary: update.Summary, Done: update.Done, Due: update.Due, Priority: update.Priority})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toProto(t), nil
+}
+
+func (s *TodoServer) ReplaceTodo(ctx context.Context, req *todov1.ReplaceTodoRequest) (*todov1.Todo, error) {
+	if err := validate(req.Todo); err != nil {
+		return nil, err
+	}
+	if req.Todo.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "todo.id is required")
+	}
+
+	current, err := s.service.GetTodo(ctx, req.Todo.Id)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	update := fromProto(req.Todo)
+	current.Summary = update.Summary
+	current.Done = update.Done
+	current.Due = update.Due
+	current.Priority = update.Priority
+	return s.update(ctx, current)
+}
+
+func (s *TodoServer) UpdateTodo(ctx context.Context, req *todov1.UpdateTodoRequest) (*todov1.Todo, error) {
+	if req.Todo == nil || req.Todo.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "todo.id is required")
+	}
+
+	paths := req.UpdateMask.GetPaths()
+	if len(paths) == 0 || (len(paths) == 1 && paths[0] == "*") {
+		paths = updatableFields
+	}
+
+	current, err := s.service.GetTodo(ctx, req.Todo.Id)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	update := fromProto(req.Todo)
+	for _, path := range paths {
+		switch path {
+		case "summary":
+			current.Summary = update.Summary
+		case "done":
+			current.Done = update.Done
+		case "due":
+			current.Due = update.Due
+		case "priority":
+			current.Priority = update.Priority
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask path %q isn't one of %s", path, strings.Join(updatableFields, ", "))
+		}
+	}
+	if err := validate(toProto(current)); err != nil {
+		return nil, err
+	}
+	return s.update(ctx, current)
+}
+
+func (s *TodoServer) DeleteTodo(ctx context.Context, req *todov1.DeleteTodoRequest) (*emptypb.Empty, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if err := s.service.DeleteTodo(ctx, req.Id); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// WatchTodos streams the changes made to todos until the client goes away. Clients that can't keep
+// up are disconnected with ResourceExhausted and should list the todos again before watching.
+func (s *TodoServer) WatchTodos(req *todov1.WatchTodosRequest, stream todov1.TodoService_WatchTodosServer) error {
+	ctx := stream.Context()
+	events := s.service.WatchTodos(ctx)
+	// Sending the headers right away tells clients the watch started, changes made after it are sent
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return toStatus(ctx, ctx.Err())
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return toStatus(ctx, ctx.Err())
+				}
+				return status.Error(codes.ResourceExhausted, "the watcher fell too far behind and missed events")
+			}
+			// The proto has no assignment events, the update they follow carries the new assignees
+			if event.Type == todo.EventAssigned {
+				continue
+			}
+			if err := stream.Send(toProtoEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// update stores t and returns it as stored
+func (s *TodoServer) update(ctx context.Context, t types.Todo) (*todov1.Todo, error) {
+	if err := s.service.UpdateTodo(ctx, t); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	updated, err := s.service.GetTodo(ctx, t.Id)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toProto(updated), nil
+}
+
+// validate applies the rules the OpenAPI definition of the REST API sets for todos
+func validate(t *todov1.Todo) error {
+	if t == nil {
+		return status.Error(codes.InvalidArgument, "todo is required")
+	}
+	if t.Summary == "" {
+		return status.Error(codes.InvalidArgument, "todo.summary is required")
+	}
+	if t.Priority < 0 || t.Priority > 9 {
+		return status.Error(codes.InvalidArgument, "todo.priority must be between 0 and 9")
+	}
+	if t.Due != nil {
+		if err := t.Due.CheckValid(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "todo.due is invalid: %v", err)
+		}
+	}
+	return nil
+}
+
+// toStatus maps service errors to gRPC status errors the way the REST error handler maps them to
+// HTTP status codes, internal errors are logged rather than reported
+func toStatus(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return status.FromContextError(ctxErr).Err()
+	}
+	var badRequest *serviceErrors.BadRequest
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return status.Error(codes.NotFound, "Todo not found")
+	case errors.As(err, &badRequest):
+		return status.Error(codes.InvalidArgument, badRequest.Message)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return status.FromContextError(err).Err()
+	}
+	slog.Error("gRPC request failed", slog.Any("error", err))
+	return status.Error(codes.Internal, "internal server error")
+}
@@ -0,0 +1,320 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"todo-service/pkg/actor"
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/features/todo"
+	todov1 "todo-service/pkg/proto/todo/v1"
+	"todo-service/pkg/types"
+)
+
+var now = time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+const firstId = "00000000-0000-7000-8000-000000000001"
+
+// newClient serves the gRPC API over an in-memory connection
+func newClient(t *testing.T) (todov1.TodoServiceClient, *grpc.ClientConn) {
+	t.Helper()
+	return serveService(t, newService(t))
+}
+
+func newService(t *testing.T) todo.TodoService {
+	t.Helper()
+	service, err := todo.NewTodoService(todo.TodoServiceProps{
+		Storage:     fakes.NewStorage(),
+		Clock:       fakes.NewClock(now),
+		IdGenerator: &fakes.IdGenerator{},
+	})
+	if err != nil {
+		t.Fatalf("NewTodoService() error = %v", err)
+	}
+	return service
+}
+
+// serveService serves the gRPC API over service through an in-memory connection
+func serveService(t *testing.T, service todo.TodoService, options ...grpc.ServerOption) (todov1.TodoServiceClient, *grpc.ClientConn) {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewServer(service, 0, options...)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return todov1.NewTodoServiceClient(conn), conn
+}
+
+func TestTodoServer(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t)
+
+	created, err := client.CreateTodo(ctx, &todov1.CreateTodoRequest{Todo: &todov1.Todo{Summary: "Pick up the groceries", Priority: 3}})
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+	if created.Id != firstId || created.Priority != 3 || !created.CreatedAt.AsTime().Equal(now) {
+		t.Errorf("CreateTodo() = %v", created)
+	}
+
+	got, err := client.GetTodo(ctx, &todov1.GetTodoRequest{Id: firstId})
+	if err != nil || got.Summary != "Pick up the groceries" {
+		t.Errorf("GetTodo() = %v, %v", got, err)
+	}
+
+	// Only the fields in the mask change
+	due := timestamppb.New(now.Add(24 * time.Hour))
+	updated, err := client.UpdateTodo(ctx, &todov1.UpdateTodoRequest{
+		Todo:       &todov1.Todo{Id: firstId, Summary: "ignored", Done: true, Due: due},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"done", "due"}},
+	})
+	if err != nil || updated.Summary != "Pick up the groceries" || !updated.Done || updated.CompletedAt == nil || !updated.Due.AsTime().Equal(due.AsTime()) || updated.Priority != 3 {
+		t.Errorf("UpdateTodo() = %v, %v", updated, err)
+	}
+
+	replaced, err := client.ReplaceTodo(ctx, &todov1.ReplaceTodoRequest{Todo: &todov1.Todo{Id: firstId, Summary: "replaced"}})
+	if err != nil || replaced.Summary != "replaced" || replaced.Done || replaced.Due != nil || replaced.Priority != 0 || !replaced.CreatedAt.AsTime().Equal(now) {
+		t.Errorf("ReplaceTodo() = %v, %v", replaced, err)
+	}
+
+	list, err := client.ListTodos(ctx, &todov1.ListTodosRequest{})
+	if err != nil || len(list.Todos) != 1 || list.NextPageToken != "" {
+		t.Errorf("ListTodos() = %v, %v", list, err)
+	}
+
+	if _, err := client.DeleteTodo(ctx, &todov1.DeleteTodoRequest{Id: firstId}); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+	if _, err := client.GetTodo(ctx, &todov1.GetTodoRequest{Id: firstId}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetTodo() after DeleteTodo() error = %v, want %v", err, codes.NotFound)
+	}
+}
+
+func TestTodoServerValidation(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t)
+	if _, err := client.CreateTodo(ctx, &todov1.CreateTodoRequest{Todo: &todov1.Todo{Summary: "todo"}}); err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{
+			name: "create without a todo",
+			call: func() error { _, err := client.CreateTodo(ctx, &todov1.CreateTodoRequest{}); return err },
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "create without a summary",
+			call: func() error {
+				_, err := client.CreateTodo(ctx, &todov1.CreateTodoRequest{Todo: &todov1.Todo{Done: true}})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "priority out of range",
+			call: func() error {
+				_, err := client.CreateTodo(ctx, &todov1.CreateTodoRequest{Todo: &todov1.Todo{Summary: "todo", Priority: 10}})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "unknown update mask path",
+			call: func() error {
+				_, err := client.UpdateTodo(ctx, &todov1.UpdateTodoRequest{Todo: &todov1.Todo{Id: firstId}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"id"}}})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "update clearing the summary",
+			call: func() error {
+				_, err := client.UpdateTodo(ctx, &todov1.UpdateTodoRequest{Todo: &todov1.Todo{Id: firstId}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"summary"}}})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "replace a missing todo",
+			call: func() error {
+				_, err := client.ReplaceTodo(ctx, &todov1.ReplaceTodoRequest{Todo: &todov1.Todo{Id: "missing", Summary: "todo"}})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "negative page size",
+			call: func() error { _, err := client.ListTodos(ctx, &todov1.ListTodosRequest{PageSize: -1}); return err },
+			want: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); status.Code(err) != tt.want {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateTodoRejectsTransitions(t *testing.T) {
+	ctx := context.Background()
+	service := newService(t)
+	client, _ := serveService(t, service)
+	// Blocked todos can't be done in the default workflow
+	if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo", Status: "blocked"}); err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+
+	_, err := client.UpdateTodo(ctx, &todov1.UpdateTodoRequest{Todo: &todov1.Todo{Id: firstId, Done: true}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"done"}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("UpdateTodo() of a blocked todo error = %v, want %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestUpdateTodoRejectsBlockedTodos(t *testing.T) {
+	ctx := context.Background()
+	service := newService(t)
+	client, _ := serveService(t, service)
+	ids := []string{}
+	for _, summary := range []string{"blocked", "blocker"} {
+		created, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: summary})
+		if err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+		ids = append(ids, created.Id)
+	}
+	if err := service.AddDependency(ctx, ids[0], ids[1]); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+
+	_, err := client.UpdateTodo(ctx, &todov1.UpdateTodoRequest{Todo: &todov1.Todo{Id: ids[0], Done: true}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"done"}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("UpdateTodo() of a blocked todo error = %v, want %v", err, codes.InvalidArgument)
+	}
+}
+
+// failingService fails to get todos with an error that must not reach clients
+type failingService struct {
+	todo.TodoService
+}
+
+func (failingService) GetTodo(ctx context.Context, id string) (types.Todo, error) {
+	return types.Todo{}, errors.New("dial tcp 10.0.0.5:5432: connection refused")
+}
+
+func TestActor(t *testing.T) {
+	service := newService(t)
+	client, _ := serveService(t, service,
+		grpc.ChainUnaryInterceptor(UnaryActor("X-Forwarded-User")),
+		grpc.ChainStreamInterceptor(StreamActor("X-Forwarded-User")),
+	)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-forwarded-user", "alice")
+	if _, err := client.CreateTodo(ctx, &todov1.CreateTodoRequest{Todo: &todov1.Todo{Summary: "Pick up the groceries"}}); err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+	if _, err := client.DeleteTodo(context.Background(), &todov1.DeleteTodoRequest{Id: firstId}); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+
+	revisions, _, err := service.TodoHistory(context.Background(), firstId, 10, "")
+	if err != nil || len(revisions) != 2 {
+		t.Fatalf("TodoHistory() = %+v, %v, want 2 revisions", revisions, err)
+	}
+	if revisions[0].Actor != "alice" || revisions[1].Actor != actor.Anonymous {
+		t.Errorf("revisions made by %q and %q, want alice and %q", revisions[0].Actor, revisions[1].Actor, actor.Anonymous)
+	}
+}
+
+func TestListTodosPageSize(t *testing.T) {
+	ctx := context.Background()
+	service := newService(t)
+	for i := 0; i < 3; i++ {
+		if _, err := service.CreateTodo(ctx, types.TodoUpdate{Summary: "todo"}); err != nil {
+			t.Fatalf("CreateTodo() error = %v", err)
+		}
+	}
+
+	// Page sizes above the max are lowered to it
+	list, err := NewTodoServer(service, 2).ListTodos(ctx, &todov1.ListTodosRequest{PageSize: 1000})
+	if err != nil || len(list.Todos) != 2 || list.NextPageToken == "" {
+		t.Errorf("ListTodos() = %v, %v, want a page of 2 todos", list, err)
+	}
+}
+
+func TestInternalErrors(t *testing.T) {
+	client, _ := serveService(t, failingService{newService(t)})
+
+	_, err := client.GetTodo(context.Background(), &todov1.GetTodoRequest{Id: firstId})
+	if s := status.Convert(err); s.Code() != codes.Internal || s.Message() != "internal server error" {
+		t.Errorf("GetTodo() error = %v, want a generic %v error", err, codes.Internal)
+	}
+}
+
+func TestWatchTodos(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, _ := newClient(t)
+
+	stream, err := client.WatchTodos(ctx, &todov1.WatchTodosRequest{})
+	if err != nil {
+		t.Fatalf("WatchTodos() error = %v", err)
+	}
+	// The watch starts once the server received the call, wait for it before making changes
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Header() error = %v", err)
+	}
+
+	changes := []todov1.TodoEvent_Type{todov1.TodoEvent_TYPE_CREATED, todov1.TodoEvent_TYPE_UPDATED, todov1.TodoEvent_TYPE_DELETED}
+	go func() {
+		client.CreateTodo(ctx, &todov1.CreateTodoRequest{Todo: &todov1.Todo{Summary: "todo"}})
+		client.UpdateTodo(ctx, &todov1.UpdateTodoRequest{Todo: &todov1.Todo{Id: firstId, Done: true}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"done"}}})
+		client.DeleteTodo(ctx, &todov1.DeleteTodoRequest{Id: firstId})
+	}()
+
+	for _, want := range changes {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if event.Type != want || event.Todo.Id != firstId {
+			t.Errorf("Recv() = %v, want a %v event for %s", event, want, firstId)
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	_, conn := newClient(t)
+	res, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: todov1.TodoService_ServiceDesc.ServiceName})
+	if err != nil || res.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Check() = %v, %v, want SERVING", res, err)
+	}
+}
@@ -0,0 +1,133 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25 parameters, the usual defaults
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Match is a document matching a search, a higher Score is a better match
+type Match struct {
+	Id    string
+	Score float64
+}
+
+// Index is an in-process inverted index of documents identified by an id. It's safe for concurrent
+// use.
+type Index struct {
+	mu sync.RWMutex
+	// postings maps every term to the number of times it appears in each document
+	postings map[string]map[string]int
+	// documents holds the terms of every document
+	documents   map[string][]string
+	totalLength int
+}
+
+func NewIndex() *Index {
+	return &Index{postings: map[string]map[string]int{}, documents: map[string][]string{}}
+}
+
+// Add indexes text as the document id, replacing the previous text of the document
+func (i *Index) Add(id string, text string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(id)
+
+	terms := Terms(text)
+	i.documents[id] = terms
+	i.totalLength += len(terms)
+	for _, term := range terms {
+		if i.postings[term] == nil {
+			i.postings[term] = map[string]int{}
+		}
+		i.postings[term][id]++
+	}
+}
+
+// Remove removes the document id from the index
+func (i *Index) Remove(id string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(id)
+}
+
+func (i *Index) remove(id string) {
+	terms, ok := i.documents[id]
+	if !ok {
+		return
+	}
+	for _, term := range terms {
+		delete(i.postings[term], id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+	i.totalLength -= len(terms)
+	delete(i.documents, id)
+}
+
+// Search returns up to limit documents containing every term of query, the best matches (ranked
+// with BM25) first and documents matching equally well by id
+func (i *Index) Search(query string, limit int) []Match {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	terms := unique(Terms(query))
+	if len(terms) == 0 || len(i.documents) == 0 {
+		return []Match{}
+	}
+
+	averageLength := float64(i.totalLength) / float64(len(i.documents))
+	scores := map[string]float64{}
+	for n, term := range terms {
+		postings := i.postings[term]
+		idf := math.Log(1 + (float64(len(i.documents))-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+		for id, frequency := range postings {
+			if _, ok := scores[id]; !ok && n > 0 {
+				// The document is missing one of the previous terms
+				continue
+			}
+			length := float64(len(i.documents[id]))
+			tf := float64(frequency)
+			scores[id] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*length/averageLength))
+		}
+		for id := range scores {
+			if _, ok := postings[id]; !ok {
+				delete(scores, id)
+			}
+		}
+	}
+
+	matches := make([]Match, 0, len(scores))
+	for id, score := range scores {
+		matches = append(matches, Match{Id: id, Score: score})
+	}
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].Id < matches[b].Id
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func unique(terms []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+// Package search holds the in-process full-text index used by storage without full-text search
+// and the text handling shared with the native full-text search of the SQL providers.
+//
+// Text is split into terms on anything that isn't a letter or a digit and terms are compared case
+// insensitively, there is no stemming or stop word removal.
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// HighlightStart and HighlightEnd surround the matched terms of a highlighted text
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+	// MatchStart and MatchEnd surround the matched terms in the highlights of the SQL providers,
+	// they are control characters that HTML escaping leaves alone
+	MatchStart = "\x02"
+	MatchEnd   = "\x03"
+)
+
+// Terms returns the lower case terms of text in the order they appear
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), separator)
+}
+
+// Highlight returns text HTML escaped with the words matching one of terms surrounded by
+// HighlightStart and HighlightEnd, so the only markup in it is the highlighting.
+func Highlight(text string, terms []string) string {
+	matches := map[string]bool{}
+	for _, term := range terms {
+		matches[strings.ToLower(term)] = true
+	}
+
+	highlighted := strings.Builder{}
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		if matches[strings.ToLower(word)] {
+			word = HighlightStart + html.EscapeString(word) + HighlightEnd
+		} else {
+			word = html.EscapeString(word)
+		}
+		highlighted.WriteString(word)
+		start = -1
+	}
+	for i, r := range text {
+		switch {
+		case !separator(r) && start < 0:
+			start = i
+		case separator(r) && start >= 0:
+			flush(i)
+		}
+		if separator(r) {
+			highlighted.WriteString(html.EscapeString(string(r)))
+		}
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+	return highlighted.String()
+}
+
+// HighlightMatches returns text highlighted like Highlight from marked, the text with its matched
+// terms surrounded by MatchStart and MatchEnd by a SQL provider. ok is false when text holds
+// MatchStart or MatchEnd itself, the matches can't be told apart from the text then.
+func HighlightMatches(marked string, text string) (highlighted string, ok bool) {
+	if strings.Contains(text, MatchStart) || strings.Contains(text, MatchEnd) {
+		return "", false
+	}
+	return strings.NewReplacer(MatchStart, HighlightStart, MatchEnd, HighlightEnd).Replace(html.EscapeString(marked)), true
+}
+
+func separator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	got := Terms("Pick up the GROCERIES, then call Zoë (x2)")
+	want := []string{"pick", "up", "the", "groceries", "then", "call", "zoë", "x2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Terms() = %q, want %q", got, want)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text  string
+		terms []string
+		want  string
+	}{
+		{text: "Pick up the groceries", terms: []string{"groceries"}, want: "Pick up the <mark>groceries</mark>"},
+		{text: "Call Mom, call Dad", terms: []string{"call"}, want: "<mark>Call</mark> Mom, <mark>call</mark> Dad"},
+		{text: "Recall the callback", terms: []string{"call"}, want: "Recall the callback"},
+		{text: "Café au lait", terms: []string{"café", "lait"}, want: "<mark>Café</mark> au <mark>lait</mark>"},
+		{text: "", terms: []string{"call"}, want: ""},
+		{text: "Fix <mark>call</mark> & <script>", terms: []string{"call", "script"}, want: "Fix &lt;mark&gt;<mark>call</mark>&lt;/mark&gt; &amp; &lt;<mark>script</mark>&gt;"},
+	}
+	for _, tt := range tests {
+		if got := Highlight(tt.text, tt.terms); got != tt.want {
+			t.Errorf("Highlight(%q, %q) = %q, want %q", tt.text, tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	text := "Fix <b> & call"
+	got, ok := HighlightMatches("Fix <b> & "+MatchStart+"call"+MatchEnd, text)
+	if want := "Fix &lt;b&gt; &amp; <mark>call</mark>"; !ok || got != want {
+		t.Errorf("HighlightMatches() = %q, %v, want %q", got, ok, want)
+	}
+	if _, ok := HighlightMatches(MatchStart+"call"+MatchEnd, "call"+MatchStart); ok {
+		t.Errorf("HighlightMatches() of a text holding MatchStart is ok, want it refused")
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	index := NewIndex()
+	index.Add("1", "Pick up the groceries")
+	index.Add("2", "Groceries groceries groceries")
+	index.Add("3", "Pay the rent")
+	index.Add("4", "Buy milk at the groceries store on the way home from work")
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{query: "groceries", limit: 10, want: []string{"2", "1", "4"}},
+		{query: "groceries", limit: 2, want: []string{"2", "1"}},
+		{query: "the groceries", limit: 10, want: []string{"1", "4"}},
+		{query: "RENT", limit: 10, want: []string{"3"}},
+		{query: "rent groceries", limit: 10, want: []string{}},
+		{query: "taxes", limit: 10, want: []string{}},
+		{query: "!!!", limit: 10, want: []string{}},
+	}
+	for _, tt := range tests {
+		ids := []string{}
+		for _, match := range index.Search(tt.query, tt.limit) {
+			ids = append(ids, match.Id)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("Search(%q, %d) = %v, want %v", tt.query, tt.limit, ids, tt.want)
+		}
+	}
+}
+
+func TestIndexAddReplacesAndRemoves(t *testing.T) {
+	index := NewIndex()
+	index.Add("1", "Pick up the groceries")
+	index.Add("1", "Pay the rent")
+	if matches := index.Search("groceries", 10); len(matches) != 0 {
+		t.Errorf("Search() after replacing the text = %v, want no matches", matches)
+	}
+	if matches := index.Search("rent", 10); len(matches) != 1 {
+		t.Errorf("Search() = %v, want the replaced text to match", matches)
+	}
+
+	index.Remove("1")
+	index.Remove("missing")
+	if matches := index.Search("rent", 10); len(matches) != 0 {
+		t.Errorf("Search() after Remove() = %v, want no matches", matches)
+	}
+}
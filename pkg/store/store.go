@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+	"gorm.io/gorm"
+)
+
+// countPageSize is the number of items read at a time when counting items one page at a time
+const countPageSize = 100
+
+// Store wraps a magic StorageAdapter and makes its operations context aware.
+//
+// The magic storage adapters don't accept a context, so for the adapters that are backed by gorm
+// (sql and memory) the operation runs on a database session bound to the given context which lets
+// the database driver abort in-flight queries once the context is cancelled. Other adapters are
+// guarded by checking the context before and after the operation.
+//
+// Filters are keyed by the JSON names of fields, which the non-SQL adapters match items on. Columns
+// that are filtered on are named like the JSON field so the same filter works with every adapter,
+// e.g. `json:"todoId" gorm:"column:todoid"`.
+type Store struct {
+	adapter storage.StorageAdapter
+}
+
+func New(adapter storage.StorageAdapter) *Store {
+	return &Store{adapter: adapter}
+}
+
+// Adapter returns the underlying storage adapter
+func (s *Store) Adapter() storage.StorageAdapter {
+	return s.adapter
+}
+
+func (s *Store) Create(ctx context.Context, item any) error {
+	return s.run(ctx, func(adapter storage.StorageAdapter) error {
+		return adapter.Create(item)
+	})
+}
+
+func (s *Store) Get(ctx context.Context, dest any, filter map[string]any) error {
+	return s.run(ctx, func(adapter storage.StorageAdapter) error {
+		return adapter.Get(dest, filter)
+	})
+}
+
+func (s *Store) Update(ctx context.Context, item any, filter map[string]any) error {
+	return s.run(ctx, func(adapter storage.StorageAdapter) error {
+		return adapter.Update(item, filter)
+	})
+}
+
+func (s *Store) Delete(ctx context.Context, item any, filter map[string]any) error {
+	return s.run(ctx, func(adapter storage.StorageAdapter) error {
+		return adapter.Delete(item, filter)
+	})
+}
+
+func (s *Store) List(ctx context.Context, dest any, sortKey string, filter map[string]any, limit int, cursor string) (string, error) {
+	if _, isGorm := GormDB(s.adapter); isGorm {
+		if _, err := DecodeCursor(cursor); err != nil {
+			return "", err
+		}
+	}
+	next := ""
+	err := s.run(ctx, func(adapter storage.StorageAdapter) error {
+		var err error
+		next, err = adapter.List(dest, sortKey, filter, limit, cursor)
+		return err
+	})
+	return next, err
+}
+
+// GetMany reads the items whose key is one of values into dest, which must point to a slice. Items
+// that don't exist are left out. Gorm backed adapters read every item with a single query, other
+// adapters read them one at a time.
+func (s *Store) GetMany(ctx context.Context, dest any, key string, values []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if db, ok := GormDB(s.adapter); ok {
+		err := db.WithContext(ctx).Where(key+" IN ?", values).Find(dest).Error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+
+	items := reflect.ValueOf(dest).Elem()
+	for _, value := range values {
+		item := reflect.New(items.Type().Elem())
+		err := s.Get(ctx, item.Interface(), map[string]any{key: value})
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		items.Set(reflect.Append(items, item.Elem()))
+	}
+	return nil
+}
+
+// Count returns the number of items matching filter, dest must point to a slice of the items type.
+// Gorm backed adapters count with a single query, other adapters read every item.
+func (s *Store) Count(ctx context.Context, dest any, filter map[string]any) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if db, ok := GormDB(s.adapter); ok {
+		count := int64(0)
+		err := db.WithContext(ctx).Model(dest).Where(filter).Count(&count).Error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, ctxErr
+		}
+		return int(count), err
+	}
+
+	count := 0
+	cursor := ""
+	for {
+		page := reflect.New(reflect.TypeOf(dest).Elem())
+		next, err := s.List(ctx, page.Interface(), "Id", filter, countPageSize, cursor)
+		if err != nil {
+			return 0, err
+		}
+		count += page.Elem().Len()
+		if next == "" {
+			return count, nil
+		}
+		cursor = next
+	}
+}
+
+// Previous returns the cursor of the page of limit items listed right before the page starting at
+// cursor, ok is false when there are no items before it. The cursor of the first page is empty.
+// dest must point to a slice of the items type. Gorm backed adapters find the page with a single
+// query, other adapters can only list forward and finding the page would mean listing every item
+// before cursor, so ok is always false on them.
+func (s *Store) Previous(ctx context.Context, dest any, sortKey string, limit int, cursor string) (prev string, ok bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+	if cursor == "" {
+		return "", false, nil
+	}
+
+	if db, isGorm := GormDB(s.adapter); isGorm {
+		value, err := DecodeCursor(cursor)
+		if err != nil {
+			return "", false, err
+		}
+		// The items before the cursor nearest first, along with one more to tell whether the
+		// previous page is the first one
+		err = db.WithContext(ctx).Where(sortKey+" < ?", value).Order(sortKey + " desc").Limit(limit + 1).Find(dest).Error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", false, ctxErr
+		}
+		if err != nil {
+			return "", false, err
+		}
+		items := reflect.ValueOf(dest).Elem()
+		if items.Len() <= limit {
+			return "", items.Len() > 0, nil
+		}
+		return base64.StdEncoding.EncodeToString([]byte(items.Index(limit - 1).FieldByName(sortKey).String())), true, nil
+	}
+
+	return "", false, nil
+}
+
+// DecodeCursor returns the sort key a cursor of the gorm backed adapters starts at, cursors that
+// weren't issued by them are a BadRequest
+func DecodeCursor(cursor string) (string, error) {
+	value, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", &serviceErrors.BadRequest{Message: "invalid cursor"}
+	}
+	return string(value), nil
+}
+
+func (s *Store) run(ctx context.Context, operation func(adapter storage.StorageAdapter) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	adapter := s.adapter
+	if db, ok := GormDB(s.adapter); ok {
+		// The CRUD operations of the SQL adapter only rely on its gorm session so a shallow adapter
+		// bound to the request context behaves exactly like the original one
+		adapter = &storage.SQLAdapter{DB: db.WithContext(ctx)}
+	}
+
+	err := operation(adapter)
+
+	// A cancelled query surfaces as a driver specific error (or as ErrNotFound when nothing was read),
+	// report the context error instead so callers can tell timeouts and disconnects apart
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// GormDB returns the gorm database session used by adapters that are backed by a SQL database
+func GormDB(adapter storage.StorageAdapter) (*gorm.DB, bool) {
+	switch a := adapter.(type) {
+	case *storage.SQLAdapter:
+		return a.DB, true
+	case *storage.MemoryAdapter:
+		return a.DB.DB, true
+	default:
+		return nil, false
+	}
+}
+
+// Provider returns the SQL provider of adapters that are backed by a SQL database
+func Provider(adapter storage.StorageAdapter) storage.StorageProviders {
+	provider := adapter.GetProvider()
+	db, ok := GormDB(adapter)
+	if provider == "" && ok {
+		// Adapters created outside of the magic factory don't know their provider, gorm does
+		switch db.Dialector.Name() {
+		case "postgres":
+			provider = storage.POSTGRESQL
+		default:
+			provider = storage.StorageProviders(db.Dialector.Name())
+		}
+	}
+	return provider
+}
@@ -0,0 +1,122 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	serviceErrors "github.com/tink3rlabs/magic/errors"
+	"github.com/tink3rlabs/magic/storage"
+
+	"todo-service/pkg/fakes"
+	"todo-service/pkg/store"
+	"todo-service/pkg/store/storetest"
+	"todo-service/pkg/types"
+)
+
+func TestAdapterContract(t *testing.T) {
+	t.Run("memory", func(t *testing.T) { storetest.Run(t, storetest.Memory) })
+	t.Run("sqlite", func(t *testing.T) { storetest.Run(t, storetest.SQLite) })
+}
+
+func TestStoreHonorsContext(t *testing.T) {
+	adapters := map[string]storetest.AdapterFactory{
+		"sqlite": storetest.SQLite,
+		"fake":   func(t *testing.T) storage.StorageAdapter { return fakes.NewStorage() },
+	}
+
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			s := store.New(newAdapter(t))
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			tests := map[string]func() error{
+				"create": func() error { return s.Create(ctx, types.Todo{Id: "1"}) },
+				"get":    func() error { return s.Get(ctx, &types.Todo{}, map[string]any{"id": "1"}) },
+				"update": func() error { return s.Update(ctx, types.Todo{Id: "1"}, map[string]any{"id": "1"}) },
+				"delete": func() error { return s.Delete(ctx, &types.Todo{}, map[string]any{"id": "1"}) },
+				"list": func() error {
+					_, err := s.List(ctx, &[]types.Todo{}, "Id", map[string]any{}, 10, "")
+					return err
+				},
+				"get many": func() error { return s.GetMany(ctx, &[]types.Todo{}, "id", []string{"1"}) },
+				"count": func() error {
+					_, err := s.Count(ctx, &[]types.Todo{}, map[string]any{})
+					return err
+				},
+				"previous": func() error {
+					_, _, err := s.Previous(ctx, &[]types.Todo{}, "Id", 10, "MQ==")
+					return err
+				},
+			}
+			for operation, run := range tests {
+				if err := run(); !errors.Is(err, context.Canceled) {
+					t.Errorf("%s with a cancelled context error = %v, want %v", operation, err, context.Canceled)
+				}
+			}
+		})
+	}
+}
+
+func TestStorePassesThroughResults(t *testing.T) {
+	s := store.New(storetest.SQLite(t))
+	ctx := context.Background()
+
+	if err := s.Create(ctx, types.Todo{Id: "1", Summary: "stored"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	got := types.Todo{}
+	if err := s.Get(ctx, &got, map[string]any{"id": "1"}); err != nil || got.Summary != "stored" {
+		t.Errorf("Get() = %+v, %v, want the stored todo", got, err)
+	}
+	if err := s.Get(ctx, &got, map[string]any{"id": "2"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Get() of a missing todo error = %v, want %v", err, storage.ErrNotFound)
+	}
+}
+
+func TestStoreGetMany(t *testing.T) {
+	adapters := map[string]storetest.AdapterFactory{
+		"sqlite": storetest.SQLite,
+		"fake":   func(t *testing.T) storage.StorageAdapter { return fakes.NewStorage() },
+	}
+
+	for name, newAdapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			s := store.New(newAdapter(t))
+			ctx := context.Background()
+			for _, id := range []string{"1", "2", "3"} {
+				if err := s.Create(ctx, types.Todo{Id: id, Summary: "todo " + id}); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+
+			got := []types.Todo{}
+			if err := s.GetMany(ctx, &got, "id", []string{"3", "1", "missing"}); err != nil {
+				t.Fatalf("GetMany() error = %v", err)
+			}
+			ids := map[string]bool{}
+			for _, todo := range got {
+				ids[todo.Id] = true
+			}
+			if len(got) != 2 || !ids["1"] || !ids["3"] {
+				t.Errorf("GetMany() = %+v, want todos 1 and 3", got)
+			}
+		})
+	}
+}
+
+func TestStoreRejectsInvalidCursors(t *testing.T) {
+	for name, newAdapter := range map[string]storetest.AdapterFactory{"memory": storetest.Memory, "sqlite": storetest.SQLite} {
+		t.Run(name, func(t *testing.T) {
+			s := store.New(newAdapter(t))
+			var badRequest *serviceErrors.BadRequest
+			if _, err := s.List(context.Background(), &[]types.Todo{}, "Id", map[string]any{}, 10, "not a cursor!"); !errors.As(err, &badRequest) {
+				t.Errorf("List() with an invalid cursor error = %v, want a BadRequest", err)
+			}
+			if _, _, err := s.Previous(context.Background(), &[]types.Todo{}, "Id", 10, "not a cursor!"); !errors.As(err, &badRequest) {
+				t.Errorf("Previous() with an invalid cursor error = %v, want a BadRequest", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+// Package storetest provides a contract test suite for storage adapters and helpers that create
+// migrated adapters for tests.
+//
+// Every storage backend the service supports must pass Run, which captures the behavior the
+// features rely on (not found errors, upserts, cursor pagination, filtering):
+//
+//	func TestMyAdapter(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) storage.StorageAdapter {
+//			return newMigratedEmptyAdapter(t)
+//		})
+//	}
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/tink3rlabs/magic/storage"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"todo-service/pkg/migrations"
+	"todo-service/pkg/types"
+)
+
+// AdapterFactory returns a storage adapter with the service schema applied and no todos stored
+type AdapterFactory func(t *testing.T) storage.StorageAdapter
+
+// Run runs the storage contract against the adapters returned by newAdapter, each sub test gets
+// a fresh adapter
+func Run(t *testing.T, newAdapter AdapterFactory) {
+	t.Run("create and get", func(t *testing.T) {
+		s := newAdapter(t)
+		want := types.Todo{Id: id(1), Summary: "Pick up the groceries", Done: true}
+		if err := s.Create(want); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		got := types.Todo{}
+		if err := s.Get(&got, map[string]any{"id": want.Id}); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("Get() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("get missing item returns ErrNotFound", func(t *testing.T) {
+		s := newAdapter(t)
+		err := s.Get(&types.Todo{}, map[string]any{"id": id(1)})
+		if !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Get() error = %v, want %v", err, storage.ErrNotFound)
+		}
+	})
+
+	t.Run("get requires a filter", func(t *testing.T) {
+		s := newAdapter(t)
+		if err := s.Get(&types.Todo{}, map[string]any{}); err == nil {
+			t.Error("Get() without a filter succeeded, want an error")
+		}
+	})
+
+	t.Run("update replaces the item", func(t *testing.T) {
+		s := newAdapter(t)
+		if err := s.Create(types.Todo{Id: id(1), Summary: "before"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		want := types.Todo{Id: id(1), Summary: "after", Done: true}
+		if err := s.Update(want, map[string]any{"id": want.Id}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+
+		got := types.Todo{}
+		if err := s.Get(&got, map[string]any{"id": want.Id}); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("Get() after Update() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("delete removes the item", func(t *testing.T) {
+		s := newAdapter(t)
+		if err := s.Create(types.Todo{Id: id(1), Summary: "delete me"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := s.Delete(&types.Todo{}, map[string]any{"id": id(1)}); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		err := s.Get(&types.Todo{}, map[string]any{"id": id(1)})
+		if !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Get() after Delete() error = %v, want %v", err, storage.ErrNotFound)
+		}
+	})
+
+	t.Run("delete missing item succeeds", func(t *testing.T) {
+		s := newAdapter(t)
+		if err := s.Delete(&types.Todo{}, map[string]any{"id": id(1)}); err != nil {
+			t.Errorf("Delete() error = %v", err)
+		}
+	})
+
+	t.Run("list pages through items with a cursor", func(t *testing.T) {
+		s := newAdapter(t)
+		for i := 1; i <= 5; i++ {
+			if err := s.Create(types.Todo{Id: id(i), Summary: fmt.Sprintf("todo %d", i)}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+		}
+
+		wantPages := [][]string{{id(1), id(2)}, {id(3), id(4)}, {id(5)}}
+		cursor := ""
+		for i, want := range wantPages {
+			todos := []types.Todo{}
+			next, err := s.List(&todos, "Id", map[string]any{}, 2, cursor)
+			if err != nil {
+				t.Fatalf("List() page %d error = %v", i, err)
+			}
+			if got := todoIds(todos); !equal(got, want) {
+				t.Errorf("List() page %d = %v, want %v", i, got, want)
+			}
+			if last := i == len(wantPages)-1; last != (next == "") {
+				t.Errorf("List() page %d next = %q, last page = %v", i, next, last)
+			}
+			cursor = next
+		}
+	})
+
+	t.Run("list filters items", func(t *testing.T) {
+		s := newAdapter(t)
+		for i := 1; i <= 4; i++ {
+			if err := s.Create(types.Todo{Id: id(i), Summary: "todo", Done: i%2 == 0}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+		}
+
+		todos := []types.Todo{}
+		_, err := s.List(&todos, "Id", map[string]any{"done": true}, 10, "")
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if got, want := todoIds(todos), []string{id(2), id(4)}; !equal(got, want) {
+			t.Errorf("List() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("list of an empty table", func(t *testing.T) {
+		s := newAdapter(t)
+		todos := []types.Todo{}
+		next, err := s.List(&todos, "Id", map[string]any{}, 10, "")
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(todos) != 0 || next != "" {
+			t.Errorf("List() = %v, %q, want no todos and no cursor", todos, next)
+		}
+	})
+}
+
+// SQLite returns an adapter backed by a sqlite database file in a temporary directory with the
+// sqlite migrations applied
+func SQLite(t *testing.T) storage.StorageAdapter {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "todo.sqlite")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	adapter := &storage.SQLAdapter{DB: db}
+	Migrate(t, adapter)
+	return adapter
+}
+
+// Memory returns the (process wide) magic memory adapter with the sqlite migrations applied and
+// all todos and their revisions removed
+func Memory(t *testing.T) storage.StorageAdapter {
+	t.Helper()
+	adapter := storage.GetMemoryAdapterInstance()
+	Migrate(t, adapter)
+	for _, table := range tables {
+		if err := adapter.Execute("DELETE FROM " + table); err != nil {
+			t.Fatalf("failed to clear %s: %v", table, err)
+		}
+	}
+	return adapter
+}
+
+// tables are the tables created by the migrations, which Memory clears
+var tables = []string{"todos", "todo_revisions", "comments", "attachments", "dependencies", "time_entries"}
+
+// Migrate applies the migrations found under config/migrations to adapter
+func Migrate(t *testing.T, adapter storage.StorageAdapter) {
+	t.Helper()
+	migrator, err := migrations.NewMigrator(adapter, os.DirFS(moduleRoot()))
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+}
+
+func moduleRoot() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..")
+}
+
+func id(n int) string {
+	return fmt.Sprintf("00000000-0000-7000-8000-%012d", n)
+}
+
+func todoIds(todos []types.Todo) []string {
+	ids := []string{}
+	for _, todo := range todos {
+		ids = append(ids, todo.Id)
+	}
+	return ids
+}
+
+func equal(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Assignees are the users a todo is assigned to. SQL databases store them as a JSON array, which
+// todos can be filtered on with LIKE '%"<user>"%'.
+type Assignees []string
+
+// GormDataType tells gorm Assignees are stored in a single column rather than as an association
+func (Assignees) GormDataType() string {
+	return "text"
+}
+
+func (a Assignees) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal([]string(a))
+	return string(data), err
+}
+
+func (a *Assignees) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), (*[]string)(a))
+	case []byte:
+		return json.Unmarshal(v, (*[]string)(a))
+	default:
+		return fmt.Errorf("can't read assignees from %T", value)
+	}
+}
+
+// Has reports whether user is one of the assignees
+func (a Assignees) Has(user string) bool {
+	return slices.Contains(a, user)
+}
@@ -0,0 +1,76 @@
+package types
+
+import "time"
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  Attachment:
+//	    type: object
+//	    properties:
+//	      id:
+//	        type: string
+//	        description: The Attachment's identifier
+//	        example: 01909a8e-6706-75f5-bc25-5c4264f50e49
+//	      todoId:
+//	        type: string
+//	        description: The identifier of the Todo the file is attached to
+//	        example: 01909a8e-6706-75f5-bc25-5c4264f50e41
+//	      name:
+//	        type: string
+//	        description: The name of the uploaded file
+//	        example: receipt.pdf
+//	      contentType:
+//	        type: string
+//	        description: The media type of the file
+//	        example: application/pdf
+//	      size:
+//	        type: integer
+//	        format: int64
+//	        description: The size of the file in bytes
+//	        example: 48213
+//	      checksum:
+//	        type: string
+//	        description: The hex encoded SHA-256 digest of the file
+//	        example: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//	      author:
+//	        type: string
+//	        description: The user who uploaded the file, anonymous when unknown
+//	        example: alice
+//	      createdAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the file was uploaded
+//	        example: 2024-07-01T12:00:00Z
+type Attachment struct {
+	Id          string    `json:"id"`
+	TodoId      string    `json:"todoId" gorm:"column:todoid"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	Author      string    `json:"author"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime:false"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  AttachmentList:
+//	    type: object
+//	    properties:
+//	      attachments:
+//	        type: array
+//	        description: The Attachments of the Todo, the oldest first
+//	        items:
+//	          $ref: '#/components/schemas/Attachment'
+//	      next:
+//	        type: string
+//	        description: An identifier to use when requesting the next set of attachments
+//	        example: MDE5MDlhOGUtNjcwNi03NWY1LWJjMjUtNWM0MjY0ZjUwZTQ5
+type AttachmentList struct {
+	Attachments []Attachment `json:"attachments"`
+	Next        string       `json:"next"`
+}
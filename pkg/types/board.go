@@ -0,0 +1,53 @@
+package types
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  BoardColumn:
+//	    type: object
+//	    properties:
+//	      status:
+//	        type: string
+//	        description: The status of the Todos of the column
+//	        example: in_progress
+//	      done:
+//	        type: boolean
+//	        description: Whether the Todos of the column are done
+//	        example: false
+//	      todos:
+//	        type: array
+//	        description: The first Todos with the status
+//	        items:
+//	          $ref: '#/components/schemas/Todo'
+//	      next:
+//	        type: string
+//	        description: An identifier to use when requesting the next set of todos with GET /todos?status={status}
+//	        example: MDE5MDlhOGUtNjcwNi03NWY1LWJjMjUtNWM0MjY0ZjUwZTQ1
+//	      totalCount:
+//	        type: integer
+//	        description: The number of Todos with the status, only sent when requested with count=true
+//	        example: 12
+type BoardColumn struct {
+	Status     string `json:"status"`
+	Done       bool   `json:"done"`
+	Todos      []Todo `json:"todos"`
+	Next       string `json:"next"`
+	TotalCount *int   `json:"totalCount,omitempty"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TodoBoard:
+//	    type: object
+//	    properties:
+//	      columns:
+//	        type: array
+//	        description: A column for every status of the workflow, in the order the workflow lists them
+//	        items:
+//	          $ref: '#/components/schemas/BoardColumn'
+type TodoBoard struct {
+	Columns []BoardColumn `json:"columns"`
+}
@@ -0,0 +1,86 @@
+package types
+
+import "time"
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  Comment:
+//	    type: object
+//	    properties:
+//	      id:
+//	        type: string
+//	        description: The Comment's identifier
+//	        example: 01909a8e-6706-75f5-bc25-5c4264f50e47
+//	      todoId:
+//	        type: string
+//	        description: The identifier of the commented Todo
+//	        example: 01909a8e-6706-75f5-bc25-5c4264f50e41
+//	      author:
+//	        type: string
+//	        description: The user who wrote the Comment, anonymous when unknown
+//	        example: alice
+//	      body:
+//	        type: string
+//	        description: The Comment in Markdown (CommonMark)
+//	        example: Check the **opening hours** first
+//	      createdAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the Comment was written
+//	        example: 2024-07-01T12:00:00Z
+//	      updatedAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the Comment was last edited
+//	        example: 2024-07-01T12:00:00Z
+type Comment struct {
+	Id        string    `json:"id"`
+	TodoId    string    `json:"todoId" gorm:"column:todoid"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime:false"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime:false"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  CommentUpdate:
+//	    type: object
+//	    required:
+//	      - body
+//	    additionalProperties: false
+//	    properties:
+//	      body:
+//	        type: string
+//	        minLength: 1
+//	        maxLength: 10000
+//	        description: The Comment in Markdown (CommonMark)
+//	        example: Check the **opening hours** first
+type CommentUpdate struct {
+	Body string `json:"body"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  CommentList:
+//	    type: object
+//	    properties:
+//	      comments:
+//	        type: array
+//	        description: The Comments of the Todo, the oldest first
+//	        items:
+//	          $ref: '#/components/schemas/Comment'
+//	      next:
+//	        type: string
+//	        description: An identifier to use when requesting the next set of comments
+//	        example: MDE5MDlhOGUtNjcwNi03NWY1LWJjMjUtNWM0MjY0ZjUwZTQ3
+type CommentList struct {
+	Comments []Comment `json:"comments"`
+	Next     string    `json:"next"`
+}
@@ -0,0 +1,41 @@
+package types
+
+import "time"
+
+// Dependency records that the todo TodoId can't be done before the todo BlockerId is
+type Dependency struct {
+	// Id is made of the two todo ids, so a dependency is only stored once
+	Id        string    `json:"id"`
+	TodoId    string    `json:"todoId" gorm:"column:todoid"`
+	BlockerId string    `json:"blockerId" gorm:"column:blockerid"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime:false"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TodoDependencies:
+//	    type: object
+//	    properties:
+//	      blockedBy:
+//	        type: array
+//	        description: The identifiers of the Todos that must be done before the Todo
+//	        items:
+//	          type: string
+//	        example: [01909a8e-6706-75f5-bc25-5c4264f50e41]
+//	      blocks:
+//	        type: array
+//	        description: The identifiers of the Todos that wait for the Todo to be done
+//	        items:
+//	          type: string
+//	        example: [01909a8e-6706-75f5-bc25-5c4264f50e45]
+//	      ready:
+//	        type: boolean
+//	        description: Whether the Todo is open and every Todo it's blocked by is done
+//	        example: false
+type TodoDependencies struct {
+	BlockedBy []string `json:"blockedBy"`
+	Blocks    []string `json:"blocks"`
+	Ready     bool     `json:"ready"`
+}
@@ -0,0 +1,108 @@
+package types
+
+import "time"
+
+// RevisionAction is the kind of change a TodoRevision records
+type RevisionAction string
+
+const (
+	RevisionCreated  RevisionAction = "created"
+	RevisionUpdated  RevisionAction = "updated"
+	RevisionDeleted  RevisionAction = "deleted"
+	RevisionReverted RevisionAction = "reverted"
+)
+
+// JSON is a JSON document stored as text, it's represented by the document itself in JSON and by
+// null when empty
+type JSON string
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
+
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*j = ""
+		return nil
+	}
+	*j = JSON(data)
+	return nil
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TodoRevision:
+//	    type: object
+//	    properties:
+//	      id:
+//	        type: string
+//	        description: The identifier of the revision
+//	        example: 01909a8e-6706-75f5-bc25-5c4264f50e45
+//	      todoId:
+//	        type: string
+//	        description: The identifier of the changed Todo
+//	        example: 01909a8e-6706-75f5-bc25-5c4264f50e41
+//	      action:
+//	        type: string
+//	        enum: [created, updated, deleted, reverted]
+//	        description: The kind of change
+//	        example: updated
+//	      actor:
+//	        type: string
+//	        description: The user who made the change, anonymous when unknown
+//	        example: alice
+//	      createdAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time of the change
+//	        example: 2024-07-01T12:00:00Z
+//	      changes:
+//	        description: A JSON Merge Patch (RFC 7396) turning the Todo before the change into the Todo after it, null for deletions
+//	        nullable: true
+//	        example: {"summary": "Pick up the groceries and the mail", "updatedAt": "2024-07-01T12:00:00Z"}
+//	      before:
+//	        description: The Todo before the change, null for creations
+//	        nullable: true
+//	        allOf:
+//	          - $ref: '#/components/schemas/Todo'
+//	      after:
+//	        description: The Todo after the change, null for deletions
+//	        nullable: true
+//	        allOf:
+//	          - $ref: '#/components/schemas/Todo'
+type TodoRevision struct {
+	Id        string         `json:"id"`
+	TodoId    string         `json:"todoId" gorm:"column:todoid"`
+	Action    RevisionAction `json:"action"`
+	Actor     string         `json:"actor"`
+	CreatedAt time.Time      `json:"createdAt" gorm:"autoCreateTime:false"`
+	Changes   JSON           `json:"changes"`
+	Before    JSON           `json:"before"`
+	After     JSON           `json:"after"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TodoHistory:
+//	    type: object
+//	    properties:
+//	      revisions:
+//	        type: array
+//	        description: The revisions of the Todo, the oldest first
+//	        items:
+//	          $ref: '#/components/schemas/TodoRevision'
+//	      next:
+//	        type: string
+//	        description: An identifier to use when requesting the next set of revisions
+//	        example: MDE5MDlhOGUtNjcwNi03NWY1LWJjMjUtNWM0MjY0ZjUwZTQ1
+type TodoHistory struct {
+	Revisions []TodoRevision `json:"revisions"`
+	Next      string         `json:"next"`
+}
@@ -0,0 +1,161 @@
+package types
+
+import "time"
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TodoStats:
+//	    type: object
+//	    properties:
+//	      from:
+//	        type: string
+//	        format: date-time
+//	        description: The start of the reported range, included
+//	        example: 2024-07-01T00:00:00Z
+//	      to:
+//	        type: string
+//	        format: date-time
+//	        description: The end of the reported range, excluded
+//	        example: 2024-08-01T00:00:00Z
+//	      interval:
+//	        type: string
+//	        description: The length of the periods
+//	        enum: [day, week]
+//	        example: day
+//	      periods:
+//	        type: array
+//	        description: The Todos created and completed in each period of the range, the oldest first. Days start at midnight UTC and weeks on Monday.
+//	        items:
+//	          $ref: '#/components/schemas/StatsPeriod'
+//	      created:
+//	        type: integer
+//	        description: The number of Todos created in the range
+//	        example: 12
+//	      completed:
+//	        type: integer
+//	        description: The number of Todos completed in the range
+//	        example: 9
+//	      averageCompletionSeconds:
+//	        type: integer
+//	        format: int64
+//	        description: The average time from the creation to the completion of the Todos completed in the range in seconds, missing when none was
+//	        example: 86400
+//	      total:
+//	        type: integer
+//	        description: The number of Todos
+//	        example: 42
+//	      open:
+//	        type: integer
+//	        description: The number of Todos that aren't done
+//	        example: 30
+//	      done:
+//	        type: integer
+//	        description: The number of Todos that are done
+//	        example: 12
+//	      overdue:
+//	        type: integer
+//	        description: The number of open Todos whose due time has passed
+//	        example: 3
+//	      byStatus:
+//	        type: array
+//	        description: The number of Todos with each status, the most Todos first
+//	        items:
+//	          $ref: '#/components/schemas/StatusStats'
+//	      byAssignee:
+//	        type: array
+//	        description: The Todos assigned to each user, the most Todos first
+//	        items:
+//	          $ref: '#/components/schemas/AssigneeStats'
+type TodoStats struct {
+	From                     time.Time       `json:"from"`
+	To                       time.Time       `json:"to"`
+	Interval                 string          `json:"interval"`
+	Periods                  []StatsPeriod   `json:"periods"`
+	Created                  int             `json:"created"`
+	Completed                int             `json:"completed"`
+	AverageCompletionSeconds *int64          `json:"averageCompletionSeconds,omitempty"`
+	Total                    int             `json:"total"`
+	Open                     int             `json:"open"`
+	Done                     int             `json:"done"`
+	Overdue                  int             `json:"overdue"`
+	ByStatus                 []StatusStats   `json:"byStatus"`
+	ByAssignee               []AssigneeStats `json:"byAssignee"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  StatsPeriod:
+//	    type: object
+//	    properties:
+//	      start:
+//	        type: string
+//	        format: date-time
+//	        description: The start of the period
+//	        example: 2024-07-01T00:00:00Z
+//	      created:
+//	        type: integer
+//	        description: The number of Todos created in the period
+//	        example: 2
+//	      completed:
+//	        type: integer
+//	        description: The number of Todos completed in the period
+//	        example: 1
+type StatsPeriod struct {
+	Start     time.Time `json:"start"`
+	Created   int       `json:"created"`
+	Completed int       `json:"completed"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  StatusStats:
+//	    type: object
+//	    properties:
+//	      status:
+//	        type: string
+//	        description: A status of the workflow
+//	        example: in_progress
+//	      count:
+//	        type: integer
+//	        description: The number of Todos with the status
+//	        example: 5
+type StatusStats struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  AssigneeStats:
+//	    type: object
+//	    properties:
+//	      assignee:
+//	        type: string
+//	        description: The user the Todos are assigned to
+//	        example: alice
+//	      open:
+//	        type: integer
+//	        description: The number of open Todos assigned to the user
+//	        example: 4
+//	      done:
+//	        type: integer
+//	        description: The number of done Todos assigned to the user
+//	        example: 7
+//	      overdue:
+//	        type: integer
+//	        description: The number of open Todos assigned to the user whose due time has passed
+//	        example: 1
+type AssigneeStats struct {
+	Assignee string `json:"assignee"`
+	Open     int    `json:"open"`
+	Done     int    `json:"done"`
+	Overdue  int    `json:"overdue"`
+}
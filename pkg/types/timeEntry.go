@@ -0,0 +1,173 @@
+package types
+
+import "time"
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TimeEntry:
+//	    type: object
+//	    properties:
+//	      id:
+//	        type: string
+//	        description: The TimeEntry's identifier
+//	        example: 01909a8e-6706-75f5-bc25-5c4264f50e49
+//	      todoId:
+//	        type: string
+//	        description: The identifier of the Todo the time was spent on
+//	        example: 01909a8e-6706-75f5-bc25-5c4264f50e41
+//	      author:
+//	        type: string
+//	        description: The user who logged the time, anonymous when unknown
+//	        example: alice
+//	      startedAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the work started
+//	        example: 2024-07-01T12:00:00Z
+//	      endedAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the work ended, missing while the timer is running
+//	        example: 2024-07-01T12:30:00Z
+//	      seconds:
+//	        type: integer
+//	        format: int64
+//	        description: The time spent in seconds, 0 while the timer is running
+//	        example: 1800
+//	      note:
+//	        type: string
+//	        description: What the time was spent on
+//	        example: Compared the opening hours
+//	      createdAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the TimeEntry was logged
+//	        example: 2024-07-01T12:30:00Z
+type TimeEntry struct {
+	Id        string     `json:"id"`
+	TodoId    string     `json:"todoId" gorm:"column:todoid"`
+	Author    string     `json:"author"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Seconds   int64      `json:"seconds"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt time.Time  `json:"createdAt" gorm:"autoCreateTime:false"`
+}
+
+// Running reports whether the entry is a timer that wasn't stopped yet
+func (e TimeEntry) Running() bool {
+	return e.EndedAt == nil
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TimeEntryCreate:
+//	    type: object
+//	    required:
+//	      - seconds
+//	    additionalProperties: false
+//	    properties:
+//	      seconds:
+//	        type: integer
+//	        format: int64
+//	        minimum: 1
+//	        maximum: 86400
+//	        description: The time spent in seconds
+//	        example: 1800
+//	      startedAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the work started, defaults to the time the entry is logged minus seconds
+//	        example: 2024-07-01T12:00:00Z
+//	      note:
+//	        type: string
+//	        maxLength: 1000
+//	        description: What the time was spent on
+//	        example: Compared the opening hours
+type TimeEntryCreate struct {
+	Seconds   int64      `json:"seconds"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	Note      string     `json:"note,omitempty"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TimeEntryList:
+//	    type: object
+//	    properties:
+//	      timeEntries:
+//	        type: array
+//	        description: The TimeEntries of the Todo, the oldest first
+//	        items:
+//	          $ref: '#/components/schemas/TimeEntry'
+//	      next:
+//	        type: string
+//	        description: An identifier to use when requesting the next set of time entries
+//	        example: MDE5MDlhOGUtNjcwNi03NWY1LWJjMjUtNWM0MjY0ZjUwZTQ5
+type TimeEntryList struct {
+	TimeEntries []TimeEntry `json:"timeEntries"`
+	Next        string      `json:"next"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TimeReport:
+//	    type: object
+//	    properties:
+//	      from:
+//	        type: string
+//	        format: date-time
+//	        description: The start of the reported range, included
+//	        example: 2024-07-01T00:00:00Z
+//	      to:
+//	        type: string
+//	        format: date-time
+//	        description: The end of the reported range, excluded
+//	        example: 2024-08-01T00:00:00Z
+//	      totalSeconds:
+//	        type: integer
+//	        format: int64
+//	        description: The time logged in the range in seconds
+//	        example: 5400
+//	      byUser:
+//	        type: array
+//	        description: The time logged by each user, the most time first
+//	        items:
+//	          $ref: '#/components/schemas/TimeTotal'
+//	      byTodo:
+//	        type: array
+//	        description: The time logged on each Todo, the most time first
+//	        items:
+//	          $ref: '#/components/schemas/TimeTotal'
+//	  TimeTotal:
+//	    type: object
+//	    properties:
+//	      key:
+//	        type: string
+//	        description: The user or the identifier of the Todo the time was logged by or on
+//	        example: alice
+//	      seconds:
+//	        type: integer
+//	        format: int64
+//	        description: The time logged in seconds
+//	        example: 3600
+type TimeReport struct {
+	From         time.Time   `json:"from"`
+	To           time.Time   `json:"to"`
+	TotalSeconds int64       `json:"totalSeconds"`
+	ByUser       []TimeTotal `json:"byUser"`
+	ByTodo       []TimeTotal `json:"byTodo"`
+}
+
+type TimeTotal struct {
+	Key     string `json:"key"`
+	Seconds int64  `json:"seconds"`
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"slices"
+	"time"
+)
+
 // @openapi
 // components:
 //
@@ -17,12 +22,96 @@ package types
 //	        example: Pick up the groceries
 //	      done:
 //	        type: boolean
-//	        description: An indicator that tells if the Todo item is complete
+//	        description: An indicator that tells if the Todo item is complete, derived from its status
 //	        example: false
+//	      status:
+//	        type: string
+//	        description: The Todo's status in the workflow configured by the service (todo, in_progress, blocked or done by default)
+//	        example: in_progress
+//	      due:
+//	        type: string
+//	        format: date-time
+//	        description: The time the Todo is due
+//	        example: 2024-07-02T17:00:00Z
+//	      priority:
+//	        type: integer
+//	        minimum: 0
+//	        maximum: 9
+//	        description: The Todo's priority from 1 (highest) to 9 (lowest), 0 means undefined
+//	        example: 5
+//	      assignees:
+//	        type: array
+//	        description: The users who should do the Todo
+//	        items:
+//	          type: string
+//	        example: [alice]
+//	      estimateSeconds:
+//	        type: integer
+//	        format: int64
+//	        minimum: 0
+//	        description: How long the Todo is expected to take in seconds, 0 means undefined
+//	        example: 3600
+//	      trackedSeconds:
+//	        type: integer
+//	        format: int64
+//	        description: The time logged against the Todo in seconds, leaving out running timers
+//	        readOnly: true
+//	        example: 1800
+//	      completedAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the Todo was marked as done
+//	        readOnly: true
+//	        example: 2024-07-02T16:30:00Z
+//	      createdAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the Todo was created
+//	        example: 2024-07-01T12:00:00Z
+//	      updatedAt:
+//	        type: string
+//	        format: date-time
+//	        description: The time the Todo was last changed
+//	        example: 2024-07-01T12:00:00Z
 type Todo struct {
-	Id      string `json:"id"`
-	Summary string `json:"summary"`
-	Done    bool   `json:"done"`
+	Id        string     `json:"id"`
+	Summary   string     `json:"summary"`
+	Done      bool       `json:"done"`
+	Status    string     `json:"status,omitempty"`
+	Due       *time.Time `json:"due,omitempty"`
+	Priority  int        `json:"priority,omitempty"`
+	Assignees Assignees  `json:"assignees,omitempty"`
+	// EstimateSeconds is stored, TrackedSeconds is summed from the time entries by the routes
+	EstimateSeconds int64      `json:"estimateSeconds,omitempty"`
+	TrackedSeconds  int64      `json:"trackedSeconds,omitempty" gorm:"-"`
+	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+	// Timestamps are set by the TodoService (using its clock) rather than by the database
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime:false"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime:false"`
+}
+
+// Equal reports whether t and other hold the same values, times are equal when they represent the
+// same instant regardless of their location
+func (t Todo) Equal(other Todo) bool {
+	return t.Id == other.Id &&
+		t.Summary == other.Summary &&
+		t.Done == other.Done &&
+		t.Status == other.Status &&
+		equalTimes(t.Due, other.Due) &&
+		t.Priority == other.Priority &&
+		slices.Equal(t.Assignees, other.Assignees) &&
+		t.EstimateSeconds == other.EstimateSeconds &&
+		t.TrackedSeconds == other.TrackedSeconds &&
+		equalTimes(t.CompletedAt, other.CompletedAt) &&
+		t.CreatedAt.Equal(other.CreatedAt) &&
+		t.UpdatedAt.Equal(other.UpdatedAt)
+}
+
+func equalTimes(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
 }
 
 // @openapi
@@ -31,6 +120,9 @@ type Todo struct {
 //	schemas:
 //	  TodoUpdate:
 //	    type: object
+//	    required:
+//	      - summary
+//	    additionalProperties: false
 //	    properties:
 //	      summary:
 //	        type: string
@@ -38,11 +130,48 @@ type Todo struct {
 //	        example: Pick up the groceries
 //	      done:
 //	        type: boolean
-//	        description: An indicator that tells if the Todo item is complete
+//	        description: An indicator that tells if the Todo item is complete, setting it alone moves the Todo to the first done status or back to the initial one
 //	        example: false
+//	      status:
+//	        type: string
+//	        minLength: 1
+//	        description: The Todo's status, which must be reachable from its current status. It sets done when both are sent.
+//	        example: in_progress
+//	      due:
+//	        type: string
+//	        format: date-time
+//	        nullable: true
+//	        description: The time the Todo is due
+//	        example: 2024-07-02T17:00:00Z
+//	      priority:
+//	        type: integer
+//	        minimum: 0
+//	        maximum: 9
+//	        description: The Todo's priority from 1 (highest) to 9 (lowest), 0 means undefined
+//	        example: 5
+//	      assignees:
+//	        type: array
+//	        description: The users who should do the Todo, who must be known to the service
+//	        maxItems: 20
+//	        items:
+//	          type: string
+//	          minLength: 1
+//	        example: [alice]
+//	      estimateSeconds:
+//	        type: integer
+//	        format: int64
+//	        minimum: 0
+//	        description: How long the Todo is expected to take in seconds, 0 means undefined
+//	        example: 3600
 type TodoUpdate struct {
-	Summary string `json:"summary"`
-	Done    bool   `json:"done"`
+	Summary   string     `json:"summary"`
+	Done      bool       `json:"done"`
+	Status    string     `json:"status,omitempty"`
+	Due       *time.Time `json:"due,omitempty"`
+	Priority  int        `json:"priority,omitempty"`
+	Assignees Assignees  `json:"assignees,omitempty"`
+	// EstimateSeconds is how long the todo is expected to take
+	EstimateSeconds int64 `json:"estimateSeconds,omitempty"`
 }
 
 // @openapi
@@ -60,7 +189,94 @@ type TodoUpdate struct {
 //	        type: string
 //	        description: An identifier to use when requesting the next set of todos
 //	        example: MDE5MDlhOGUtNjcwNi03NWY1LWJjMjUtNWM0MjY0ZjUwZTQ1
+//	      prev:
+//	        type: string
+//	        description: An identifier to use when requesting the previous set of todos, left out on the first page and on storage that can only page forward (DynamoDB), empty when the previous page is the first one
+//	        example: MDE5MDlhOGUtNjcwNi03NWY1LWJjMjUtNWM0MjY0ZjUwZTQx
+//	      totalCount:
+//	        type: integer
+//	        description: The total number of todos, only sent when requested with count=true
+//	        example: 342
 type TodoList struct {
-	Todos []Todo `json:"todos"`
-	Next  string `json:"next"`
+	Todos      []Todo  `json:"todos"`
+	Next       string  `json:"next"`
+	Prev       *string `json:"prev,omitempty"`
+	TotalCount *int    `json:"totalCount,omitempty"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  TodoSearchResult:
+//	    type: object
+//	    properties:
+//	      todo:
+//	        $ref: '#/components/schemas/Todo'
+//	      score:
+//	        type: number
+//	        description: The relevance of the todo, higher is more relevant. Scores are only comparable within a search.
+//	        example: 1.52
+//	      highlight:
+//	        type: string
+//	        description: The summary as HTML with the matching words surrounded by <mark> and </mark>, the rest of the summary is escaped so the marks are its only markup
+//	        example: Pick up the <mark>groceries</mark>
+//	  TodoSearchResults:
+//	    type: object
+//	    properties:
+//	      results:
+//	        type: array
+//	        description: The matching todos, the most relevant first
+//	        items:
+//	          $ref: '#/components/schemas/TodoSearchResult'
+type TodoSearchResult struct {
+	Todo      Todo    `json:"todo"`
+	Score     float64 `json:"score"`
+	Highlight string  `json:"highlight"`
+}
+
+type TodoSearchResults struct {
+	Results []TodoSearchResult `json:"results"`
+}
+
+// @openapi
+// components:
+//
+//	schemas:
+//	  ImportSummary:
+//	    type: object
+//	    properties:
+//	      created:
+//	        type: integer
+//	        description: The number of todos that were created
+//	        example: 10
+//	      overwritten:
+//	        type: integer
+//	        description: The number of existing todos that were overwritten
+//	        example: 2
+//	      skipped:
+//	        type: integer
+//	        description: The number of todos that were skipped because a todo with the same id exists
+//	        example: 1
+//	      failed:
+//	        type: integer
+//	        description: The number of records that couldn't be imported
+//	        example: 1
+//	      errors:
+//	        type: array
+//	        description: The reason each of the first 100 failed records couldn't be imported, failed counts every one of them
+//	        items:
+//	          type: string
+//	        example: ["record 4: summary is required"]
+//	      dryRun:
+//	        type: boolean
+//	        description: An indicator that tells if the import was a dry run that didn't change anything
+//	        example: false
+type ImportSummary struct {
+	Created     int      `json:"created"`
+	Overwritten int      `json:"overwritten"`
+	Skipped     int      `json:"skipped"`
+	Failed      int      `json:"failed"`
+	Errors      []string `json:"errors,omitempty"`
+	DryRun      bool     `json:"dryRun"`
 }